@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"github.com/joho/godotenv"
 	"os"
+	"strings"
 
 	"Library/internal/models"
 )
 
 var AppSettings models.Configs
 
+// minDownloadSigningKeyLen — минимальная длина ключа подписи ссылок на скачивание (256 бит).
+const minDownloadSigningKeyLen = 32
+
 // ReadSettings загружает .env и internal/config/configs.json
 func ReadSettings() error {
 	// 1) Подгружаем переменные из .env
@@ -30,13 +34,19 @@ func ReadSettings() error {
 		return errors.New("cannot parse configs.json: " + err.Error())
 	}
 
-	// 3) Перезаписываем PostgresParams из ENV
-	AppSettings.PostgresParams = models.PostgresParams{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		Database: os.Getenv("DB_NAME"),
+	// 3) Перезаписываем адрес и учётные данные PostgresParams из ENV
+	// (пул, TLS и реплики остаются из JSON; DB_SSLMODE и DB_REPLICA_HOSTS — необязательные)
+	pg := &AppSettings.PostgresParams
+	pg.Host = os.Getenv("DB_HOST")
+	pg.Port = os.Getenv("DB_PORT")
+	pg.User = os.Getenv("DB_USER")
+	pg.Password = os.Getenv("DB_PASSWORD")
+	pg.Database = os.Getenv("DB_NAME")
+	if v := os.Getenv("DB_SSLMODE"); v != "" {
+		pg.SSLMode = v
+	}
+	if v := os.Getenv("DB_REPLICA_HOSTS"); v != "" {
+		pg.ReplicaHosts = strings.Split(v, ",")
 	}
 
 	// Дополнительно проверяем, что всё не пусто
@@ -48,5 +58,10 @@ func ReadSettings() error {
 		return errors.New("missing one of DB_HOST/DB_PORT/DB_USER/DB_PASSWORD/DB_NAME in .env")
 	}
 
+	// С пустым ключом ссылки на скачивание e-книг может подделать кто угодно
+	if len(AppSettings.AuthParams.DownloadSigningKey) < minDownloadSigningKeyLen {
+		return fmt.Errorf("auth_params.download_signing_key must be at least %d bytes", minDownloadSigningKeyLen)
+	}
+
 	return nil
 }
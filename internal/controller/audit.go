@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"Library/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditActor собирает данные об инициаторе изменения для журнала аудита.
+func auditActor(c *gin.Context) models.AuditActor {
+	return models.AuditActor{
+		UserID:    middleware.GetUserID(c),
+		RequestID: middleware.GetRequestID(c),
+		IP:        c.ClientIP(),
+	}
+}
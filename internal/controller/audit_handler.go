@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Журнал аудита
+// @Description Возвращает административные изменения (новые сверху) с фильтрацией (Admin only)
+// @Tags        audit
+// @Produce     json
+// @Param       actor_id     query  int     false  "ID пользователя-инициатора"
+// @Param       action       query  string  false  "create | update | delete | restore | purge | revert"
+// @Param       entity_type  query  string  false  "book | author | user | publisher"
+// @Param       entity_id    query  int     false  "ID сущности"
+// @Param       from         query  string  false  "Начало периода (RFC3339)"
+// @Param       to           query  string  false  "Конец периода (RFC3339)"
+// @Param       limit        query  int     false  "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param       offset       query  int     false  "Смещение"
+// @Success     200 {array} models.AuditEvent
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /audit [get]
+func getAuditEvents(c *gin.Context) {
+	f := models.AuditFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+	}
+
+	for param, dst := range map[string]**int{"actor_id": &f.ActorID, "entity_id": &f.EntityID} {
+		if v := c.Query(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				logger.Warn.Printf("getAuditEvents: invalid %s %q", param, v)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*dst = &n
+		}
+	}
+	for param, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if v := c.Query(param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				logger.Warn.Printf("getAuditEvents: invalid %s %q", param, v)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + ", expected RFC3339"})
+				return
+			}
+			*dst = &t
+		}
+	}
+	f.Limit, _ = strconv.Atoi(c.Query("limit"))
+	f.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	events, err := service.GetAuditEvents(c.Request.Context(), f)
+	if err != nil {
+		handleServiceError(c, "getAuditEvents", err)
+		return
+	}
+	logger.Info.Printf("getAuditEvents: returned %d events", len(events))
+	c.JSON(http.StatusOK, events)
+}
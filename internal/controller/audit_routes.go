@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAuditRoutes монтирует просмотр журнала аудита (только admin).
+func RegisterAuditRoutes(r *gin.Engine) {
+	audit := r.Group("/audit", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		audit.GET("", getAuditEvents)
+	}
+}
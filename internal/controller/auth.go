@@ -40,7 +40,7 @@ func SignUp(c *gin.Context) {
 		// Role оставляем пустым
 	}
 
-	if err := service.CreateUser(&user); err != nil {
+	if err := service.CreateUser(c.Request.Context(), &user, auditActor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -72,7 +72,7 @@ func SignIn(c *gin.Context) {
 	}
 
 	// Аутентифицируем: внутри сервиса сравнение bcrypt и чтение role
-	user, err := service.AuthenticateUser(in.Username, in.Password)
+	user, err := service.AuthenticateUser(c.Request.Context(), in.Username, in.Password)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
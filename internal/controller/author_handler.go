@@ -15,11 +15,16 @@ import (
 // @Description Возвращает всех авторов
 // @Tags        authors
 // @Produce     json
+// @Param       include_deleted  query  bool  false  "Включить удалённых (только admin)"
 // @Success     200 {array} models.Author
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /authors [get]
 func getAllAuthors(c *gin.Context) {
-	authors, err := service.GetAllAuthors()
+	withDeleted, ok := includeDeleted(c, "getAllAuthors")
+	if !ok {
+		return
+	}
+	authors, err := service.GetAllAuthors(c.Request.Context(), withDeleted)
 	if err != nil {
 		logger.Error.Printf("getAllAuthors: service error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -34,7 +39,10 @@ func getAllAuthors(c *gin.Context) {
 // @Tags        authors
 // @Produce     json
 // @Param       id   path      int  true  "ID автора"
+// @Param       include_deleted  query  bool  false  "Включить удалённых (только admin)"
+// @Param       If-None-Match    header string false "ETag для условного запроса"
 // @Success     200  {object}  models.Author
+// @Success     304  {string}  string  "Not Modified"
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
 // @Router      /authors/{id} [get]
@@ -47,10 +55,16 @@ func getAuthorByID(c *gin.Context) {
 		return
 	}
 
-	author, err := service.GetAuthorByID(id)
+	withDeleted, ok := includeDeleted(c, "getAuthorByID")
+	if !ok {
+		return
+	}
+	author, err := service.GetAuthorByID(c.Request.Context(), id, withDeleted)
 	if err != nil {
-		logger.Error.Printf("getAuthorByID: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		handleServiceError(c, "getAuthorByID", err)
+		return
+	}
+	if notModified(c, setETag(c, author.Version, author)) {
 		return
 	}
 	logger.Info.Printf("getAuthorByID: returned author ID=%d name=%q", author.ID, author.Name)
@@ -58,12 +72,12 @@ func getAuthorByID(c *gin.Context) {
 }
 
 // @Summary     Создать автора
-// @Description Добавляет нового автора (Admin only)
+// @Description Добавляет нового автора; даты рождения и смерти — YYYY-MM-DD (Admin only)
 // @Tags        authors
 // @Security    ApiKeyAuth
 // @Accept      json
 // @Produce     json
-// @Param       author  body      models.Author  true  "Имя нового автора"
+// @Param       author  body      models.Author  true  "Профиль нового автора"
 // @Success     201     {object}  models.Author
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
@@ -76,26 +90,29 @@ func createAuthor(c *gin.Context) {
 		return
 	}
 
-	if err := service.CreateAuthor(&a); err != nil {
-		logger.Error.Printf("createAuthor: service error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.CreateAuthor(c.Request.Context(), &a, auditActor(c)); err != nil {
+		handleServiceError(c, "createAuthor", err)
 		return
 	}
 	logger.Info.Printf("createAuthor: created author ID=%d name=%q", a.ID, a.Name)
+	setETag(c, a.Version, a)
 	c.JSON(http.StatusCreated, a)
 }
 
 // @Summary     Обновить автора
-// @Description Меняет имя автора по ID (Admin only)
+// @Description Заменяет профиль автора по ID (Admin only)
 // @Tags        authors
 // @Security    ApiKeyAuth
 // @Accept      json
 // @Produce     json
 // @Param       id      path      int            true  "ID автора"
-// @Param       author  body      models.Author  true  "Новое имя автора"
+// @Param       author  body      models.Author  true  "Новый профиль автора"
+// @Param       If-Match  header  string  true  "ETag, полученный при чтении автора"
 // @Success     200     {object}  models.Author
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
+// @Failure     412 {object} models.ErrorResponse
+// @Failure     428 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Router      /authors/{id} [put]
 func updateAuthor(c *gin.Context) {
@@ -107,6 +124,11 @@ func updateAuthor(c *gin.Context) {
 		return
 	}
 
+	version, ok := requireIfMatch(c, "updateAuthor")
+	if !ok {
+		return
+	}
+
 	var a models.Author
 	if err := c.BindJSON(&a); err != nil {
 		logger.Error.Printf("updateAuthor: bind error for ID %d: %v", id, err)
@@ -114,12 +136,13 @@ func updateAuthor(c *gin.Context) {
 		return
 	}
 	a.ID = id
+	a.Version = version
 
-	if err := service.UpdateAuthor(&a); err != nil {
-		logger.Error.Printf("updateAuthor: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.UpdateAuthor(c.Request.Context(), &a, auditActor(c)); err != nil {
+		handleServiceError(c, "updateAuthor", err)
 		return
 	}
+	setETag(c, a.Version, a)
 	logger.Info.Printf("updateAuthor: updated author ID=%d name=%q", a.ID, a.Name)
 	c.JSON(http.StatusOK, a)
 }
@@ -144,9 +167,8 @@ func deleteAuthor(c *gin.Context) {
 		return
 	}
 
-	if err := service.DeleteAuthorByID(id); err != nil {
-		logger.Error.Printf("deleteAuthor: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.DeleteAuthorByID(c.Request.Context(), id, auditActor(c)); err != nil {
+		handleServiceError(c, "deleteAuthor", err)
 		return
 	}
 	logger.Info.Printf("deleteAuthor: deleted author ID=%d", id)
@@ -161,7 +183,7 @@ func searchAuthorsByName(c *gin.Context) {
 		return
 	}
 
-	authors, err := service.SearchAuthorsByName(fragment)
+	authors, err := service.SearchAuthorsByName(c.Request.Context(), fragment)
 	if err != nil {
 		logger.Error.Printf("searchAuthorsByName: service error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -171,3 +193,163 @@ func searchAuthorsByName(c *gin.Context) {
 	logger.Info.Printf("searchAuthorsByName: returned %d authors for fragment=%q", len(authors), fragment)
 	c.JSON(http.StatusOK, authors)
 }
+
+// @Summary     Восстановить автора
+// @Description Снимает пометку об удалении (требуется роль admin)
+// @Tags        authors
+// @Produce     json
+// @Param       id   path      int  true  "ID автора"
+// @Success     200  {object}  models.Author
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /authors/{id}/restore [post]
+func restoreAuthor(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("restoreAuthor: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		return
+	}
+
+	author, err := service.RestoreAuthorByID(c.Request.Context(), id, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "restoreAuthor", err)
+		return
+	}
+	logger.Info.Printf("restoreAuthor: restored author ID=%d", id)
+	setETag(c, author.Version, author)
+	c.JSON(http.StatusOK, author)
+}
+
+// @Summary     Частично обновить автора
+// @Description Применяет JSON Merge Patch (RFC 7396): меняются только переданные поля (требуется роль admin)
+// @Tags        authors
+// @Accept      application/merge-patch+json
+// @Produce     json
+// @Param       id        path    int     true  "ID автора"
+// @Param       If-Match  header  string  true  "ETag, полученный при чтении"
+// @Param       patch     body    object  true  "Изменяемые поля"
+// @Success     200  {object}  models.Author
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     412 {object} models.ErrorResponse
+// @Failure     415 {object} models.ErrorResponse
+// @Failure     428 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /authors/{id} [patch]
+func patchAuthor(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("patchAuthor: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		return
+	}
+
+	version, ok := requireIfMatch(c, "patchAuthor")
+	if !ok {
+		return
+	}
+	patch, ok := bindMergePatch(c, "patchAuthor")
+	if !ok {
+		return
+	}
+
+	author, err := service.PatchAuthor(c.Request.Context(), id, version, patch, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "patchAuthor", err)
+		return
+	}
+	setETag(c, author.Version, author)
+	logger.Info.Printf("patchAuthor: patched author ID=%d", id)
+	c.JSON(http.StatusOK, author)
+}
+
+type authorAliasesInput struct {
+	Aliases []models.AuthorAlias `json:"aliases" binding:"required"`
+}
+
+// @Summary     Задать альтернативные имена автора
+// @Description Заменяет набор псевдонимов и транслитераций автора; по ним работает /authors/search. kind: pseudonym, transliteration, alternate (Admin only)
+// @Tags        authors
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id     path      int                            true  "ID автора"
+// @Param       input  body      controller.authorAliasesInput  true  "Альтернативные имена"
+// @Success     200    {array}   models.AuthorAlias
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /authors/{id}/aliases [put]
+func setAuthorAliases(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("setAuthorAliases: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		return
+	}
+
+	var in authorAliasesInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("setAuthorAliases: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aliases, err := service.SetAuthorAliases(c.Request.Context(), id, in.Aliases, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "setAuthorAliases", err)
+		return
+	}
+	logger.Info.Printf("setAuthorAliases: author ID=%d now has %d aliases", id, len(aliases))
+	c.JSON(http.StatusOK, aliases)
+}
+
+type mergeAuthorsInput struct {
+	SourceIDs []int `json:"source_ids" binding:"required"`
+}
+
+// @Summary     Слить дубли автора
+// @Description Переносит книги авторов source_ids к автору {id} одной транзакцией; имена дублей становятся альтернативными именами, сами дубли помечаются удалёнными (Admin only)
+// @Tags        authors
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id     path      int                           true  "ID автора, в которого сливаются дубли"
+// @Param       input  body      controller.mergeAuthorsInput  true  "ID дублей"
+// @Success     200    {object}  models.Author
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /authors/{id}/merge [post]
+func mergeAuthors(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("mergeAuthors: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		return
+	}
+
+	var in mergeAuthorsInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("mergeAuthors: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	author, err := service.MergeAuthors(c.Request.Context(), id, in.SourceIDs, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "mergeAuthors", err)
+		return
+	}
+	setETag(c, author.Version, author)
+	logger.Info.Printf("mergeAuthors: merged %v into author ID=%d", in.SourceIDs, id)
+	c.JSON(http.StatusOK, author)
+}
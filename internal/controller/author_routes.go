@@ -7,8 +7,8 @@ import (
 
 func RegisterAuthorRoutes(r *gin.Engine) {
 	// публичные руты
-	r.GET("/authors", getAllAuthors)
-	r.GET("/authors/:id", getAuthorByID)
+	r.GET("/authors", middleware.OptionalJWT, getAllAuthors)
+	r.GET("/authors/:id", middleware.OptionalJWT, getAuthorByID)
 	r.GET("/authors/search", searchAuthorsByName)
 
 	// защищённые руты
@@ -16,7 +16,11 @@ func RegisterAuthorRoutes(r *gin.Engine) {
 	{
 		authBooks.POST("", createAuthor)
 		authBooks.PUT("/:id", updateAuthor)
+		authBooks.PATCH("/:id", patchAuthor)
 		authBooks.DELETE("/:id", deleteAuthor)
+		authBooks.POST("/:id/restore", restoreAuthor)
+		authBooks.PUT("/:id/aliases", setAuthorAliases)
+		authBooks.POST("/:id/merge", mergeAuthors)
 	}
 
 }
@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead — запас сверх лимита файла на заголовки частей и границы multipart-формы.
+const multipartOverhead = 64 << 10
+
+// formFile ограничивает тело запроса до разбора формы: c.FormFile буферизует всё тело целиком,
+// и лимит, проверяемый в сервисе, уже не защитил бы от гигабайтной загрузки.
+// Превышение отдаётся как 413, остальные ошибки формы — как 400.
+func formFile(c *gin.Context, handler string, limit int64) (*multipart.FileHeader, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+multipartOverhead)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			logger.Warn.Printf("%s: request body exceeds %d bytes", handler, tooLarge.Limit)
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errs.ErrFileTooLarge.Error()})
+			return nil, false
+		}
+		logger.Error.Printf("%s: form file error: %v", handler, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field 'file' is required"})
+		return nil, false
+	}
+	return fh, true
+}
+
+// serveBlob отдаёт содержимое файла книги с корректными заголовками.
+func serveBlob(c *gin.Context, f models.BookFile, rc io.ReadCloser, disposition string) {
+	defer rc.Close()
+	if c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", "public, max-age=86400")
+	}
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, f.Size, f.ContentType, rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("%s; filename=%q", disposition, f.FileName),
+	})
+}
+
+// @Summary     Загрузить обложку
+// @Description Загружает или заменяет обложку книги (multipart, поле file) и генерирует миниатюру (Admin only)
+// @Tags        files
+// @Accept      multipart/form-data
+// @Produce     json
+// @Param       id    path      int   true  "ID книги"
+// @Param       file  formData  file  true  "Изображение JPEG/PNG/GIF"
+// @Success     201   {object}  models.BookFile
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     413 {object} models.ErrorResponse
+// @Failure     415 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/cover [put]
+func uploadBookCover(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("uploadBookCover: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	fh, ok := formFile(c, "uploadBookCover", service.MaxCoverUploadBytes())
+	if !ok {
+		return
+	}
+	src, err := fh.Open()
+	if err != nil {
+		logger.Error.Printf("uploadBookCover: open error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer src.Close()
+
+	cover, err := service.UploadBookCover(c.Request.Context(), id, fh.Filename, src)
+	if err != nil {
+		handleServiceError(c, "uploadBookCover", err)
+		return
+	}
+	logger.Info.Printf("uploadBookCover: stored cover for book ID=%d", id)
+	c.JSON(http.StatusCreated, cover)
+}
+
+// @Summary     Обложка книги
+// @Description Возвращает обложку книги; с size=thumb — миниатюру
+// @Tags        files
+// @Produce     image/jpeg
+// @Param       id    path      int     true   "ID книги"
+// @Param       size  query     string  false  "thumb — миниатюра"
+// @Success     200 {file} file
+// @Success     304 {string} string "Not Modified"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /books/{id}/cover [get]
+func getBookCover(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getBookCover: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	cover, rc, err := service.OpenBookCover(c.Request.Context(), id, c.Query("size") == "thumb")
+	if err != nil {
+		handleServiceError(c, "getBookCover", err)
+		return
+	}
+	// Обложка заменяется по тому же адресу, поэтому кеш обязан перепроверять её по ETag (хешу содержимого)
+	c.Header("Cache-Control", "no-cache")
+	tag := `"` + cover.SHA256 + `"`
+	c.Header("ETag", tag)
+	if notModified(c, tag) {
+		rc.Close()
+		return
+	}
+	serveBlob(c, cover, rc, "inline")
+}
+
+// @Summary     Удалить обложку
+// @Description Удаляет обложку книги (Admin only)
+// @Tags        files
+// @Param       id   path      int  true  "ID книги"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/cover [delete]
+func deleteBookCover(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("deleteBookCover: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	if err := service.DeleteBookCover(c.Request.Context(), id); err != nil {
+		handleServiceError(c, "deleteBookCover", err)
+		return
+	}
+	logger.Info.Printf("deleteBookCover: deleted cover for book ID=%d", id)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Загрузить файл книги
+// @Description Прикрепляет к книге PDF или EPUB (multipart, поле file) (Admin only)
+// @Tags        files
+// @Accept      multipart/form-data
+// @Produce     json
+// @Param       id    path      int   true  "ID книги"
+// @Param       file  formData  file  true  "PDF/EPUB"
+// @Success     201   {object}  models.BookFile
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     413 {object} models.ErrorResponse
+// @Failure     415 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/files [post]
+func uploadBookFile(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("uploadBookFile: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	fh, ok := formFile(c, "uploadBookFile", service.MaxFileUploadBytes())
+	if !ok {
+		return
+	}
+	src, err := fh.Open()
+	if err != nil {
+		logger.Error.Printf("uploadBookFile: open error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer src.Close()
+
+	f, err := service.UploadBookFile(c.Request.Context(), id, fh.Filename, src)
+	if err != nil {
+		handleServiceError(c, "uploadBookFile", err)
+		return
+	}
+	logger.Info.Printf("uploadBookFile: stored file ID=%d for book ID=%d", f.ID, id)
+	c.JSON(http.StatusCreated, f)
+}
+
+// @Summary     Файлы книги
+// @Description Возвращает список файлов, прикреплённых к книге
+// @Tags        files
+// @Produce     json
+// @Param       id   path      int  true  "ID книги"
+// @Success     200 {array} models.BookFile
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /books/{id}/files [get]
+func getBookFiles(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getBookFiles: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	files, err := service.GetBookFiles(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getBookFiles", err)
+		return
+	}
+	logger.Info.Printf("getBookFiles: returned %d files for book ID=%d", len(files), id)
+	c.JSON(http.StatusOK, files)
+}
+
+// @Summary     Скачать файл книги
+// @Description Прямое скачивание файла (Admin only); читатели получают файлы через цифровую выдачу
+// @Tags        files
+// @Produce     octet-stream
+// @Param       id      path      int  true  "ID книги"
+// @Param       fileId  path      int  true  "ID файла"
+// @Success     200 {file} file
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/files/{fileId} [get]
+func downloadBookFile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+	fileID, err := strconv.Atoi(c.Param("fileId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file ID"})
+		return
+	}
+
+	f, rc, err := service.OpenBookFile(c.Request.Context(), id, fileID)
+	if err != nil {
+		handleServiceError(c, "downloadBookFile", err)
+		return
+	}
+	logger.Info.Printf("downloadBookFile: serving file ID=%d for book ID=%d", f.ID, id)
+	serveBlob(c, f, rc, "attachment")
+}
+
+// @Summary     Удалить файл книги
+// @Description Удаляет файл книги (Admin only)
+// @Tags        files
+// @Param       id      path      int  true  "ID книги"
+// @Param       fileId  path      int  true  "ID файла"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/files/{fileId} [delete]
+func deleteBookFile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+	fileID, err := strconv.Atoi(c.Param("fileId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file ID"})
+		return
+	}
+
+	if err := service.DeleteBookFile(c.Request.Context(), id, fileID); err != nil {
+		handleServiceError(c, "deleteBookFile", err)
+		return
+	}
+	logger.Info.Printf("deleteBookFile: deleted file ID=%d for book ID=%d", fileID, id)
+	c.Status(http.StatusNoContent)
+}
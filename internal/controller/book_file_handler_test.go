@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func discardLogs() {
+	l := log.New(io.Discard, "", 0)
+	logger.Info, logger.Error, logger.Warn, logger.Debug = l, l, l, l
+}
+
+func multipartBody(t *testing.T, field string, size int) (*bytes.Buffer, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile(field, "book.pdf")
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	if _, err := part.Write(bytes.Repeat([]byte{'x'}, size)); err != nil {
+		t.Fatalf("write part: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close writer: %v", err)
+	}
+	return &buf, w.FormDataContentType()
+}
+
+func TestFormFile(t *testing.T) {
+	discardLogs()
+	gin.SetMode(gin.TestMode)
+
+	const limit = 1 << 20
+	tests := []struct {
+		name     string
+		field    string
+		size     int
+		wantOK   bool
+		wantCode int
+	}{
+		{name: "within limit", field: "file", size: limit, wantOK: true},
+		{name: "over limit", field: "file", size: limit + 2*multipartOverhead, wantCode: http.StatusRequestEntityTooLarge},
+		{name: "missing field", field: "other", size: 10, wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(t, tt.field, tt.size)
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPost, "/books/1/files", body)
+			c.Request.Header.Set("Content-Type", contentType)
+
+			fh, ok := formFile(c, "TestFormFile", limit)
+			if ok != tt.wantOK {
+				t.Fatalf("formFile() ok = %v, want %v (status %d: %s)", ok, tt.wantOK, rec.Code, rec.Body)
+			}
+			if ok {
+				if fh.Size != int64(tt.size) {
+					t.Errorf("file size = %d, want %d", fh.Size, tt.size)
+				}
+				return
+			}
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantCode, rec.Body)
+			}
+		})
+	}
+}
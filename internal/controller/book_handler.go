@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -12,18 +13,45 @@ import (
 )
 
 // @Summary     Список книг
-// @Description Возвращает все книги с вложенным именем автора
+// @Description Возвращает книги с вложенным именем автора и издателя, с фильтрацией по выходным данным
 // @Tags        books
 // @Produce     json
+// @Param       include_deleted  query  bool    false  "Включить удалённые (только admin)"
+// @Param       publisher_id     query  int     false  "ID издателя"
+// @Param       language         query  string  false  "Код языка ISO 639"
+// @Param       year_from        query  int     false  "Год издания не раньше"
+// @Param       year_to          query  int     false  "Год издания не позже"
+// @Param       sort             query  string  false  "id (по умолчанию) | shelf — порядок расстановки по индексу Дьюи/УДК"
 // @Success     200 {array} models.Book
+// @Failure     400 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Security    ApiKeyAuth
 // @Router      /books [get]
 func getAllBooks(c *gin.Context) {
-	books, err := service.GetAllBooks()
+	withDeleted, ok := includeDeleted(c, "getAllBooks")
+	if !ok {
+		return
+	}
+	f := models.BookFilter{
+		IncludeDeleted: withDeleted,
+		Language:       c.Query("language"),
+		Sort:           c.Query("sort"),
+	}
+	for param, dst := range map[string]**int{"publisher_id": &f.PublisherID, "year_from": &f.YearFrom, "year_to": &f.YearTo} {
+		if v := c.Query(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				logger.Warn.Printf("getAllBooks: invalid %s %q", param, v)
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param})
+				return
+			}
+			*dst = &n
+		}
+	}
+
+	books, err := service.GetAllBooks(c.Request.Context(), f)
 	if err != nil {
-		logger.Error.Printf("getAllBooks: service error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleServiceError(c, "getAllBooks", err)
 		return
 	}
 	logger.Info.Printf("getAllBooks: returned %d books", len(books))
@@ -31,12 +59,18 @@ func getAllBooks(c *gin.Context) {
 }
 
 // @Summary     Книга по ID
-// @Description Возвращает книгу и имя автора по её ID
+// @Description Возвращает книгу и имя автора по её ID; next_in_series — следующие книги в сериях,
+// @Description они же отдаются заголовком Link с rel="next"
 // @Tags        books
 // @Produce     json
 // @Param       id   path      int  true  "ID книги"
+// @Param       include_deleted  query  bool  false  "Включить удалённые (только admin)"
+// @Param       as_of            query  string false "Момент времени RFC3339: состояние книги на этот момент (только admin)"
+// @Param       If-None-Match    header string false "ETag для условного запроса"
 // @Success     200  {object}  models.Book
+// @Success     304  {string}  string  "Not Modified"
 // @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Security    ApiKeyAuth
@@ -50,20 +84,42 @@ func getBookByID(c *gin.Context) {
 		return
 	}
 
-	book, err := service.GetBookByID(id)
+	if c.Query("as_of") != "" {
+		getBookAsOf(c, id)
+		return
+	}
+
+	withDeleted, ok := includeDeleted(c, "getBookByID")
+	if !ok {
+		return
+	}
+	book, err := service.GetBookByID(c.Request.Context(), id, withDeleted)
 	if err != nil {
-		logger.Error.Printf("getBookByID: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		handleServiceError(c, "getBookByID", err)
+		return
+	}
+	if notModified(c, setETag(c, book.Version, book)) {
 		return
 	}
+	for _, next := range book.NextInSeries {
+		c.Writer.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"; title=%q`, next.Href, next.SeriesName))
+	}
 	logger.Info.Printf("getBookByID: returned book ID=%d title=%q", book.ID, book.Title)
 	c.JSON(http.StatusOK, book)
 }
 
 type createBookInput struct {
-	Name     string `json:"name"     binding:"required"`
-	Title    string `json:"title"    binding:"required"`
-	AuthorID int    `json:"author_id" binding:"required"`
+	Name          string  `json:"name"     binding:"required"`
+	Title         string  `json:"title"    binding:"required"`
+	AuthorID      int     `json:"author_id" binding:"required"`
+	PublisherID   *int    `json:"publisher_id"`
+	PublishedYear *int    `json:"published_year"`
+	Edition       *string `json:"edition"`
+	Language      *string `json:"language"`
+	PageCount     *int    `json:"page_count"`
+	Format        *string `json:"format"`
+	DeweyClass    *string `json:"dewey_class"`
+	UDCClass      *string `json:"udc_class"`
 }
 
 // @Summary     Создать книгу
@@ -86,18 +142,26 @@ func createBook(c *gin.Context) {
 	}
 
 	b := models.Book{
-		Name:     in.Name,
-		Title:    in.Title,
-		AuthorID: in.AuthorID,
+		Name:          in.Name,
+		Title:         in.Title,
+		AuthorID:      in.AuthorID,
+		PublisherID:   in.PublisherID,
+		PublishedYear: in.PublishedYear,
+		Edition:       in.Edition,
+		Language:      in.Language,
+		PageCount:     in.PageCount,
+		Format:        in.Format,
+		DeweyClass:    in.DeweyClass,
+		UDCClass:      in.UDCClass,
 	}
 
-	if err := service.CreateBook(&b); err != nil {
-		logger.Error.Printf("createBook: service error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.CreateBook(c.Request.Context(), &b, auditActor(c)); err != nil {
+		handleServiceError(c, "createBook", err)
 		return
 	}
 
 	logger.Info.Printf("createBook: created book ID=%d title=%q", b.ID, b.Title)
+	setETag(c, b.Version, b)
 	c.JSON(http.StatusCreated, b)
 }
 
@@ -107,10 +171,13 @@ func createBook(c *gin.Context) {
 // @Accept      json
 // @Produce     json
 // @Param       id    path      int                        true  "ID книги"
+// @Param       If-Match  header  string  true  "ETag, полученный при чтении книги"
 
 // @Success     200   {object}  models.Book
 // @Failure     400 {object} models.ErrorResponse
 // @Failure     404 {object} models.ErrorResponse
+// @Failure     412 {object} models.ErrorResponse
+// @Failure     428 {object} models.ErrorResponse
 // @Failure     500 {object} models.ErrorResponse
 // @Security    ApiKeyAuth
 // @Router      /books/{id} [put]
@@ -123,6 +190,11 @@ func updateBook(c *gin.Context) {
 		return
 	}
 
+	version, ok := requireIfMatch(c, "updateBook")
+	if !ok {
+		return
+	}
+
 	var b models.Book
 	if err := c.BindJSON(&b); err != nil {
 		logger.Error.Printf("updateBook: bind error for ID %d: %v", id, err)
@@ -130,12 +202,13 @@ func updateBook(c *gin.Context) {
 		return
 	}
 	b.ID = id
+	b.Version = version
 
-	if err := service.UpdateBook(&b); err != nil {
-		logger.Error.Printf("updateBook: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.UpdateBook(c.Request.Context(), &b, auditActor(c)); err != nil {
+		handleServiceError(c, "updateBook", err)
 		return
 	}
+	setETag(c, b.Version, b)
 	logger.Info.Printf("updateBook: updated book ID=%d title=%q", b.ID, b.Title)
 	c.JSON(http.StatusOK, b)
 }
@@ -160,9 +233,8 @@ func deleteBook(c *gin.Context) {
 		return
 	}
 
-	if err := service.DeleteBookByID(id); err != nil {
-		logger.Error.Printf("deleteBook: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.DeleteBookByID(c.Request.Context(), id, auditActor(c)); err != nil {
+		handleServiceError(c, "deleteBook", err)
 		return
 	}
 	logger.Info.Printf("deleteBook: deleted book ID=%d", id)
@@ -187,7 +259,7 @@ func searchBooksByName(c *gin.Context) {
 		return
 	}
 
-	books, err := service.SearchBooksByName(fragment)
+	books, err := service.SearchBooksByName(c.Request.Context(), fragment)
 	if err != nil {
 		logger.Error.Printf("searchBooksByName: service error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -197,3 +269,78 @@ func searchBooksByName(c *gin.Context) {
 	logger.Info.Printf("searchBooksByName: returned %d books for fragment=%q", len(books), fragment)
 	c.JSON(http.StatusOK, books)
 }
+
+// @Summary     Восстановить книгу
+// @Description Снимает пометку об удалении (требуется роль admin)
+// @Tags        books
+// @Produce     json
+// @Param       id   path      int  true  "ID книги"
+// @Success     200  {object}  models.Book
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/restore [post]
+func restoreBook(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("restoreBook: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	book, err := service.RestoreBookByID(c.Request.Context(), id, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "restoreBook", err)
+		return
+	}
+	logger.Info.Printf("restoreBook: restored book ID=%d", id)
+	setETag(c, book.Version, book)
+	c.JSON(http.StatusOK, book)
+}
+
+// @Summary     Частично обновить книгу
+// @Description Применяет JSON Merge Patch (RFC 7396): меняются только переданные поля (требуется роль admin)
+// @Tags        books
+// @Accept      application/merge-patch+json
+// @Produce     json
+// @Param       id        path    int     true  "ID книги"
+// @Param       If-Match  header  string  true  "ETag, полученный при чтении"
+// @Param       patch     body    object  true  "Изменяемые поля"
+// @Success     200  {object}  models.Book
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     412 {object} models.ErrorResponse
+// @Failure     415 {object} models.ErrorResponse
+// @Failure     428 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id} [patch]
+func patchBook(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("patchBook: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	version, ok := requireIfMatch(c, "patchBook")
+	if !ok {
+		return
+	}
+	patch, ok := bindMergePatch(c, "patchBook")
+	if !ok {
+		return
+	}
+
+	book, err := service.PatchBook(c.Request.Context(), id, version, patch, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "patchBook", err)
+		return
+	}
+	setETag(c, book.Version, book)
+	logger.Info.Printf("patchBook: patched book ID=%d", id)
+	c.JSON(http.StatusOK, book)
+}
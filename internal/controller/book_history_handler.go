@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"Library/internal/middleware"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getBookAsOf отдаёт состояние книги на момент ?as_of (вызывается из getBookByID).
+// История может содержать удалённые данные, поэтому доступна только администратору.
+func getBookAsOf(c *gin.Context, id int) {
+	if middleware.GetUserRole(c) != "admin" {
+		logger.Warn.Println("getBookAsOf: as_of requested by non-admin")
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
+		return
+	}
+	asOf, err := time.Parse(time.RFC3339, c.Query("as_of"))
+	if err != nil {
+		logger.Warn.Printf("getBookAsOf: invalid as_of %q: %v", c.Query("as_of"), err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "as_of must be an RFC3339 timestamp"})
+		return
+	}
+
+	book, err := service.GetBookAsOf(c.Request.Context(), id, asOf)
+	if err != nil {
+		handleServiceError(c, "getBookAsOf", err)
+		return
+	}
+	logger.Info.Printf("getBookAsOf: returned book ID=%d version=%d as of %s", book.ID, book.Version, asOf.Format(time.RFC3339))
+	c.JSON(http.StatusOK, book)
+}
+
+// @Summary     История книги
+// @Description Возвращает все сохранённые версии записи книги, начиная с последней (требуется роль admin)
+// @Tags        books
+// @Produce     json
+// @Param       id   path      int  true  "ID книги"
+// @Success     200  {array}   models.BookHistory
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/history [get]
+func getBookHistory(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getBookHistory: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	history, err := service.GetBookHistory(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getBookHistory", err)
+		return
+	}
+	logger.Info.Printf("getBookHistory: returned %d versions for book ID=%d", len(history), id)
+	c.JSON(http.StatusOK, history)
+}
+
+// @Summary     Откатить книгу к версии
+// @Description Возвращает поля книги к указанной версии, создавая новую версию (требуется роль admin)
+// @Tags        books
+// @Produce     json
+// @Param       id        path    int     true   "ID книги"
+// @Param       version   path    int     true   "Версия из истории"
+// @Param       If-Match  header  string  false  "ETag текущей версии"
+// @Success     200  {object}  models.Book
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     412 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/revert/{version} [post]
+func revertBook(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("revertBook: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+	versionParam := c.Param("version")
+	toVersion, err := strconv.Atoi(versionParam)
+	if err != nil {
+		logger.Error.Printf("revertBook: invalid version param %q: %v", versionParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+		return
+	}
+
+	// If-Match здесь необязателен: откат и так явно указывает целевую версию.
+	expected := 0
+	if c.GetHeader("If-Match") != "" {
+		var ok bool
+		if expected, ok = requireIfMatch(c, "revertBook"); !ok {
+			return
+		}
+	}
+
+	book, err := service.RevertBook(c.Request.Context(), id, toVersion, expected, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "revertBook", err)
+		return
+	}
+	setETag(c, book.Version, book)
+	logger.Info.Printf("revertBook: book ID=%d reverted to version=%d", id, toVersion)
+	c.JSON(http.StatusOK, book)
+}
@@ -8,16 +8,29 @@ import (
 // RegisterBookRoutes монтирует маршруты для работы с книгами.
 func RegisterBookRoutes(r *gin.Engine) {
 	// публичные руты
-	r.GET("/books", getAllBooks)
-	r.GET("/books/:id", getBookByID)
+	r.GET("/books", middleware.OptionalJWT, getAllBooks)
+	r.GET("/books/:id", middleware.OptionalJWT, getBookByID)
 	r.GET("/books/search", searchBooksByName)
+	r.GET("/books/:id/cover", getBookCover)
+	r.GET("/books/:id/files", getBookFiles)
 
 	// защищённые руты
 	authBooks := r.Group("/books", middleware.JWTAuthMiddleware, middleware.AdminOnly)
 	{
 		authBooks.POST("", createBook)
 		authBooks.PUT("/:id", updateBook)
+		authBooks.PATCH("/:id", patchBook)
 		authBooks.DELETE("/:id", deleteBook)
+		authBooks.POST("/:id/restore", restoreBook)
+		authBooks.GET("/:id/history", getBookHistory)
+		authBooks.POST("/:id/revert/:version", revertBook)
+
+		authBooks.PUT("/:id/cover", uploadBookCover)
+		authBooks.DELETE("/:id/cover", deleteBookCover)
+		authBooks.POST("/:id/files", uploadBookFile)
+		// читатели скачивают файлы только через цифровую выдачу (/books/:id/checkout)
+		authBooks.GET("/:id/files/:fileId", downloadBookFile)
+		authBooks.DELETE("/:id/files/:fileId", deleteBookFile)
 	}
 
 }
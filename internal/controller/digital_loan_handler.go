@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/middleware"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Взять e-книгу
+// @Description Выдаёт читателю электронную книгу и подписанные ссылки на скачивание
+// @Tags        digital
+// @Produce     json
+// @Param       id   path      int  true  "ID книги"
+// @Success     201 {object} models.DigitalLoanWithLinks
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/checkout [post]
+func checkoutDigitalBook(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("checkoutDigitalBook: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	loan, err := service.CheckoutDigitalBook(c.Request.Context(), id, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "checkoutDigitalBook", err)
+		return
+	}
+	logger.Info.Printf("checkoutDigitalBook: created loan ID=%d for book ID=%d", loan.ID, id)
+	c.JSON(http.StatusCreated, loan)
+}
+
+// @Summary     Доступность e-книги
+// @Description Возвращает размер пула лицензий и число свободных
+// @Tags        digital
+// @Produce     json
+// @Param       id   path      int  true  "ID книги"
+// @Success     200 {object} models.DigitalAvailability
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /books/{id}/availability [get]
+func getDigitalAvailability(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getDigitalAvailability: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	a, err := service.GetDigitalAvailability(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getDigitalAvailability", err)
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+type setLicensesInput struct {
+	LicenseCount *int `json:"license_count" binding:"required"`
+}
+
+// @Summary     Лицензии на e-книгу
+// @Description Задаёт число одновременных выдач издания (Admin only)
+// @Tags        digital
+// @Accept      json
+// @Produce     json
+// @Param       id     path  int               true  "ID книги"
+// @Param       input  body  setLicensesInput  true  "Число лицензий"
+// @Success     200 {object} models.DigitalAvailability
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /books/{id}/licenses [put]
+func setDigitalLicenses(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("setDigitalLicenses: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var in setLicensesInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := service.SetDigitalLicenseCount(c.Request.Context(), id, *in.LicenseCount); err != nil {
+		handleServiceError(c, "setDigitalLicenses", err)
+		return
+	}
+	a, err := service.GetDigitalAvailability(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "setDigitalLicenses", err)
+		return
+	}
+	logger.Info.Printf("setDigitalLicenses: book ID=%d licenses=%d", id, *in.LicenseCount)
+	c.JSON(http.StatusOK, a)
+}
+
+// @Summary     Мои e-книги
+// @Description Действующие выдачи текущего пользователя со свежими ссылками
+// @Tags        digital
+// @Produce     json
+// @Success     200 {array} models.DigitalLoanWithLinks
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /me/loans [get]
+func getMyDigitalLoans(c *gin.Context) {
+	loans, err := service.GetMyDigitalLoans(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getMyDigitalLoans", err)
+		return
+	}
+	c.JSON(http.StatusOK, loans)
+}
+
+// @Summary     Вернуть e-книгу
+// @Description Досрочно возвращает лицензию в пул
+// @Tags        digital
+// @Param       id   path      int  true  "ID выдачи"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /me/loans/{id}/return [post]
+func returnDigitalBook(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("returnDigitalBook: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid loan ID"})
+		return
+	}
+
+	if err := service.ReturnDigitalBook(c.Request.Context(), id, middleware.GetUserID(c)); err != nil {
+		handleServiceError(c, "returnDigitalBook", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Скачать e-книгу
+// @Description Скачивание по подписанной ссылке из выдачи; авторизация не требуется
+// @Tags        digital
+// @Produce     octet-stream
+// @Param       loan     query  int     true  "ID выдачи"
+// @Param       file     query  int     true  "ID файла"
+// @Param       expires  query  int     true  "Unix-время истечения ссылки"
+// @Param       sig      query  string  true  "HMAC-подпись"
+// @Success     200 {file} file
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     410 {object} models.ErrorResponse
+// @Router      /digital/download [get]
+func downloadDigitalBook(c *gin.Context) {
+	loanID, err1 := strconv.Atoi(c.Query("loan"))
+	fileID, err2 := strconv.Atoi(c.Query("file"))
+	expires, err3 := strconv.ParseInt(c.Query("expires"), 10, 64)
+	sig := c.Query("sig")
+	if err1 != nil || err2 != nil || err3 != nil || sig == "" {
+		logger.Warn.Println("downloadDigitalBook: malformed download link")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "malformed download link"})
+		return
+	}
+
+	f, rc, err := service.OpenDigitalDownload(c.Request.Context(), loanID, fileID, expires, sig)
+	if err != nil {
+		handleServiceError(c, "downloadDigitalBook", err)
+		return
+	}
+	logger.Info.Printf("downloadDigitalBook: serving file ID=%d for loan ID=%d", f.ID, loanID)
+	c.Header("Cache-Control", "private, no-store")
+	serveBlob(c, f, rc, "attachment")
+}
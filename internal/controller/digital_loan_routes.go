@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterDigitalLoanRoutes монтирует маршруты цифровой выдачи e-книг.
+func RegisterDigitalLoanRoutes(r *gin.Engine) {
+	// публичные руты
+	r.GET("/books/:id/availability", getDigitalAvailability)
+	r.GET("/digital/download", downloadDigitalBook) // доступ по подписанной ссылке
+
+	// руты для авторизованных читателей
+	patron := r.Group("", middleware.JWTAuthMiddleware)
+	{
+		patron.POST("/books/:id/checkout", checkoutDigitalBook)
+		patron.GET("/me/loans", getMyDigitalLoans)
+		patron.POST("/me/loans/:id/return", returnDigitalBook)
+	}
+
+	// защищённые руты
+	admin := r.Group("/books", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		admin.PUT("/:id/licenses", setDigitalLicenses)
+	}
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"Library/internal/errs"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleServiceError логирует ошибку сервиса и отвечает подходящим HTTP-статусом.
+func handleServiceError(c *gin.Context, handler string, err error) {
+	logger.Error.Printf("%s: service error: %v", handler, err)
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, errs.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errs.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, errs.ErrFileTooLarge), errors.Is(err, errs.ErrImageTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, errs.ErrUnsupportedMediaType):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, errs.ErrNoLicensesAvailable), errors.Is(err, errs.ErrAlreadyBorrowed),
+		errors.Is(err, errs.ErrAuthorHasBooks), errors.Is(err, errs.ErrAuthorDeleted), errors.Is(err, errs.ErrPublisherHasBooks),
+		errors.Is(err, errs.ErrSubjectHasChildren), errors.Is(err, errs.ErrSubjectCycle),
+		errors.Is(err, errs.ErrSeriesPositionTaken), errors.Is(err, errs.ErrReviewExists),
+		errors.Is(err, errs.ErrListNameTaken), errors.Is(err, errs.ErrTaskNotRetryable):
+		status = http.StatusConflict
+	case errors.Is(err, errs.ErrVersionMismatch):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, errs.ErrInvalidSignature), errors.Is(err, errs.ErrNotReviewAuthor):
+		status = http.StatusForbidden
+	case errors.Is(err, errs.ErrLoanExpired):
+		status = http.StatusGone
+	case errors.Is(err, errs.ErrShuttingDown):
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
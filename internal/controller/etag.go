@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag формирует значение ETag вида "<версия>-<хеш>". Версия нужна для If-Match, а хеш
+// представления — чтобы If-None-Match учитывал и связанные данные (имя автора, следующую
+// книгу серии, рейтинг), которые меняются без изменения версии строки.
+func etag(version int, body interface{}) string {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return `"` + strconv.Itoa(version) + `"`
+	}
+	sum := sha256.Sum256(raw)
+	return `"` + strconv.Itoa(version) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// setETag отдаёт клиенту ETag текущего представления ресурса и возвращает его.
+func setETag(c *gin.Context, version int, body interface{}) string {
+	tag := etag(version, body)
+	c.Header("ETag", tag)
+	return tag
+}
+
+// notModified отвечает 304, если клиент прислал If-None-Match с актуальным ETag.
+func notModified(c *gin.Context, current string) bool {
+	inm := c.GetHeader("If-None-Match")
+	if inm == "" {
+		return false
+	}
+	for _, tag := range strings.Split(inm, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// requireIfMatch извлекает ожидаемую версию из If-Match: ETag целиком ("<версия>-<хеш>")
+// или только версию ("<версия>"). Без заголовка отвечает 428, "*" означает «любая версия» (0).
+// Второе значение false, если ответ уже отправлен.
+func requireIfMatch(c *gin.Context, handler string) (int, bool) {
+	im := strings.TrimSpace(c.GetHeader("If-Match"))
+	if im == "" {
+		logger.Warn.Printf("%s: missing If-Match header", handler)
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return 0, false
+	}
+	if im == "*" {
+		return 0, true
+	}
+	// Клиент может вернуть ETag в слабой форме W/"…" — версия в нём та же
+	raw := strings.Trim(strings.TrimPrefix(im, "W/"), `"`)
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		raw = raw[:i]
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v <= 0 {
+		logger.Warn.Printf("%s: unparsable If-Match %q", handler, im)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match current version"})
+		return 0, false
+	}
+	return v, true
+}
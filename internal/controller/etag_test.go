@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	discardLogs()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		ifMatch  string
+		want     int
+		wantOK   bool
+		wantCode int
+	}{
+		{name: "strong etag", ifMatch: `"7-0123456789abcdef"`, want: 7, wantOK: true},
+		{name: "weak etag", ifMatch: `W/"7-0123456789abcdef"`, want: 7, wantOK: true},
+		{name: "version only", ifMatch: `"12"`, want: 12, wantOK: true},
+		{name: "unquoted version", ifMatch: `3`, want: 3, wantOK: true},
+		{name: "any version", ifMatch: `*`, want: 0, wantOK: true},
+		{name: "missing", ifMatch: ``, wantCode: http.StatusPreconditionRequired},
+		{name: "garbage", ifMatch: `"abc"`, wantCode: http.StatusPreconditionFailed},
+		{name: "zero version", ifMatch: `"0"`, wantCode: http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPut, "/books/1", nil)
+			if tt.ifMatch != "" {
+				c.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			got, ok := requireIfMatch(c, "TestRequireIfMatch")
+			if ok != tt.wantOK {
+				t.Fatalf("requireIfMatch(%q) ok = %v, want %v", tt.ifMatch, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("requireIfMatch(%q) = %d, want %d", tt.ifMatch, got, tt.want)
+			}
+			if !ok && rec.Code != tt.wantCode {
+				t.Errorf("requireIfMatch(%q) status = %d, want %d", tt.ifMatch, rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Library/internal/middleware"
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat — период комментариев-пингов, чтобы прокси не закрывали простаивающее соединение.
+const streamHeartbeat = 25 * time.Second
+
+// @Summary     Поток изменений каталога
+// @Description Server-Sent Events с изменениями книг, авторов и пользователей (book.created, author.updated, user.deleted…). id события — Last-Event-ID: при переподключении с этим заголовком поток продолжится с места обрыва. События о пользователях администратор видит все, читатель — только о себе
+// @Tags        events
+// @Security    ApiKeyAuth
+// @Produce     text/event-stream
+// @Param       types          query   string  false  "Типы событий через запятую, маски book.* и * (по умолчанию book.*,author.*,user.*)"
+// @Param       Last-Event-ID  header  int     false  "ID последнего полученного события"
+// @Param       last_event_id  query   int     false  "То же, что Last-Event-ID, для клиентов без доступа к заголовкам"
+// @Success     200 {object} models.StreamEvent
+// @Failure     400 {object} models.ErrorResponse
+// @Router      /events/stream [get]
+func streamEvents(c *gin.Context) {
+	types, err := service.ParseStreamTypes(c.Query("types"))
+	if err != nil {
+		handleServiceError(c, "streamEvents", err)
+		return
+	}
+	lastID, ok := lastEventIDParam(c)
+	if !ok {
+		return
+	}
+	userID, role := middleware.GetUserID(c), middleware.GetUserRole(c)
+
+	// Подписываемся до чтения истории, чтобы не пропустить события между ними
+	sub := service.SubscribeEvents()
+	defer service.UnsubscribeEvents(sub)
+
+	// Поток живёт дольше WriteTimeout сервера, поэтому снимаем для него дедлайн записи
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn.Printf("streamEvents: cannot clear write deadline: %v", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+
+	send := func(ev models.StreamEvent) bool {
+		if ev.ID <= lastID {
+			return true
+		}
+		lastID = ev.ID
+		if !service.StreamEventVisible(ev, types, userID, role) {
+			return true
+		}
+		if role != "admin" {
+			ev.ActorID, ev.RequestID = nil, ""
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			logger.Error.Printf("streamEvents: marshal error event ID=%d: %v", ev.ID, err)
+			return true
+		}
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, data); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	for after := lastID; after > 0; {
+		events, next, err := service.GetStreamEventsAfter(c.Request.Context(), after)
+		if err != nil {
+			logger.Error.Printf("streamEvents: replay error after=%d: %v", after, err)
+			return
+		}
+		for _, ev := range events {
+			if !send(ev) {
+				return
+			}
+		}
+		after = next
+	}
+
+	logger.Info.Printf("streamEvents: user_id=%d subscribed types=%v from=%d", userID, types, lastID)
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, open := <-sub.C:
+			if !open {
+				// Клиент не успевал читать; он переподключится с Last-Event-ID
+				return
+			}
+			if !send(ev) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+func lastEventIDParam(c *gin.Context) (int64, bool) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		logger.Warn.Printf("streamEvents: invalid Last-Event-ID %q", raw)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID must be a non-negative integer"})
+		return 0, false
+	}
+	return id, true
+}
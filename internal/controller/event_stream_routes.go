@@ -0,0 +1,11 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterEventStreamRoutes монтирует поток изменений каталога (Server-Sent Events).
+func RegisterEventStreamRoutes(r *gin.Engine) {
+	r.GET("/events/stream", middleware.JWTAuthMiddleware, streamEvents)
+}
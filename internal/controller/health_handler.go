@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"net/http"
+
+	"Library/internal/models"
+	"Library/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Liveness-проба
+// @Description Процесс жив и обрабатывает запросы; зависимости не проверяются
+// @Tags        health
+// @Produce     json
+// @Success     200 {object} map[string]string
+// @Router      /healthz [get]
+func healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": models.HealthStatusOK})
+}
+
+// @Summary     Readiness-проба
+// @Description Проверяет доступность БД, применённость schema.sql и работу фоновых обработчиков; по каждой проверке — статус, время и ошибка. Во время остановки отвечает 503
+// @Tags        health
+// @Produce     json
+// @Success     200 {object} models.HealthReport
+// @Failure     503 {object} models.HealthReport
+// @Router      /readyz [get]
+func readyz(c *gin.Context) {
+	report := service.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != models.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
@@ -0,0 +1,11 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterHealthRoutes монтирует пробы для оркестратора (без авторизации).
+func RegisterHealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", healthz)
+	r.GET("/readyz", readyz)
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Фоновые задачи
+// @Description Список задач планировщика: расписание, следующий запуск на этом экземпляре и последний запуск на любом (Admin only)
+// @Tags        jobs
+// @Produce     json
+// @Success     200 {array} models.JobInfo
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /jobs [get]
+func getJobs(c *gin.Context) {
+	jobs, err := service.ListJobs(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getJobs", err)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// @Summary     История запусков задачи
+// @Description Последние запуски задачи, новые сверху (Admin only)
+// @Tags        jobs
+// @Produce     json
+// @Param       name   path   string  true   "Имя задачи"
+// @Param       limit  query  int     false  "Сколько запусков вернуть (по умолчанию 20, максимум 200)"
+// @Success     200 {array} models.JobRun
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /jobs/{name}/runs [get]
+func getJobRuns(c *gin.Context) {
+	var limit int
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			logger.Warn.Printf("getJobRuns: invalid limit %q", raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	runs, err := service.GetJobRuns(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		handleServiceError(c, "getJobRuns", err)
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// @Summary     Запустить задачу вручную
+// @Description Запускает задачу вне расписания в фоне. Если она уже выполняется, запуск записывается в историю как skipped (Admin only)
+// @Tags        jobs
+// @Produce     json
+// @Param       name  path  string  true  "Имя задачи"
+// @Success     202 {object} map[string]string
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     503 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /jobs/{name}/run [post]
+func triggerJob(c *gin.Context) {
+	name := c.Param("name")
+	if err := service.TriggerJob(name); err != nil {
+		handleServiceError(c, "triggerJob", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "started", "job": name})
+}
@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterJobRoutes монтирует управление фоновыми задачами (только admin).
+func RegisterJobRoutes(r *gin.Engine) {
+	jobs := r.Group("/jobs", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		jobs.GET("", getJobs)
+		jobs.GET("/:name/runs", getJobRuns)
+		jobs.POST("/:name/run", triggerJob)
+	}
+}
@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"Library/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Метрики Prometheus
+// @Description HTTP-запросы, пул соединений и время запросов к БД, бизнес-показатели (книги, читатели, выдачи). Доступен, если включён в metrics_params; при заданном username — под Basic-авторизацией
+// @Tags        metrics
+// @Produce     plain
+// @Success     200 {string} string
+// @Failure     401 {string} string
+// @Router      /metrics [get]
+func metricsHandler(c *gin.Context) {
+	metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,19 @@
+package controller
+
+import (
+	"Library/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMetricsRoutes монтирует /metrics, если экспорт включён в metrics_params.
+func RegisterMetricsRoutes(r *gin.Engine) {
+	if !metrics.Enabled() {
+		return
+	}
+	handlers := []gin.HandlerFunc{}
+	if username, password := metrics.Credentials(); username != "" {
+		handlers = append(handlers, gin.BasicAuth(gin.Accounts{username: password}))
+	}
+	r.GET("/metrics", append(handlers, metricsHandler)...)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/middleware"
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Мои уведомления
+// @Description Входящие уведомления текущего пользователя (новые сверху) и число непрочитанных
+// @Tags        notifications
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       unread  query  bool  false  "Только непрочитанные"
+// @Param       limit   query  int   false  "Размер страницы (по умолчанию 20, максимум 100)"
+// @Param       offset  query  int   false  "Смещение"
+// @Success     200 {object} models.NotificationInbox
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/notifications [get]
+func getMyNotifications(c *gin.Context) {
+	var unreadOnly bool
+	if raw := c.Query("unread"); raw != "" {
+		var err error
+		if unreadOnly, err = strconv.ParseBool(raw); err != nil {
+			logger.Warn.Printf("getMyNotifications: invalid unread %q", raw)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unread must be a boolean"})
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	inbox, err := service.GetMyNotifications(c.Request.Context(), middleware.GetUserID(c), unreadOnly, limit, offset)
+	if err != nil {
+		handleServiceError(c, "getMyNotifications", err)
+		return
+	}
+	c.JSON(http.StatusOK, inbox)
+}
+
+// @Summary     Отметить уведомление прочитанным
+// @Tags        notifications
+// @Security    ApiKeyAuth
+// @Param       id  path  int  true  "ID уведомления"
+// @Success     204
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /me/notifications/{id}/read [post]
+func markNotificationRead(c *gin.Context) {
+	setNotificationRead(c, "markNotificationRead", true)
+}
+
+// @Summary     Отметить уведомление непрочитанным
+// @Tags        notifications
+// @Security    ApiKeyAuth
+// @Param       id  path  int  true  "ID уведомления"
+// @Success     204
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /me/notifications/{id}/unread [post]
+func markNotificationUnread(c *gin.Context) {
+	setNotificationRead(c, "markNotificationUnread", false)
+}
+
+func setNotificationRead(c *gin.Context, handler string, read bool) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		logger.Error.Printf("%s: invalid ID param %q: %v", handler, idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification ID"})
+		return
+	}
+	if err := service.SetNotificationRead(c.Request.Context(), middleware.GetUserID(c), id, read); err != nil {
+		handleServiceError(c, handler, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Прочитать все уведомления
+// @Tags        notifications
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Success     200 {object} map[string]int64
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/notifications/read-all [post]
+func markAllNotificationsRead(c *gin.Context) {
+	n, err := service.MarkAllNotificationsRead(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "markAllNotificationsRead", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": n})
+}
+
+// @Summary     Настройки уведомлений
+// @Description Язык уведомлений (ru/en) и включённые каналы (in_app, email) для каждого вида
+// @Tags        notifications
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Success     200 {object} models.NotificationSettings
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/notification-settings [get]
+func getNotificationSettings(c *gin.Context) {
+	s, err := service.GetNotificationSettings(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getNotificationSettings", err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Изменить настройки уведомлений
+// @Description Меняет язык и перечисленные сочетания вида и канала; не перечисленные остаются как были
+// @Tags        notifications
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       input  body  models.NotificationSettings  true  "Язык и каналы"
+// @Success     200 {object} models.NotificationSettings
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/notification-settings [put]
+func updateNotificationSettings(c *gin.Context) {
+	var in models.NotificationSettings
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("updateNotificationSettings: bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := service.UpdateNotificationSettings(c.Request.Context(), middleware.GetUserID(c), in)
+	if err != nil {
+		handleServiceError(c, "updateNotificationSettings", err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
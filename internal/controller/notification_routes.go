@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterNotificationRoutes монтирует входящие уведомления и их настройки.
+func RegisterNotificationRoutes(r *gin.Engine) {
+	// руты для авторизованных читателей
+	me := r.Group("/me", middleware.JWTAuthMiddleware)
+	{
+		me.GET("/notifications", getMyNotifications)
+		me.POST("/notifications/read-all", markAllNotificationsRead)
+		me.POST("/notifications/:id/read", markNotificationRead)
+		me.POST("/notifications/:id/unread", markNotificationUnread)
+		me.GET("/notification-settings", getNotificationSettings)
+		me.PUT("/notification-settings", updateNotificationSettings)
+	}
+}
@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+
+	"Library/internal/middleware"
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// renderXML отдаёт OPDS/OpenSearch-документ с нужным Content-Type.
+func renderXML(c *gin.Context, contentType string, v interface{}) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		logger.Error.Printf("renderXML: marshal error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", append([]byte(xml.Header), out...))
+}
+
+// renderOPDS2 отдаёт OPDS 2.0 фид.
+func renderOPDS2(c *gin.Context, feed models.OPDS2Feed) {
+	c.Header("Content-Type", models.OPDS2Type)
+	c.JSON(http.StatusOK, feed)
+}
+
+// @Summary     OPDS: корневой каталог
+// @Description Навигационный фид OPDS 1.2 (Atom)
+// @Tags        opds
+// @Produce     xml
+// @Success     200 {object} models.AtomFeed
+// @Router      /opds [get]
+func getOPDSRoot(c *gin.Context) {
+	renderXML(c, models.OPDSNavigationType, service.BuildOPDSRootFeed())
+}
+
+// @Summary     OPDS: авторы
+// @Description Навигационный фид со списком авторов
+// @Tags        opds
+// @Produce     xml
+// @Success     200 {object} models.AtomFeed
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /opds/authors [get]
+func getOPDSAuthors(c *gin.Context) {
+	feed, err := service.BuildOPDSAuthorsFeed(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getOPDSAuthors", err)
+		return
+	}
+	renderXML(c, models.OPDSNavigationType, feed)
+}
+
+// @Summary     OPDS: книги автора
+// @Description Acquisition-фид с книгами автора
+// @Tags        opds
+// @Produce     xml
+// @Param       id   path      int  true  "ID автора"
+// @Success     200 {object} models.AtomFeed
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /opds/authors/{id} [get]
+func getOPDSAuthorBooks(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getOPDSAuthorBooks: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		return
+	}
+
+	feed, err := service.BuildOPDSAuthorBooksFeed(c.Request.Context(), id, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getOPDSAuthorBooks", err)
+		return
+	}
+	renderXML(c, models.OPDSAcquisitionType, feed)
+}
+
+// @Summary     OPDS: новые поступления
+// @Description Acquisition-фид последних добавленных книг
+// @Tags        opds
+// @Produce     xml
+// @Success     200 {object} models.AtomFeed
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /opds/new [get]
+func getOPDSNewest(c *gin.Context) {
+	feed, err := service.BuildOPDSNewestFeed(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getOPDSNewest", err)
+		return
+	}
+	renderXML(c, models.OPDSAcquisitionType, feed)
+}
+
+// @Summary     OPDS: поиск
+// @Description Acquisition-фид с результатами поиска по названию
+// @Tags        opds
+// @Produce     xml
+// @Param       q  query     string  true  "Фрагмент в названии"
+// @Success     200 {object} models.AtomFeed
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /opds/search [get]
+func searchOPDS(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		logger.Warn.Println("searchOPDS: missing query param 'q'")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter 'q' is required"})
+		return
+	}
+
+	feed, err := service.BuildOPDSSearchFeed(c.Request.Context(), q, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "searchOPDS", err)
+		return
+	}
+	renderXML(c, models.OPDSAcquisitionType, feed)
+}
+
+// @Summary     OPDS: взять e-книгу
+// @Description Цель ссылки borrow из acquisition-фидов: оформляет цифровую выдачу (или находит действующую)
+// @Description и возвращает OPDS-entry книги с подписанными ссылками на файлы
+// @Tags        opds
+// @Produce     xml
+// @Param       id   path      int  true  "ID книги"
+// @Success     200 {object} models.AtomEntry
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /opds/books/{id}/borrow [get]
+func borrowOPDS(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("borrowOPDS: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	entry, err := service.BorrowOPDSBook(c.Request.Context(), id, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "borrowOPDS", err)
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	renderXML(c, models.OPDSEntryType, entry)
+}
+
+// @Summary     OPDS: OpenSearch
+// @Description OpenSearch-описание поиска книг
+// @Tags        opds
+// @Produce     xml
+// @Success     200 {object} models.OpenSearchDescription
+// @Router      /opds/opensearch.xml [get]
+func getOpenSearchDescription(c *gin.Context) {
+	renderXML(c, models.OPDSOpenSearchType, service.BuildOpenSearchDescription())
+}
+
+// @Summary     OPDS 2.0: корневой каталог
+// @Tags        opds
+// @Produce     json
+// @Success     200 {object} models.OPDS2Feed
+// @Router      /opds/v2 [get]
+func getOPDS2Root(c *gin.Context) {
+	renderOPDS2(c, service.BuildOPDS2RootFeed())
+}
+
+// @Summary     OPDS 2.0: авторы
+// @Tags        opds
+// @Produce     json
+// @Success     200 {object} models.OPDS2Feed
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /opds/v2/authors [get]
+func getOPDS2Authors(c *gin.Context) {
+	feed, err := service.BuildOPDS2AuthorsFeed(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getOPDS2Authors", err)
+		return
+	}
+	renderOPDS2(c, feed)
+}
+
+// @Summary     OPDS 2.0: книги автора
+// @Tags        opds
+// @Produce     json
+// @Param       id   path      int  true  "ID автора"
+// @Success     200 {object} models.OPDS2Feed
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /opds/v2/authors/{id} [get]
+func getOPDS2AuthorBooks(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getOPDS2AuthorBooks: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author ID"})
+		return
+	}
+
+	feed, err := service.BuildOPDS2AuthorBooksFeed(c.Request.Context(), id, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getOPDS2AuthorBooks", err)
+		return
+	}
+	renderOPDS2(c, feed)
+}
+
+// @Summary     OPDS 2.0: новые поступления
+// @Tags        opds
+// @Produce     json
+// @Success     200 {object} models.OPDS2Feed
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /opds/v2/new [get]
+func getOPDS2Newest(c *gin.Context) {
+	feed, err := service.BuildOPDS2NewestFeed(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getOPDS2Newest", err)
+		return
+	}
+	renderOPDS2(c, feed)
+}
+
+// @Summary     OPDS 2.0: поиск
+// @Tags        opds
+// @Produce     json
+// @Param       query  query     string  true  "Фрагмент в названии"
+// @Success     200 {object} models.OPDS2Feed
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /opds/v2/search [get]
+func searchOPDS2(c *gin.Context) {
+	q := c.Query("query")
+	if q == "" {
+		logger.Warn.Println("searchOPDS2: missing query param 'query'")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter 'query' is required"})
+		return
+	}
+
+	feed, err := service.BuildOPDS2SearchFeed(c.Request.Context(), q, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "searchOPDS2", err)
+		return
+	}
+	renderOPDS2(c, feed)
+}
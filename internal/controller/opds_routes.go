@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterOPDSRoutes монтирует публичный OPDS-каталог для читалок. Acquisition-фиды принимают
+// необязательный токен: читателю с действующей выдачей в них сразу отдаются ссылки на файлы.
+func RegisterOPDSRoutes(r *gin.Engine) {
+	opds := r.Group("/opds")
+	{
+		// OPDS 1.2 (Atom)
+		opds.GET("", getOPDSRoot)
+		opds.GET("/authors", getOPDSAuthors)
+		opds.GET("/authors/:id", middleware.OptionalJWT, getOPDSAuthorBooks)
+		opds.GET("/new", middleware.OptionalJWT, getOPDSNewest)
+		opds.GET("/search", middleware.OptionalJWT, searchOPDS)
+		opds.GET("/opensearch.xml", getOpenSearchDescription)
+		// ссылка borrow из фидов: OPDS-клиент переходит по ней GET-запросом с токеном читателя
+		opds.GET("/books/:id/borrow", middleware.JWTAuthMiddleware, borrowOPDS)
+
+		// OPDS 2.0 (JSON)
+		opds.GET("/v2", getOPDS2Root)
+		opds.GET("/v2/authors", getOPDS2Authors)
+		opds.GET("/v2/authors/:id", middleware.OptionalJWT, getOPDS2AuthorBooks)
+		opds.GET("/v2/new", middleware.OptionalJWT, getOPDS2Newest)
+		opds.GET("/v2/search", middleware.OptionalJWT, searchOPDS2)
+	}
+}
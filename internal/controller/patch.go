@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// bindMergePatch читает тело JSON Merge Patch (RFC 7396). Патч обязан быть
+// JSON-объектом: замена ресурса целиком делается через PUT.
+// Второе значение false, если ответ уже отправлен.
+func bindMergePatch(c *gin.Context, handler string) (map[string]json.RawMessage, bool) {
+	ct, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if ct != mergePatchContentType && ct != "application/json" {
+		logger.Warn.Printf("%s: unsupported content type %q", handler, ct)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "content type must be " + mergePatchContentType})
+		return nil, false
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil || patch == nil {
+		logger.Warn.Printf("%s: invalid merge patch body: %v", handler, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "merge patch body must be a JSON object"})
+		return nil, false
+	}
+	return patch, true
+}
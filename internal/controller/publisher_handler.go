@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Список издателей
+// @Description Возвращает всех издателей
+// @Tags        publishers
+// @Produce     json
+// @Success     200 {array} models.Publisher
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /publishers [get]
+func getAllPublishers(c *gin.Context) {
+	publishers, err := service.GetAllPublishers(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getAllPublishers", err)
+		return
+	}
+	logger.Info.Printf("getAllPublishers: returned %d publishers", len(publishers))
+	c.JSON(http.StatusOK, publishers)
+}
+
+// @Summary     Издатель по ID
+// @Description Возвращает издателя по его ID
+// @Tags        publishers
+// @Produce     json
+// @Param       id   path      int  true  "ID издателя"
+// @Success     200  {object}  models.Publisher
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /publishers/{id} [get]
+func getPublisherByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getPublisherByID: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid publisher ID"})
+		return
+	}
+
+	p, err := service.GetPublisherByID(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getPublisherByID", err)
+		return
+	}
+	c.JSON(http.StatusOK, p)
+}
+
+// @Summary     Создать издателя
+// @Description Добавляет нового издателя (Admin only)
+// @Tags        publishers
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       publisher  body      models.Publisher  true  "Новый издатель"
+// @Success     201        {object}  models.Publisher
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /publishers [post]
+func createPublisher(c *gin.Context) {
+	var p models.Publisher
+	if err := c.BindJSON(&p); err != nil {
+		logger.Error.Printf("createPublisher: bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := service.CreatePublisher(c.Request.Context(), &p, auditActor(c)); err != nil {
+		handleServiceError(c, "createPublisher", err)
+		return
+	}
+	logger.Info.Printf("createPublisher: created publisher ID=%d name=%q", p.ID, p.Name)
+	c.JSON(http.StatusCreated, p)
+}
+
+// @Summary     Обновить издателя
+// @Description Обновляет данные издателя по ID (Admin only)
+// @Tags        publishers
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id         path      int               true  "ID издателя"
+// @Param       publisher  body      models.Publisher  true  "Данные издателя"
+// @Success     200        {object}  models.Publisher
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /publishers/{id} [put]
+func updatePublisher(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("updatePublisher: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid publisher ID"})
+		return
+	}
+
+	var p models.Publisher
+	if err := c.BindJSON(&p); err != nil {
+		logger.Error.Printf("updatePublisher: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p.ID = id
+
+	if err := service.UpdatePublisher(c.Request.Context(), &p, auditActor(c)); err != nil {
+		handleServiceError(c, "updatePublisher", err)
+		return
+	}
+	logger.Info.Printf("updatePublisher: updated publisher ID=%d", p.ID)
+	c.JSON(http.StatusOK, p)
+}
+
+// @Summary     Удалить издателя
+// @Description Удаляет издателя, если у него нет книг (Admin only)
+// @Tags        publishers
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID издателя"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /publishers/{id} [delete]
+func deletePublisher(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("deletePublisher: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid publisher ID"})
+		return
+	}
+
+	if err := service.DeletePublisherByID(c.Request.Context(), id, auditActor(c)); err != nil {
+		handleServiceError(c, "deletePublisher", err)
+		return
+	}
+	logger.Info.Printf("deletePublisher: deleted publisher ID=%d", id)
+	c.Status(http.StatusNoContent)
+}
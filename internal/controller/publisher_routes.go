@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPublisherRoutes монтирует маршруты для работы с издателями.
+func RegisterPublisherRoutes(r *gin.Engine) {
+	// публичные руты
+	r.GET("/publishers", getAllPublishers)
+	r.GET("/publishers/:id", getPublisherByID)
+
+	// защищённые руты
+	authPublishers := r.Group("/publishers", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		authPublishers.POST("", createPublisher)
+		authPublishers.PUT("/:id", updatePublisher)
+		authPublishers.DELETE("/:id", deletePublisher)
+	}
+}
@@ -0,0 +1,353 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"Library/internal/middleware"
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readingListID разбирает ID списка из пути.
+func readingListID(c *gin.Context, handler string) (int, bool) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("%s: invalid ID param %q: %v", handler, idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid list ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// renderReadingListCSV отдаёт список файлом CSV.
+func renderReadingListCSV(c *gin.Context, handler string, l models.ReadingList) {
+	data, err := service.ReadingListCSV(l)
+	if err != nil {
+		handleServiceError(c, handler, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="reading-list-%d.csv"`, l.ID))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// @Summary     Мои списки
+// @Description Возвращает списки текущего пользователя: стандартные полки (want_to_read, reading, finished, favourites) и именованные списки
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Success     200 {array} models.ReadingList
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists [get]
+func getMyReadingLists(c *gin.Context) {
+	lists, err := service.GetMyReadingLists(c.Request.Context(), middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getMyReadingLists", err)
+		return
+	}
+	c.JSON(http.StatusOK, lists)
+}
+
+// @Summary     Мой список
+// @Description Возвращает список текущего пользователя с книгами по порядку
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID списка"
+// @Success     200  {object}  models.ReadingList
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /me/lists/{id} [get]
+func getMyReadingList(c *gin.Context) {
+	id, ok := readingListID(c, "getMyReadingList")
+	if !ok {
+		return
+	}
+	l, err := service.GetMyReadingList(c.Request.Context(), id, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "getMyReadingList", err)
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+type readingListInput struct {
+	Name       string `json:"name" binding:"required"`
+	Visibility string `json:"visibility"`
+}
+
+// @Summary     Создать список
+// @Description Создаёт именованный список; visibility — private (по умолчанию) или public
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       list  body      controller.readingListInput  true  "Название и видимость"
+// @Success     201   {object}  models.ReadingList
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists [post]
+func createReadingList(c *gin.Context) {
+	var in readingListInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("createReadingList: bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	l := models.ReadingList{UserID: middleware.GetUserID(c), Name: in.Name, Visibility: in.Visibility}
+	if err := service.CreateReadingList(c.Request.Context(), &l); err != nil {
+		handleServiceError(c, "createReadingList", err)
+		return
+	}
+	logger.Info.Printf("createReadingList: created list ID=%d", l.ID)
+	c.JSON(http.StatusCreated, l)
+}
+
+// @Summary     Изменить список
+// @Description Меняет название и видимость списка; у стандартных полок меняется только видимость
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id    path      int                          true  "ID списка"
+// @Param       list  body      controller.readingListInput  true  "Название и видимость"
+// @Success     200   {object}  models.ReadingList
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists/{id} [put]
+func updateReadingList(c *gin.Context) {
+	id, ok := readingListID(c, "updateReadingList")
+	if !ok {
+		return
+	}
+	var in readingListInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("updateReadingList: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	l := models.ReadingList{ID: id, UserID: middleware.GetUserID(c), Name: in.Name, Visibility: in.Visibility}
+	if err := service.UpdateReadingList(c.Request.Context(), &l); err != nil {
+		handleServiceError(c, "updateReadingList", err)
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+// @Summary     Удалить список
+// @Description Удаляет именованный список; стандартные полки удалить нельзя
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID списка"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists/{id} [delete]
+func deleteReadingList(c *gin.Context) {
+	id, ok := readingListID(c, "deleteReadingList")
+	if !ok {
+		return
+	}
+	if err := service.DeleteReadingList(c.Request.Context(), id, middleware.GetUserID(c)); err != nil {
+		handleServiceError(c, "deleteReadingList", err)
+		return
+	}
+	logger.Info.Printf("deleteReadingList: deleted list ID=%d", id)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Новая ссылка на список
+// @Description Выдаёт списку новую ссылку share_url; прежняя ссылка перестаёт работать
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID списка"
+// @Success     200  {object}  models.ReadingList
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists/{id}/share [post]
+func rotateReadingListShareLink(c *gin.Context) {
+	id, ok := readingListID(c, "rotateReadingListShareLink")
+	if !ok {
+		return
+	}
+	l, err := service.RotateReadingListShareLink(c.Request.Context(), id, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "rotateReadingListShareLink", err)
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+type readingListItemInput struct {
+	BookID int    `json:"book_id" binding:"required"`
+	Note   string `json:"note"`
+}
+
+// @Summary     Добавить книгу в список
+// @Description Добавляет книгу в конец списка; если книга уже в списке, обновляет заметку
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id    path      int                              true  "ID списка"
+// @Param       item  body      controller.readingListItemInput  true  "Книга и заметка"
+// @Success     200   {object}  models.ReadingList
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists/{id}/items [post]
+func addReadingListItem(c *gin.Context) {
+	id, ok := readingListID(c, "addReadingListItem")
+	if !ok {
+		return
+	}
+	var in readingListItemInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("addReadingListItem: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	l, err := service.AddReadingListItem(c.Request.Context(), id, middleware.GetUserID(c), in.BookID, in.Note)
+	if err != nil {
+		handleServiceError(c, "addReadingListItem", err)
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+type readingListOrderInput struct {
+	BookIDs []int `json:"book_ids" binding:"required"`
+}
+
+// @Summary     Упорядочить список
+// @Description Расставляет книги списка в порядке book_ids; нужно перечислить все книги списка
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id     path      int                               true  "ID списка"
+// @Param       order  body      controller.readingListOrderInput  true  "Новый порядок книг"
+// @Success     200    {object}  models.ReadingList
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists/{id}/items [put]
+func reorderReadingList(c *gin.Context) {
+	id, ok := readingListID(c, "reorderReadingList")
+	if !ok {
+		return
+	}
+	var in readingListOrderInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("reorderReadingList: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	l, err := service.ReorderReadingList(c.Request.Context(), id, middleware.GetUserID(c), in.BookIDs)
+	if err != nil {
+		handleServiceError(c, "reorderReadingList", err)
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+// @Summary     Убрать книгу из списка
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id      path      int  true  "ID списка"
+// @Param       bookId  path      int  true  "ID книги"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/lists/{id}/items/{bookId} [delete]
+func removeReadingListItem(c *gin.Context) {
+	id, ok := readingListID(c, "removeReadingListItem")
+	if !ok {
+		return
+	}
+	bookID, err := strconv.Atoi(c.Param("bookId"))
+	if err != nil {
+		logger.Error.Printf("removeReadingListItem: invalid bookId param %q: %v", c.Param("bookId"), err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	if err := service.RemoveReadingListItem(c.Request.Context(), id, middleware.GetUserID(c), bookID); err != nil {
+		handleServiceError(c, "removeReadingListItem", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Выгрузить список в CSV
+// @Tags        reading-lists
+// @Security    ApiKeyAuth
+// @Produce     text/csv
+// @Param       id   path      int  true  "ID списка"
+// @Success     200  {string}  string  "CSV"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /me/lists/{id}/export [get]
+func exportMyReadingList(c *gin.Context) {
+	id, ok := readingListID(c, "exportMyReadingList")
+	if !ok {
+		return
+	}
+	l, err := service.GetMyReadingList(c.Request.Context(), id, middleware.GetUserID(c))
+	if err != nil {
+		handleServiceError(c, "exportMyReadingList", err)
+		return
+	}
+	renderReadingListCSV(c, "exportMyReadingList", l)
+}
+
+// @Summary     Публичный список
+// @Description Открывает публичный список по ссылке share_url; приватные списки не отдаются
+// @Tags        reading-lists
+// @Produce     json
+// @Param       token  path      string  true  "Токен ссылки"
+// @Success     200    {object}  models.ReadingList
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /lists/shared/{token} [get]
+func getSharedReadingList(c *gin.Context) {
+	l, err := service.GetSharedReadingList(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		handleServiceError(c, "getSharedReadingList", err)
+		return
+	}
+	c.JSON(http.StatusOK, l)
+}
+
+// @Summary     Выгрузить публичный список в CSV
+// @Tags        reading-lists
+// @Produce     text/csv
+// @Param       token  path      string  true  "Токен ссылки"
+// @Success     200    {string}  string  "CSV"
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /lists/shared/{token}/export [get]
+func exportSharedReadingList(c *gin.Context) {
+	l, err := service.GetSharedReadingList(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		handleServiceError(c, "exportSharedReadingList", err)
+		return
+	}
+	renderReadingListCSV(c, "exportSharedReadingList", l)
+}
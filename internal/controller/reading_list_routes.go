@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterReadingListRoutes монтирует маршруты личных списков чтения.
+func RegisterReadingListRoutes(r *gin.Engine) {
+	// публичные руты: открытые списки по ссылке
+	r.GET("/lists/shared/:token", getSharedReadingList)
+	r.GET("/lists/shared/:token/export", exportSharedReadingList)
+
+	// руты для авторизованных читателей
+	lists := r.Group("/me/lists", middleware.JWTAuthMiddleware)
+	{
+		lists.GET("", getMyReadingLists)
+		lists.POST("", createReadingList)
+		lists.GET("/:id", getMyReadingList)
+		lists.PUT("/:id", updateReadingList)
+		lists.DELETE("/:id", deleteReadingList)
+		lists.POST("/:id/share", rotateReadingListShareLink)
+		lists.GET("/:id/export", exportMyReadingList)
+		lists.POST("/:id/items", addReadingListItem)
+		lists.PUT("/:id/items", reorderReadingList)
+		lists.DELETE("/:id/items/:bookId", removeReadingListItem)
+	}
+}
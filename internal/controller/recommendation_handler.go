@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/middleware"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recommendLimitParam читает ?limit; 0 — значение по умолчанию.
+func recommendLimitParam(c *gin.Context) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return 0, false
+	}
+	return limit, true
+}
+
+// @Summary     Похожие книги
+// @Description «Читатели, бравшие эту книгу, брали также»; если совместных выдач мало — книги того же автора и тех же рубрик. reason объясняет источник рекомендации
+// @Tags        books
+// @Produce     json
+// @Param       id     path   int  true   "ID книги"
+// @Param       limit  query  int  false  "Сколько книг вернуть (по умолчанию 10, максимум 50)"
+// @Success     200  {array}   models.Recommendation
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /books/{id}/similar [get]
+func getSimilarBooks(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getSimilarBooks: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+	limit, ok := recommendLimitParam(c)
+	if !ok {
+		return
+	}
+
+	recs, err := service.GetSimilarBooks(c.Request.Context(), id, limit)
+	if err != nil {
+		handleServiceError(c, "getSimilarBooks", err)
+		return
+	}
+	c.JSON(http.StatusOK, recs)
+}
+
+// @Summary     Мои рекомендации
+// @Description Персональные рекомендации по выдачам и спискам чтения текущего пользователя; уже знакомые книги не предлагаются
+// @Tags        books
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       limit  query  int  false  "Сколько книг вернуть (по умолчанию 10, максимум 50)"
+// @Success     200  {array}   models.Recommendation
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /me/recommendations [get]
+func getMyRecommendations(c *gin.Context) {
+	limit, ok := recommendLimitParam(c)
+	if !ok {
+		return
+	}
+
+	userID := middleware.GetUserID(c)
+	recs, err := service.GetUserRecommendations(c.Request.Context(), userID, limit)
+	if err != nil {
+		handleServiceError(c, "getMyRecommendations", err)
+		return
+	}
+	logger.Info.Printf("getMyRecommendations: returned %d books for user ID=%d", len(recs), userID)
+	c.JSON(http.StatusOK, recs)
+}
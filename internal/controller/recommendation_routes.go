@@ -0,0 +1,15 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRecommendationRoutes монтирует маршруты рекомендаций.
+func RegisterRecommendationRoutes(r *gin.Engine) {
+	// публичные руты
+	r.GET("/books/:id/similar", getSimilarBooks)
+
+	// руты для авторизованных читателей
+	r.GET("/me/recommendations", middleware.JWTAuthMiddleware, getMyRecommendations)
+}
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/middleware"
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Отзывы о книге
+// @Description Возвращает опубликованные отзывы о книге, новые сначала; скрытые модератором — только admin с include_hidden=true
+// @Tags        reviews
+// @Produce     json
+// @Param       id              path   int   true   "ID книги"
+// @Param       include_hidden  query  bool  false  "Включить скрытые отзывы (только admin)"
+// @Success     200  {array}   models.Review
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /books/{id}/reviews [get]
+func getBookReviews(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getBookReviews: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var withHidden bool
+	if raw := c.Query("include_hidden"); raw != "" {
+		if withHidden, err = strconv.ParseBool(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "include_hidden must be a boolean"})
+			return
+		}
+		if withHidden && middleware.GetUserRole(c) != "admin" {
+			logger.Warn.Println("getBookReviews: include_hidden requested by non-admin")
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
+			return
+		}
+	}
+
+	reviews, err := service.GetBookReviews(c.Request.Context(), id, withHidden)
+	if err != nil {
+		handleServiceError(c, "getBookReviews", err)
+		return
+	}
+	logger.Info.Printf("getBookReviews: returned %d reviews for book ID=%d", len(reviews), id)
+	c.JSON(http.StatusOK, reviews)
+}
+
+type reviewInput struct {
+	Rating int    `json:"rating" binding:"required"`
+	Text   string `json:"text"`
+}
+
+// @Summary     Оставить отзыв
+// @Description Публикует отзыв текущего пользователя о книге: оценка 1–5 и текст. Один отзыв на книгу от читателя
+// @Tags        reviews
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id      path      int                     true  "ID книги"
+// @Param       review  body      controller.reviewInput  true  "Оценка и текст"
+// @Success     201     {object}  models.Review
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /books/{id}/reviews [post]
+func createReview(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("createReview: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var in reviewInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("createReview: bind error for book ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	r := models.Review{BookID: id, UserID: middleware.GetUserID(c), Rating: in.Rating, Text: in.Text}
+	if err := service.CreateReview(c.Request.Context(), &r, auditActor(c)); err != nil {
+		handleServiceError(c, "createReview", err)
+		return
+	}
+	logger.Info.Printf("createReview: created review ID=%d for book ID=%d", r.ID, id)
+	c.JSON(http.StatusCreated, r)
+}
+
+// @Summary     Изменить свой отзыв
+// @Description Меняет оценку и текст собственного отзыва
+// @Tags        reviews
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id      path      int                     true  "ID отзыва"
+// @Param       review  body      controller.reviewInput  true  "Оценка и текст"
+// @Success     200     {object}  models.Review
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /reviews/{id} [put]
+func updateReview(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("updateReview: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		return
+	}
+
+	var in reviewInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("updateReview: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	r := models.Review{ID: id, UserID: middleware.GetUserID(c), Rating: in.Rating, Text: in.Text}
+	if err := service.UpdateReview(c.Request.Context(), &r, auditActor(c)); err != nil {
+		handleServiceError(c, "updateReview", err)
+		return
+	}
+	logger.Info.Printf("updateReview: updated review ID=%d", id)
+	c.JSON(http.StatusOK, r)
+}
+
+// @Summary     Удалить отзыв
+// @Description Удаляет собственный отзыв; администратор может удалить любой
+// @Tags        reviews
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID отзыва"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     403 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /reviews/{id} [delete]
+func deleteReview(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("deleteReview: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		return
+	}
+
+	asAdmin := middleware.GetUserRole(c) == "admin"
+	if err := service.DeleteReview(c.Request.Context(), id, middleware.GetUserID(c), asAdmin, auditActor(c)); err != nil {
+		handleServiceError(c, "deleteReview", err)
+		return
+	}
+	logger.Info.Printf("deleteReview: deleted review ID=%d", id)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Скрыть отзыв
+// @Description Скрывает отзыв из публичного списка и рейтинга книги (Admin only)
+// @Tags        reviews
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID отзыва"
+// @Success     200  {object}  models.Review
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /reviews/{id}/hide [post]
+func hideReview(c *gin.Context) {
+	moderateReview(c, "hideReview", models.ReviewStatusHidden)
+}
+
+// @Summary     Одобрить отзыв
+// @Description Возвращает скрытый отзыв в публичный список и рейтинг книги (Admin only)
+// @Tags        reviews
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID отзыва"
+// @Success     200  {object}  models.Review
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /reviews/{id}/approve [post]
+func approveReview(c *gin.Context) {
+	moderateReview(c, "approveReview", models.ReviewStatusApproved)
+}
+
+func moderateReview(c *gin.Context, handler, status string) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("%s: invalid ID param %q: %v", handler, idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		return
+	}
+
+	r, err := service.ModerateReview(c.Request.Context(), id, status, auditActor(c))
+	if err != nil {
+		handleServiceError(c, handler, err)
+		return
+	}
+	logger.Info.Printf("%s: review ID=%d is now %s", handler, id, status)
+	c.JSON(http.StatusOK, r)
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterReviewRoutes монтирует маршруты отзывов и их модерации.
+func RegisterReviewRoutes(r *gin.Engine) {
+	// публичные руты
+	r.GET("/books/:id/reviews", middleware.OptionalJWT, getBookReviews)
+
+	// руты читателя
+	r.POST("/books/:id/reviews", middleware.JWTAuthMiddleware, createReview)
+	authReviews := r.Group("/reviews", middleware.JWTAuthMiddleware)
+	{
+		authReviews.PUT("/:id", updateReview)
+		authReviews.DELETE("/:id", deleteReview)
+	}
+
+	// модерация
+	moderation := r.Group("/reviews", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		moderation.POST("/:id/hide", hideReview)
+		moderation.POST("/:id/approve", approveReview)
+	}
+}
@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Список серий
+// @Description Возвращает все книжные серии без состава
+// @Tags        series
+// @Produce     json
+// @Success     200 {array} models.Series
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /series [get]
+func getAllSeries(c *gin.Context) {
+	series, err := service.GetAllSeries(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getAllSeries", err)
+		return
+	}
+	logger.Info.Printf("getAllSeries: returned %d series", len(series))
+	c.JSON(http.StatusOK, series)
+}
+
+// @Summary     Серия по ID
+// @Description Возвращает серию и её книги в порядке чтения (по возрастанию позиции)
+// @Tags        series
+// @Produce     json
+// @Param       id   path      int  true  "ID серии"
+// @Success     200  {object}  models.Series
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /series/{id} [get]
+func getSeriesByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getSeriesByID: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	s, err := service.GetSeriesByID(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getSeriesByID", err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+type seriesInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// @Summary     Создать серию
+// @Description Добавляет книжную серию (Admin only)
+// @Tags        series
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       series  body      controller.seriesInput  true  "Новая серия"
+// @Success     201     {object}  models.Series
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /series [post]
+func createSeries(c *gin.Context) {
+	var in seriesInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("createSeries: bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s := models.Series{Name: in.Name, Description: in.Description}
+	if err := service.CreateSeries(c.Request.Context(), &s, auditActor(c)); err != nil {
+		handleServiceError(c, "createSeries", err)
+		return
+	}
+	logger.Info.Printf("createSeries: created series ID=%d name=%q", s.ID, s.Name)
+	c.JSON(http.StatusCreated, s)
+}
+
+// @Summary     Обновить серию
+// @Description Меняет название и описание серии (Admin only)
+// @Tags        series
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id      path      int                     true  "ID серии"
+// @Param       series  body      controller.seriesInput  true  "Данные серии"
+// @Success     200     {object}  models.Series
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /series/{id} [put]
+func updateSeries(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("updateSeries: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	var in seriesInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("updateSeries: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s := models.Series{ID: id, Name: in.Name, Description: in.Description}
+	if err := service.UpdateSeries(c.Request.Context(), &s, auditActor(c)); err != nil {
+		handleServiceError(c, "updateSeries", err)
+		return
+	}
+	logger.Info.Printf("updateSeries: updated series ID=%d", id)
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Удалить серию
+// @Description Удаляет серию; сами книги остаются в каталоге (Admin only)
+// @Tags        series
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID серии"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /series/{id} [delete]
+func deleteSeries(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("deleteSeries: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return
+	}
+
+	if err := service.DeleteSeriesByID(c.Request.Context(), id, auditActor(c)); err != nil {
+		handleServiceError(c, "deleteSeries", err)
+		return
+	}
+	logger.Info.Printf("deleteSeries: deleted series ID=%d", id)
+	c.Status(http.StatusNoContent)
+}
+
+type seriesBookInput struct {
+	Position float64 `json:"position" binding:"required"`
+}
+
+// seriesBookParams разбирает ID серии и ID книги из пути.
+func seriesBookParams(c *gin.Context, handler string) (int, int, bool) {
+	seriesID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logger.Error.Printf("%s: invalid ID param %q: %v", handler, c.Param("id"), err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid series ID"})
+		return 0, 0, false
+	}
+	bookID, err := strconv.Atoi(c.Param("bookId"))
+	if err != nil {
+		logger.Error.Printf("%s: invalid bookId param %q: %v", handler, c.Param("bookId"), err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return 0, 0, false
+	}
+	return seriesID, bookID, true
+}
+
+// @Summary     Поставить книгу в серию
+// @Description Добавляет книгу в серию или переставляет её. Позиция дробная (например, 2.5), не более двух знаков после запятой (Admin only)
+// @Tags        series
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id      path      int                         true  "ID серии"
+// @Param       bookId  path      int                         true  "ID книги"
+// @Param       input   body      controller.seriesBookInput  true  "Позиция в серии"
+// @Success     200     {object}  models.Series
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /series/{id}/books/{bookId} [put]
+func setSeriesBook(c *gin.Context) {
+	seriesID, bookID, ok := seriesBookParams(c, "setSeriesBook")
+	if !ok {
+		return
+	}
+
+	var in seriesBookInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("setSeriesBook: bind error for series ID %d: %v", seriesID, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s, err := service.SetSeriesBookPosition(c.Request.Context(), seriesID, bookID, in.Position, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "setSeriesBook", err)
+		return
+	}
+	logger.Info.Printf("setSeriesBook: book ID=%d at position %g in series ID=%d", bookID, in.Position, seriesID)
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Убрать книгу из серии
+// @Description Исключает книгу из серии (Admin only)
+// @Tags        series
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id      path      int  true  "ID серии"
+// @Param       bookId  path      int  true  "ID книги"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /series/{id}/books/{bookId} [delete]
+func removeSeriesBook(c *gin.Context) {
+	seriesID, bookID, ok := seriesBookParams(c, "removeSeriesBook")
+	if !ok {
+		return
+	}
+
+	if err := service.RemoveBookFromSeries(c.Request.Context(), seriesID, bookID, auditActor(c)); err != nil {
+		handleServiceError(c, "removeSeriesBook", err)
+		return
+	}
+	logger.Info.Printf("removeSeriesBook: removed book ID=%d from series ID=%d", bookID, seriesID)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSeriesRoutes монтирует маршруты книжных серий.
+func RegisterSeriesRoutes(r *gin.Engine) {
+	// публичные руты
+	r.GET("/series", getAllSeries)
+	r.GET("/series/:id", getSeriesByID)
+
+	// защищённые руты
+	authSeries := r.Group("/series", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		authSeries.POST("", createSeries)
+		authSeries.PUT("/:id", updateSeries)
+		authSeries.DELETE("/:id", deleteSeries)
+		authSeries.PUT("/:id/books/:bookId", setSeriesBook)
+		authSeries.DELETE("/:id/books/:bookId", removeSeriesBook)
+	}
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/middleware"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// includeDeleted читает флаг ?include_deleted. Видеть удалённые записи может
+// только администратор; остальным отвечаем 403. Второе значение false, если
+// ответ уже отправлен.
+func includeDeleted(c *gin.Context, handler string) (bool, bool) {
+	raw := c.Query("include_deleted")
+	if raw == "" {
+		return false, true
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include_deleted must be a boolean"})
+		return false, false
+	}
+	if v && middleware.GetUserRole(c) != "admin" {
+		logger.Warn.Printf("%s: include_deleted requested by non-admin", handler)
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin privileges required"})
+		return false, false
+	}
+	return v, true
+}
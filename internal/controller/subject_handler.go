@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Рубрикатор
+// @Description Возвращает все рубрики (темы и жанры) в порядке обхода дерева с глубиной и полным путём
+// @Tags        subjects
+// @Produce     json
+// @Success     200 {array} models.Subject
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /subjects [get]
+func getAllSubjects(c *gin.Context) {
+	subjects, err := service.GetAllSubjects(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getAllSubjects", err)
+		return
+	}
+	logger.Info.Printf("getAllSubjects: returned %d subjects", len(subjects))
+	c.JSON(http.StatusOK, subjects)
+}
+
+// @Summary     Рубрика по ID
+// @Description Возвращает рубрику с глубиной и полным путём
+// @Tags        subjects
+// @Produce     json
+// @Param       id   path      int  true  "ID рубрики"
+// @Success     200  {object}  models.Subject
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /subjects/{id} [get]
+func getSubjectByID(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getSubjectByID: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subject ID"})
+		return
+	}
+
+	s, err := service.GetSubjectByID(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getSubjectByID", err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Книги рубрики
+// @Description Возвращает книги рубрики и всех вложенных в неё рубрик
+// @Tags        subjects
+// @Produce     json
+// @Param       id   path      int  true  "ID рубрики"
+// @Success     200  {array}   models.Book
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /subjects/{id}/books [get]
+func getSubjectBooks(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getSubjectBooks: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subject ID"})
+		return
+	}
+
+	books, err := service.GetBooksBySubject(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getSubjectBooks", err)
+		return
+	}
+	logger.Info.Printf("getSubjectBooks: returned %d books for subject ID=%d", len(books), id)
+	c.JSON(http.StatusOK, books)
+}
+
+type subjectInput struct {
+	Name     string `json:"name" binding:"required"`
+	ParentID *int   `json:"parent_id"`
+}
+
+// @Summary     Создать рубрику
+// @Description Добавляет рубрику; parent_id — родительская рубрика (Admin only)
+// @Tags        subjects
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       subject  body      controller.subjectInput  true  "Новая рубрика"
+// @Success     201      {object}  models.Subject
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /subjects [post]
+func createSubject(c *gin.Context) {
+	var in subjectInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("createSubject: bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s := models.Subject{Name: in.Name, ParentID: in.ParentID}
+	if err := service.CreateSubject(c.Request.Context(), &s, auditActor(c)); err != nil {
+		handleServiceError(c, "createSubject", err)
+		return
+	}
+	logger.Info.Printf("createSubject: created subject ID=%d name=%q", s.ID, s.Name)
+	c.JSON(http.StatusCreated, s)
+}
+
+// @Summary     Обновить рубрику
+// @Description Переименовывает рубрику или переносит её в другую ветку дерева (Admin only)
+// @Tags        subjects
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id       path      int                      true  "ID рубрики"
+// @Param       subject  body      controller.subjectInput  true  "Данные рубрики"
+// @Success     200      {object}  models.Subject
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /subjects/{id} [put]
+func updateSubject(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("updateSubject: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subject ID"})
+		return
+	}
+
+	var in subjectInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("updateSubject: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s := models.Subject{ID: id, Name: in.Name, ParentID: in.ParentID}
+	if err := service.UpdateSubject(c.Request.Context(), &s, auditActor(c)); err != nil {
+		handleServiceError(c, "updateSubject", err)
+		return
+	}
+	logger.Info.Printf("updateSubject: updated subject ID=%d", id)
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Удалить рубрику
+// @Description Удаляет рубрику без вложенных рубрик; привязки к книгам снимаются (Admin only)
+// @Tags        subjects
+// @Security    ApiKeyAuth
+// @Produce     json
+// @Param       id   path      int  true  "ID рубрики"
+// @Success     204 {string}  string  "No Content"
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /subjects/{id} [delete]
+func deleteSubject(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("deleteSubject: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subject ID"})
+		return
+	}
+
+	if err := service.DeleteSubjectByID(c.Request.Context(), id, auditActor(c)); err != nil {
+		handleServiceError(c, "deleteSubject", err)
+		return
+	}
+	logger.Info.Printf("deleteSubject: deleted subject ID=%d", id)
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Рубрики книги
+// @Description Возвращает рубрики, к которым отнесена книга
+// @Tags        books
+// @Produce     json
+// @Param       id   path      int  true  "ID книги"
+// @Success     200  {array}   models.Subject
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Router      /books/{id}/subjects [get]
+func getBookSubjects(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("getBookSubjects: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	subjects, err := service.GetBookSubjects(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getBookSubjects", err)
+		return
+	}
+	c.JSON(http.StatusOK, subjects)
+}
+
+type bookSubjectsInput struct {
+	SubjectIDs []int `json:"subject_ids" binding:"required"`
+}
+
+// @Summary     Задать рубрики книги
+// @Description Заменяет набор рубрик книги (Admin only)
+// @Tags        books
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       id     path      int                           true  "ID книги"
+// @Param       input  body      controller.bookSubjectsInput  true  "ID рубрик"
+// @Success     200    {array}   models.Subject
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Router      /books/{id}/subjects [put]
+func setBookSubjects(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("setBookSubjects: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid book ID"})
+		return
+	}
+
+	var in bookSubjectsInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("setBookSubjects: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subjects, err := service.SetBookSubjects(c.Request.Context(), id, in.SubjectIDs, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "setBookSubjects", err)
+		return
+	}
+	logger.Info.Printf("setBookSubjects: book ID=%d now has %d subjects", id, len(subjects))
+	c.JSON(http.StatusOK, subjects)
+}
@@ -0,0 +1,24 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterSubjectRoutes монтирует маршруты рубрикатора.
+func RegisterSubjectRoutes(r *gin.Engine) {
+	// публичные руты
+	r.GET("/subjects", getAllSubjects)
+	r.GET("/subjects/:id", getSubjectByID)
+	r.GET("/subjects/:id/books", getSubjectBooks)
+	r.GET("/books/:id/subjects", getBookSubjects)
+
+	// защищённые руты
+	authSubjects := r.Group("/subjects", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		authSubjects.POST("", createSubject)
+		authSubjects.PUT("/:id", updateSubject)
+		authSubjects.DELETE("/:id", deleteSubject)
+	}
+	r.PUT("/books/:id/subjects", middleware.JWTAuthMiddleware, middleware.AdminOnly, setBookSubjects)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary     Задачи очереди
+// @Description Задачи фоновой обработки (новые сверху) с фильтром по статусу и виду (Admin only)
+// @Tags        tasks
+// @Produce     json
+// @Param       status  query  string  false  "queued | running | succeeded | dead"
+// @Param       kind    query  string  false  "Вид задачи"
+// @Param       limit   query  int     false  "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param       offset  query  int     false  "Смещение"
+// @Success     200 {array} models.Task
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /tasks [get]
+func getTasks(c *gin.Context) {
+	f := models.TaskFilter{
+		Status: c.Query("status"),
+		Kind:   c.Query("kind"),
+	}
+	f.Limit, _ = strconv.Atoi(c.Query("limit"))
+	f.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	tasks, err := service.GetTasks(c.Request.Context(), f)
+	if err != nil {
+		handleServiceError(c, "getTasks", err)
+		return
+	}
+	logger.Info.Printf("getTasks: returned %d tasks", len(tasks))
+	c.JSON(http.StatusOK, tasks)
+}
+
+// @Summary     Статистика очереди
+// @Description Число задач по видам и статусам (Admin only)
+// @Tags        tasks
+// @Produce     json
+// @Success     200 {array} models.TaskStats
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /tasks/stats [get]
+func getTaskStats(c *gin.Context) {
+	stats, err := service.GetTaskStats(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getTaskStats", err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary     Задача очереди
+// @Description Задача с числом попыток и последней ошибкой (Admin only)
+// @Tags        tasks
+// @Produce     json
+// @Param       id  path  int  true  "ID задачи"
+// @Success     200 {object} models.Task
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /tasks/{id} [get]
+func getTaskByID(c *gin.Context) {
+	id, ok := taskIDParam(c, "getTaskByID")
+	if !ok {
+		return
+	}
+	task, err := service.GetTaskByID(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getTaskByID", err)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// @Summary     Поставить задачу в очередь
+// @Description Ручная постановка задачи. С idempotency_key повторный запрос вернёт уже поставленную задачу (Admin only)
+// @Tags        tasks
+// @Accept      json
+// @Produce     json
+// @Param       input  body  models.EnqueueTaskRequest  true  "Вид задачи и параметры"
+// @Success     202 {object} models.Task
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /tasks [post]
+func enqueueTask(c *gin.Context) {
+	var req models.EnqueueTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error.Printf("enqueueTask: bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	task, err := service.EnqueueTaskRequest(c.Request.Context(), req)
+	if err != nil {
+		handleServiceError(c, "enqueueTask", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, task)
+}
+
+// @Summary     Повторить задачу
+// @Description Возвращает задачу из статуса dead в очередь с обнулённым счётчиком попыток (Admin only)
+// @Tags        tasks
+// @Produce     json
+// @Param       id  path  int  true  "ID задачи"
+// @Success     200 {object} models.Task
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Failure     409 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /tasks/{id}/retry [post]
+func retryTask(c *gin.Context) {
+	id, ok := taskIDParam(c, "retryTask")
+	if !ok {
+		return
+	}
+	task, err := service.RetryTask(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "retryTask", err)
+		return
+	}
+	logger.Info.Printf("retryTask: task ID=%d requeued", id)
+	c.JSON(http.StatusOK, task)
+}
+
+func taskIDParam(c *gin.Context, handler string) (int64, bool) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		logger.Error.Printf("%s: invalid ID param %q: %v", handler, idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return 0, false
+	}
+	return id, true
+}
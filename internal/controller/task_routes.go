@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterTaskRoutes монтирует просмотр и повтор задач очереди (только admin).
+func RegisterTaskRoutes(r *gin.Engine) {
+	tasks := r.Group("/tasks", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		tasks.GET("", getTasks)
+		tasks.POST("", enqueueTask)
+		tasks.GET("/stats", getTaskStats)
+		tasks.GET("/:id", getTaskByID)
+		tasks.POST("/:id/retry", retryTask)
+	}
+}
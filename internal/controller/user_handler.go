@@ -13,7 +13,11 @@ import (
 
 // getAllUsers отдаёт всех пользователей.
 func getAllUsers(c *gin.Context) {
-	users, err := service.GetAllUsers()
+	withDeleted, ok := includeDeleted(c, "getAllUsers")
+	if !ok {
+		return
+	}
+	users, err := service.GetAllUsers(c.Request.Context(), withDeleted)
 	if err != nil {
 		logger.Error.Printf("getAllUsers: service error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -33,10 +37,16 @@ func getUserByID(c *gin.Context) {
 		return
 	}
 
-	user, err := service.GetUserByID(id)
+	withDeleted, ok := includeDeleted(c, "getUserByID")
+	if !ok {
+		return
+	}
+	user, err := service.GetUserByID(c.Request.Context(), id, withDeleted)
 	if err != nil {
-		logger.Error.Printf("getUserByID: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		handleServiceError(c, "getUserByID", err)
+		return
+	}
+	if notModified(c, setETag(c, user.Version, user)) {
 		return
 	}
 	logger.Info.Printf("getUserByID: returned user ID=%d username=%q", user.ID, user.Username)
@@ -52,12 +62,13 @@ func createUser(c *gin.Context) {
 		return
 	}
 
-	if err := service.CreateUser(&u); err != nil {
+	if err := service.CreateUser(c.Request.Context(), &u, auditActor(c)); err != nil {
 		logger.Error.Printf("createUser: service error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	logger.Info.Printf("createUser: created user ID=%d username=%q", u.ID, u.Username)
+	setETag(c, u.Version, u)
 	c.JSON(http.StatusCreated, u)
 }
 
@@ -71,6 +82,11 @@ func updateUser(c *gin.Context) {
 		return
 	}
 
+	version, ok := requireIfMatch(c, "updateUser")
+	if !ok {
+		return
+	}
+
 	var u models.User
 	if err := c.BindJSON(&u); err != nil {
 		logger.Error.Printf("updateUser: bind error for ID %d: %v", id, err)
@@ -78,12 +94,13 @@ func updateUser(c *gin.Context) {
 		return
 	}
 	u.ID = id
+	u.Version = version
 
-	if err := service.UpdateUser(&u); err != nil {
-		logger.Error.Printf("updateUser: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.UpdateUser(c.Request.Context(), &u, auditActor(c)); err != nil {
+		handleServiceError(c, "updateUser", err)
 		return
 	}
+	setETag(c, u.Version, u)
 	logger.Info.Printf("updateUser: updated user ID=%d username=%q", u.ID, u.Username)
 	c.JSON(http.StatusOK, u)
 }
@@ -98,11 +115,59 @@ func deleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := service.DeleteUserByID(id); err != nil {
-		logger.Error.Printf("deleteUser: service error for ID %d: %v", id, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := service.DeleteUserByID(c.Request.Context(), id, auditActor(c)); err != nil {
+		handleServiceError(c, "deleteUser", err)
 		return
 	}
 	logger.Info.Printf("deleteUser: deleted user ID=%d", id)
 	c.Status(http.StatusNoContent)
 }
+
+// restoreUser восстанавливает удалённого пользователя.
+func restoreUser(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("restoreUser: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	user, err := service.RestoreUserByID(c.Request.Context(), id, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "restoreUser", err)
+		return
+	}
+	logger.Info.Printf("restoreUser: restored user ID=%d", id)
+	setETag(c, user.Version, user)
+	c.JSON(http.StatusOK, user)
+}
+
+// patchUser частично обновляет пользователя по JSON Merge Patch.
+func patchUser(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("patchUser: invalid ID param %q: %v", idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	version, ok := requireIfMatch(c, "patchUser")
+	if !ok {
+		return
+	}
+	patch, ok := bindMergePatch(c, "patchUser")
+	if !ok {
+		return
+	}
+
+	user, err := service.PatchUser(c.Request.Context(), id, version, patch, auditActor(c))
+	if err != nil {
+		handleServiceError(c, "patchUser", err)
+		return
+	}
+	setETag(c, user.Version, user)
+	logger.Info.Printf("patchUser: patched user ID=%d", id)
+	c.JSON(http.StatusOK, user)
+}
@@ -9,15 +9,17 @@ import (
 // Параметр svc больше не нужен, т.к. service — это набор функций.
 func RegisterUserRoutes(r *gin.Engine) {
 	// публичные руты
-	r.GET("/users", getAllUsers)
-	r.GET("/users/:id", getUserByID)
+	r.GET("/users", middleware.OptionalJWT, getAllUsers)
+	r.GET("/users/:id", middleware.OptionalJWT, getUserByID)
 
 	// защищённые руты
 	authBooks := r.Group("/users", middleware.JWTAuthMiddleware, middleware.AdminOnly)
 	{
 		authBooks.POST("", createUser)
 		authBooks.PUT("/:id", updateUser)
+		authBooks.PATCH("/:id", patchUser)
 		authBooks.DELETE("/:id", deleteUser)
+		authBooks.POST("/:id/restore", restoreUser)
 	}
 
 }
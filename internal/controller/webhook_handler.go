@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"Library/internal/models"
+	"Library/internal/service"
+	"Library/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func webhookIDParam(c *gin.Context, handler string) (int, bool) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Error.Printf("%s: invalid ID param %q: %v", handler, idParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// @Summary     Подписки на вебхуки
+// @Description Все подписки без секретов (Admin only)
+// @Tags        webhooks
+// @Produce     json
+// @Success     200 {array} models.WebhookSubscription
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks [get]
+func getWebhookSubscriptions(c *gin.Context) {
+	subs, err := service.GetWebhookSubscriptions(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, "getWebhookSubscriptions", err)
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// @Summary     Подписка на вебхуки
+// @Tags        webhooks
+// @Produce     json
+// @Param       id  path  int  true  "ID подписки"
+// @Success     200 {object} models.WebhookSubscription
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks/{id} [get]
+func getWebhookSubscriptionByID(c *gin.Context) {
+	id, ok := webhookIDParam(c, "getWebhookSubscriptionByID")
+	if !ok {
+		return
+	}
+	s, err := service.GetWebhookSubscriptionByID(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "getWebhookSubscriptionByID", err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Создать подписку на вебхуки
+// @Description event_types — типы событий (book.created, user.deleted, loan.returned…), маски "book.*" или "*". Если secret не задан, он генерируется и возвращается только в этом ответе (Admin only)
+// @Tags        webhooks
+// @Accept      json
+// @Produce     json
+// @Param       input  body  models.WebhookSubscriptionInput  true  "URL, события и секрет"
+// @Success     201 {object} models.WebhookSubscription
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     500 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks [post]
+func createWebhookSubscription(c *gin.Context) {
+	var in models.WebhookSubscriptionInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("createWebhookSubscription: bind error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := service.CreateWebhookSubscription(c.Request.Context(), in)
+	if err != nil {
+		handleServiceError(c, "createWebhookSubscription", err)
+		return
+	}
+	c.JSON(http.StatusCreated, s)
+}
+
+// @Summary     Изменить подписку на вебхуки
+// @Description Заменяет URL, события и описание. Секрет меняется, если передан новый или rotate_secret=true, и тогда возвращается в ответе (Admin only)
+// @Tags        webhooks
+// @Accept      json
+// @Produce     json
+// @Param       id     path  int                             true  "ID подписки"
+// @Param       input  body  models.WebhookSubscriptionInput  true  "Новые настройки"
+// @Success     200 {object} models.WebhookSubscription
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks/{id} [put]
+func updateWebhookSubscription(c *gin.Context) {
+	id, ok := webhookIDParam(c, "updateWebhookSubscription")
+	if !ok {
+		return
+	}
+	var in models.WebhookSubscriptionInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error.Printf("updateWebhookSubscription: bind error for ID %d: %v", id, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s, err := service.UpdateWebhookSubscription(c.Request.Context(), id, in)
+	if err != nil {
+		handleServiceError(c, "updateWebhookSubscription", err)
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Удалить подписку на вебхуки
+// @Tags        webhooks
+// @Param       id  path  int  true  "ID подписки"
+// @Success     204
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks/{id} [delete]
+func deleteWebhookSubscription(c *gin.Context) {
+	id, ok := webhookIDParam(c, "deleteWebhookSubscription")
+	if !ok {
+		return
+	}
+	if err := service.DeleteWebhookSubscription(c.Request.Context(), id); err != nil {
+		handleServiceError(c, "deleteWebhookSubscription", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Проверить подписку
+// @Description Синхронно отправляет событие ping и возвращает результат доставки (Admin only)
+// @Tags        webhooks
+// @Produce     json
+// @Param       id  path  int  true  "ID подписки"
+// @Success     200 {object} models.WebhookDelivery
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks/{id}/ping [post]
+func pingWebhook(c *gin.Context) {
+	id, ok := webhookIDParam(c, "pingWebhook")
+	if !ok {
+		return
+	}
+	d, err := service.PingWebhook(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, "pingWebhook", err)
+		return
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+// @Summary     Журнал доставок
+// @Description Доставки событий подписчику (новые сверху) с кодом ответа, ошибкой и числом попыток (Admin only)
+// @Tags        webhooks
+// @Produce     json
+// @Param       id      path   int  true   "ID подписки"
+// @Param       limit   query  int  false  "Размер страницы (по умолчанию 50, максимум 500)"
+// @Param       offset  query  int  false  "Смещение"
+// @Success     200 {array} models.WebhookDelivery
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks/{id}/deliveries [get]
+func getWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookIDParam(c, "getWebhookDeliveries")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	deliveries, err := service.GetWebhookDeliveries(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		handleServiceError(c, "getWebhookDeliveries", err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// @Summary     Доставить повторно
+// @Description Ставит повторную доставку события в очередь (Admin only)
+// @Tags        webhooks
+// @Produce     json
+// @Param       id          path  int  true  "ID подписки"
+// @Param       deliveryId  path  int  true  "ID доставки"
+// @Success     202 {object} models.WebhookDelivery
+// @Failure     400 {object} models.ErrorResponse
+// @Failure     404 {object} models.ErrorResponse
+// @Security    ApiKeyAuth
+// @Router      /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func redeliverWebhook(c *gin.Context) {
+	id, ok := webhookIDParam(c, "redeliverWebhook")
+	if !ok {
+		return
+	}
+	deliveryParam := c.Param("deliveryId")
+	deliveryID, err := strconv.ParseInt(deliveryParam, 10, 64)
+	if err != nil {
+		logger.Error.Printf("redeliverWebhook: invalid delivery ID param %q: %v", deliveryParam, err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery ID"})
+		return
+	}
+	d, err := service.RedeliverWebhook(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		handleServiceError(c, "redeliverWebhook", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, d)
+}
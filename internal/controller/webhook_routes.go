@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"Library/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterWebhookRoutes монтирует управление подписками на вебхуки (только admin).
+func RegisterWebhookRoutes(r *gin.Engine) {
+	hooks := r.Group("/webhooks", middleware.JWTAuthMiddleware, middleware.AdminOnly)
+	{
+		hooks.GET("", getWebhookSubscriptions)
+		hooks.POST("", createWebhookSubscription)
+		hooks.GET("/:id", getWebhookSubscriptionByID)
+		hooks.PUT("/:id", updateWebhookSubscription)
+		hooks.DELETE("/:id", deleteWebhookSubscription)
+		hooks.POST("/:id/ping", pingWebhook)
+		hooks.GET("/:id/deliveries", getWebhookDeliveries)
+		hooks.POST("/:id/deliveries/:deliveryId/redeliver", redeliverWebhook)
+	}
+}
@@ -1,42 +1,165 @@
 package db
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"Library/internal/models"
+	"Library/internal/tracing"
 	"Library/logger"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
-var db *sqlx.DB
+var (
+	db *sqlx.DB
+	// dsn нужен отдельным соединениям LISTEN, которые не берутся из пула.
+	dsn string
+)
 
-// ConnectDB открывает соединение с PostgreSQL и логирует результат.
-func ConnectDB(cfg models.PostgresParams) error {
-	// Формируем DSN
-	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Host,
-		cfg.Port,
-		cfg.User,
-		cfg.Password,
-		cfg.Database,
-	)
-	logger.Info.Printf("ConnectDB: connecting to Postgres at %s:%s/%s", cfg.Host, cfg.Port, cfg.Database)
+// Значения по умолчанию для подключения.
+const (
+	defaultSSLMode                = "disable"
+	defaultMaxOpenConns           = 25
+	defaultMaxIdleConns           = 10
+	defaultConnMaxLifetimeSeconds = 30 * 60
+	defaultConnMaxIdleTimeSeconds = 5 * 60
+	defaultConnectAttempts        = 5
+	defaultConnectTimeoutSeconds  = 5
+	defaultConnectRetryMaxSeconds = 30
+)
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true,
+}
+
+func intOrDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// quoteDSNValue экранирует значение для DSN вида key=value (пароль может содержать пробелы и кавычки).
+func quoteDSNValue(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// buildDSN собирает строку подключения к host:port с учётными данными и TLS из cfg.
+func buildDSN(cfg models.PostgresParams, host, port string) string {
+	params := [][2]string{
+		{"host", host},
+		{"port", port},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"dbname", cfg.Database},
+		{"sslmode", cfg.SSLMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+		{"connect_timeout", strconv.Itoa(cfg.ConnectTimeoutSeconds)},
+	}
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p[1] != "" {
+			parts = append(parts, p[0]+"="+quoteDSNValue(p[1]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// openPool создаёт пул соединений с трассировкой запросов (см. tracing.WrapConnector).
+// Само подключение не проверяется — database/sql открывает соединения по требованию.
+func openPool(cfg models.PostgresParams, dsn string) (*sqlx.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	pool := sqlx.NewDb(sql.OpenDB(tracing.WrapConnector(connector)), "postgres")
+	pool.SetMaxOpenConns(cfg.MaxOpenConns)
+	pool.SetMaxIdleConns(cfg.MaxIdleConns)
+	pool.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+	pool.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeSeconds) * time.Second)
+	return pool, nil
+}
 
+// pingWithRetry ждёт, пока база начнёт принимать соединения: до cfg.ConnectAttempts попыток
+// с паузой 1s, 2s, 4s… но не больше cfg.ConnectRetryMaxSeconds.
+func pingWithRetry(pool *sqlx.DB, cfg models.PostgresParams) error {
+	delay, maxDelay := time.Second, time.Duration(cfg.ConnectRetryMaxSeconds)*time.Second
 	var err error
-	db, err = sqlx.Connect("postgres", dsn)
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ConnectTimeoutSeconds)*time.Second)
+		err = pool.PingContext(ctx)
+		cancel()
+		if err == nil || attempt >= cfg.ConnectAttempts {
+			return err
+		}
+		logger.Warn.Printf("ConnectDB: attempt %d/%d failed, retrying in %s: %v", attempt, cfg.ConnectAttempts, delay, err)
+		time.Sleep(delay)
+		if delay *= 2; delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// withDefaults подставляет значения по умолчанию в нулевые поля.
+func withDefaults(cfg models.PostgresParams) (models.PostgresParams, error) {
+	if cfg.SSLMode == "" {
+		cfg.SSLMode = defaultSSLMode
+	}
+	if !sslModes[cfg.SSLMode] {
+		return cfg, fmt.Errorf("unknown sslmode %q", cfg.SSLMode)
+	}
+	cfg.MaxOpenConns = intOrDefault(cfg.MaxOpenConns, defaultMaxOpenConns)
+	cfg.MaxIdleConns = intOrDefault(cfg.MaxIdleConns, defaultMaxIdleConns)
+	if cfg.MaxIdleConns > cfg.MaxOpenConns {
+		cfg.MaxIdleConns = cfg.MaxOpenConns
+	}
+	cfg.ConnMaxLifetimeSeconds = intOrDefault(cfg.ConnMaxLifetimeSeconds, defaultConnMaxLifetimeSeconds)
+	cfg.ConnMaxIdleTimeSeconds = intOrDefault(cfg.ConnMaxIdleTimeSeconds, defaultConnMaxIdleTimeSeconds)
+	cfg.ConnectAttempts = intOrDefault(cfg.ConnectAttempts, defaultConnectAttempts)
+	cfg.ConnectTimeoutSeconds = intOrDefault(cfg.ConnectTimeoutSeconds, defaultConnectTimeoutSeconds)
+	cfg.ConnectRetryMaxSeconds = intOrDefault(cfg.ConnectRetryMaxSeconds, defaultConnectRetryMaxSeconds)
+	return cfg, nil
+}
+
+// ConnectDB открывает пул соединений с PostgreSQL (и с репликами, если они заданы) и логирует результат.
+func ConnectDB(cfg models.PostgresParams) error {
+	cfg, err := withDefaults(cfg)
+	if err != nil {
+		logger.Error.Printf("ConnectDB: invalid settings: %v", err)
+		return err
+	}
+	dsn = buildDSN(cfg, cfg.Host, cfg.Port)
+	logger.Info.Printf("ConnectDB: connecting to Postgres at %s:%s/%s sslmode=%s", cfg.Host, cfg.Port, cfg.Database, cfg.SSLMode)
+
+	pool, err := openPool(cfg, dsn)
 	if err != nil {
-		logger.Error.Printf("ConnectDB: failed to connect to Postgres: %v", err)
+		logger.Error.Printf("ConnectDB: invalid connection settings: %v", err)
+		return fmt.Errorf("invalid Postgres settings: %w", err)
+	}
+	if err := pingWithRetry(pool, cfg); err != nil {
+		_ = pool.Close()
+		logger.Error.Printf("ConnectDB: failed to connect to Postgres after %d attempts: %v", cfg.ConnectAttempts, err)
 		return fmt.Errorf("failed to connect to Postgres: %w", err)
 	}
+	db = pool
+	logger.Info.Printf("ConnectDB: ✅ Connected to PostgreSQL (max_open_conns=%d, max_idle_conns=%d)", cfg.MaxOpenConns, cfg.MaxIdleConns)
 
-	logger.Info.Println("ConnectDB: ✅ Connected to PostgreSQL")
+	if err := connectReplicas(cfg); err != nil {
+		return err
+	}
 	return nil
 }
 
-// CloseDB закрывает соединение с базой и логирует результат.
+// CloseDB закрывает соединения с базой и репликами и логирует результат.
 func CloseDB() error {
+	closeReplicas()
 	if db == nil {
 		logger.Warn.Println("CloseDB: warning: database connection is already nil")
 		return nil
@@ -57,3 +180,42 @@ func GetDBConn() *sqlx.DB {
 	}
 	return db
 }
+
+// WithTx выполняет fn в транзакции: commit, если fn вернула nil, иначе rollback.
+// Запросы внутри fn должны получать тот же ctx (tx.ExecContext(ctx, ...)).
+func WithTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := GetDBConn().BeginTxx(ctx, nil)
+	if err != nil {
+		logger.Error.Printf("WithTx: begin error: %v", err)
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.Error.Printf("WithTx: rollback error: %v", rbErr)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		logger.Error.Printf("WithTx: commit error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Listen открывает выделенное соединение и подписывается на канал NOTIFY.
+// Соединение восстанавливается само; после переподключения в Notify приходит nil —
+// знак, что часть уведомлений могла потеряться.
+func Listen(channel string) (*pq.Listener, error) {
+	l := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Warn.Printf("Listen: channel %s event %d: %v", channel, ev, err)
+		}
+	})
+	if err := l.Listen(channel); err != nil {
+		l.Close()
+		logger.Error.Printf("Listen: cannot listen on %s: %v", channel, err)
+		return nil, err
+	}
+	logger.Info.Printf("Listen: listening on channel %s", channel)
+	return l, nil
+}
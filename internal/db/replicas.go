@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"Library/internal/models"
+	"Library/logger"
+	"github.com/jmoiron/sqlx"
+)
+
+// replicaCheckInterval — как часто проверять доступность реплик.
+const replicaCheckInterval = 10 * time.Second
+
+type replica struct {
+	addr    string
+	pool    *sqlx.DB
+	healthy atomic.Bool
+}
+
+var (
+	replicas    []*replica
+	replicaNext atomic.Uint64
+	stopMonitor context.CancelFunc
+)
+
+type replicaReadsKey struct{}
+
+// AllowReplicaReads разрешает чтениям с этим контекстом уходить на реплики.
+// Включается только для GET-запросов: после записи в том же контексте реплика могла
+// ещё не получить изменения.
+func AllowReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+// UsePrimary возвращает контекст, чтения с которым снова идут на основную базу, —
+// для чтения своих же записей внутри GET-запроса.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, false)
+}
+
+// GetReadConn возвращает пул для запросов только на чтение: доступную реплику по кругу,
+// если контекст это разрешает, иначе основную базу.
+func GetReadConn(ctx context.Context) *sqlx.DB {
+	if allowed, _ := ctx.Value(replicaReadsKey{}).(bool); !allowed || len(replicas) == 0 {
+		return GetDBConn()
+	}
+	start := replicaNext.Add(1)
+	for i := range replicas {
+		r := replicas[(start+uint64(i))%uint64(len(replicas))]
+		if r.healthy.Load() {
+			return r.pool
+		}
+	}
+	return GetDBConn()
+}
+
+// ReplicaStatus возвращает доступность реплик по адресам (для /readyz).
+func ReplicaStatus() map[string]bool {
+	if len(replicas) == 0 {
+		return nil
+	}
+	status := make(map[string]bool, len(replicas))
+	for _, r := range replicas {
+		status[r.addr] = r.healthy.Load()
+	}
+	return status
+}
+
+// connectReplicas открывает пулы реплик. Недоступная при старте реплика не мешает запуску:
+// она помечается недоступной, и чтения идут на основную базу, пока проверка её не вернёт.
+func connectReplicas(cfg models.PostgresParams) error {
+	for _, hostPort := range cfg.ReplicaHosts {
+		hostPort = strings.TrimSpace(hostPort)
+		if hostPort == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			host, port = hostPort, cfg.Port
+		}
+		pool, err := openPool(cfg, buildDSN(cfg, host, port))
+		if err != nil {
+			logger.Error.Printf("ConnectDB: invalid replica %s: %v", hostPort, err)
+			return err
+		}
+		r := &replica{addr: net.JoinHostPort(host, port), pool: pool}
+		replicas = append(replicas, r)
+		checkReplica(r, cfg.ConnectTimeoutSeconds, true)
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopMonitor = cancel
+	go monitorReplicas(ctx, cfg.ConnectTimeoutSeconds)
+	logger.Info.Printf("ConnectDB: %d read replica(s) configured", len(replicas))
+	return nil
+}
+
+// checkReplica проверяет реплику и пишет в лог смену её состояния
+// (при первой проверке — и недоступность).
+func checkReplica(r *replica, timeoutSeconds int, first bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
+	defer cancel()
+	err := r.pool.PingContext(ctx)
+	was := r.healthy.Swap(err == nil)
+	switch {
+	case err != nil && (was || first):
+		logger.Warn.Printf("ConnectDB: replica %s unavailable, reads go to primary: %v", r.addr, err)
+	case err == nil && !was:
+		logger.Info.Printf("ConnectDB: replica %s available", r.addr)
+	}
+}
+
+func monitorReplicas(ctx context.Context, timeoutSeconds int) {
+	ticker := time.NewTicker(replicaCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range replicas {
+				checkReplica(r, timeoutSeconds, false)
+			}
+		}
+	}
+}
+
+func closeReplicas() {
+	if stopMonitor != nil {
+		stopMonitor()
+	}
+	for _, r := range replicas {
+		if err := r.pool.Close(); err != nil {
+			logger.Error.Printf("CloseDB: error closing replica %s: %v", r.addr, err)
+		}
+	}
+}
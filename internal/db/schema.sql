@@ -48,3 +48,447 @@ SELECT
 FROM books b
          JOIN authors a ON a.id = b.author_id
 WHERE b.id = 3;
+
+-- Обложки и файлы книг (содержимое хранится в BlobStore по SHA-256)
+CREATE TABLE IF NOT EXISTS book_files
+(
+    id             SERIAL PRIMARY KEY,
+    book_id        INTEGER     NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    kind           VARCHAR(10) NOT NULL CHECK (kind IN ('cover', 'file')),
+    file_name      TEXT        NOT NULL,
+    content_type   TEXT        NOT NULL,
+    size           BIGINT      NOT NULL,
+    sha256         CHAR(64)    NOT NULL,
+    thumbnail_hash CHAR(64)    NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS book_files_one_cover_idx
+    ON book_files (book_id) WHERE kind = 'cover';
+
+CREATE INDEX IF NOT EXISTS book_files_sha256_idx ON book_files (sha256);
+
+-- Цифровая выдача: пул лицензий на издание и выдачи читателям
+CREATE TABLE IF NOT EXISTS digital_titles
+(
+    book_id       INTEGER PRIMARY KEY REFERENCES books (id) ON DELETE CASCADE,
+    license_count INTEGER NOT NULL CHECK (license_count >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS digital_loans
+(
+    id             SERIAL PRIMARY KEY,
+    book_id        INTEGER     NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    user_id        INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    checked_out_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at     TIMESTAMPTZ NOT NULL,
+    returned_at    TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS digital_loans_active_idx
+    ON digital_loans (book_id) WHERE returned_at IS NULL;
+CREATE INDEX IF NOT EXISTS digital_loans_user_idx ON digital_loans (user_id);
+
+-- Журнал аудита административных изменений
+CREATE TABLE IF NOT EXISTS audit_events
+(
+    id          BIGSERIAL PRIMARY KEY,
+    actor_id    INTEGER     NULL,
+    action      VARCHAR(20) NOT NULL,
+    entity_type VARCHAR(30) NOT NULL,
+    entity_id   INTEGER     NOT NULL,
+    before      JSONB       NULL,
+    after       JSONB       NULL,
+    request_id  TEXT        NOT NULL DEFAULT '',
+    ip          TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_entity_idx ON audit_events (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON audit_events (created_at);
+
+-- Мягкое удаление: записи помечаются deleted_at и окончательно удаляются фоновой очисткой
+ALTER TABLE books   ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+ALTER TABLE users   ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS books_deleted_at_idx   ON books (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS authors_deleted_at_idx ON authors (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx   ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Оптимистичные блокировки: версия строки увеличивается при каждом изменении и отдаётся как ETag
+ALTER TABLE books   ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE users   ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- История записей: снимок строки на каждую версию (для просмотра на момент времени и отката)
+CREATE TABLE IF NOT EXISTS books_history
+(
+    book_id    INTEGER     NOT NULL,
+    version    INTEGER     NOT NULL,
+    name       TEXT        NOT NULL,
+    title      TEXT        NOT NULL,
+    author_id  INTEGER     NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    operation  VARCHAR(20) NOT NULL,
+    changed_by INTEGER     NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (book_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS authors_history
+(
+    author_id  INTEGER     NOT NULL,
+    version    INTEGER     NOT NULL,
+    name       TEXT        NOT NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    operation  VARCHAR(20) NOT NULL,
+    changed_by INTEGER     NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (author_id, version)
+);
+
+-- Пароль в историю не попадает
+CREATE TABLE IF NOT EXISTS users_history
+(
+    user_id    INTEGER      NOT NULL,
+    version    INTEGER      NOT NULL,
+    username   VARCHAR(50)  NOT NULL,
+    email      VARCHAR(100) NOT NULL,
+    role       VARCHAR(10)  NULL,
+    deleted_at TIMESTAMPTZ  NULL,
+    operation  VARCHAR(20)  NOT NULL,
+    changed_by INTEGER      NULL,
+    changed_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, version)
+);
+
+-- Начальные снимки для записей, созданных до появления истории
+INSERT INTO books_history (book_id, version, name, title, author_id, deleted_at, operation)
+SELECT id, version, name, title, author_id, deleted_at, 'create' FROM books
+ON CONFLICT DO NOTHING;
+INSERT INTO authors_history (author_id, version, name, deleted_at, operation)
+SELECT id, version, name, deleted_at, 'create' FROM authors
+ON CONFLICT DO NOTHING;
+INSERT INTO users_history (user_id, version, username, email, role, deleted_at, operation)
+SELECT id, version, username, email, role, deleted_at, 'create' FROM users
+ON CONFLICT DO NOTHING;
+
+-- Издатели и выходные данные изданий
+CREATE TABLE IF NOT EXISTS publishers
+(
+    id      SERIAL PRIMARY KEY,
+    name    TEXT NOT NULL UNIQUE,
+    country TEXT NOT NULL DEFAULT '',
+    website TEXT NOT NULL DEFAULT ''
+);
+
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS publisher_id   INTEGER     NULL REFERENCES publishers (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS published_year SMALLINT    NULL,
+    ADD COLUMN IF NOT EXISTS edition        TEXT        NULL,
+    ADD COLUMN IF NOT EXISTS language       VARCHAR(3)  NULL,
+    ADD COLUMN IF NOT EXISTS page_count     INTEGER     NULL CHECK (page_count > 0),
+    ADD COLUMN IF NOT EXISTS format         VARCHAR(20) NULL;
+
+CREATE INDEX IF NOT EXISTS books_publisher_idx ON books (publisher_id);
+CREATE INDEX IF NOT EXISTS books_language_year_idx ON books (language, published_year);
+
+ALTER TABLE books_history
+    ADD COLUMN IF NOT EXISTS publisher_id   INTEGER     NULL,
+    ADD COLUMN IF NOT EXISTS published_year SMALLINT    NULL,
+    ADD COLUMN IF NOT EXISTS edition        TEXT        NULL,
+    ADD COLUMN IF NOT EXISTS language       VARCHAR(3)  NULL,
+    ADD COLUMN IF NOT EXISTS page_count     INTEGER     NULL,
+    ADD COLUMN IF NOT EXISTS format         VARCHAR(20) NULL;
+
+-- Рубрикатор (дерево тем и жанров) и классификационные индексы для расстановки на полках
+CREATE TABLE IF NOT EXISTS subjects
+(
+    id        SERIAL PRIMARY KEY,
+    name      TEXT    NOT NULL,
+    parent_id INTEGER NULL REFERENCES subjects (id) ON DELETE RESTRICT,
+    CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS subjects_parent_name_uq ON subjects (COALESCE(parent_id, 0), lower(name));
+
+CREATE TABLE IF NOT EXISTS book_subjects
+(
+    book_id    INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    subject_id INTEGER NOT NULL REFERENCES subjects (id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, subject_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_subjects_subject_idx ON book_subjects (subject_id);
+
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS dewey_class VARCHAR(32) NULL,
+    ADD COLUMN IF NOT EXISTS udc_class   VARCHAR(64) NULL;
+
+CREATE INDEX IF NOT EXISTS books_shelf_order_idx ON books (dewey_class, udc_class, title);
+
+ALTER TABLE books_history
+    ADD COLUMN IF NOT EXISTS dewey_class VARCHAR(32) NULL,
+    ADD COLUMN IF NOT EXISTS udc_class   VARCHAR(64) NULL;
+
+-- Книжные серии (циклы) и порядок книг в них; позиция дробная (2.5 — повесть между томами)
+CREATE TABLE IF NOT EXISTS series
+(
+    id          SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS series_books
+(
+    series_id INTEGER       NOT NULL REFERENCES series (id) ON DELETE CASCADE,
+    book_id   INTEGER       NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    position  NUMERIC(8, 2) NOT NULL CHECK (position > 0),
+    PRIMARY KEY (series_id, book_id),
+    UNIQUE (series_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS series_books_book_idx ON series_books (book_id);
+
+-- Профиль автора и альтернативные имена (псевдонимы, транслитерации), по которым работает поиск
+ALTER TABLE authors
+    ADD COLUMN IF NOT EXISTS birth_date  DATE NULL,
+    ADD COLUMN IF NOT EXISTS death_date  DATE NULL,
+    ADD COLUMN IF NOT EXISTS biography   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS nationality TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE authors DROP CONSTRAINT IF EXISTS authors_life_dates_chk;
+ALTER TABLE authors
+    ADD CONSTRAINT authors_life_dates_chk CHECK (death_date IS NULL OR birth_date IS NULL OR death_date >= birth_date);
+
+ALTER TABLE authors_history
+    ADD COLUMN IF NOT EXISTS birth_date  DATE NULL,
+    ADD COLUMN IF NOT EXISTS death_date  DATE NULL,
+    ADD COLUMN IF NOT EXISTS biography   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS nationality TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS author_aliases
+(
+    id        SERIAL PRIMARY KEY,
+    author_id INTEGER     NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+    name      TEXT        NOT NULL,
+    kind      VARCHAR(20) NOT NULL CHECK (kind IN ('pseudonym', 'transliteration', 'alternate'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS author_aliases_author_name_uq ON author_aliases (author_id, lower(name));
+
+-- Отзывы читателей и агрегированный рейтинг книги.
+-- book_ratings пересчитывается в той же транзакции, что и изменение отзыва,
+-- поэтому список книг не агрегирует reviews на каждом запросе.
+CREATE TABLE IF NOT EXISTS reviews
+(
+    id         SERIAL PRIMARY KEY,
+    book_id    INTEGER     NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    user_id    INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    rating     SMALLINT    NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text       TEXT        NOT NULL DEFAULT '',
+    status     VARCHAR(10) NOT NULL DEFAULT 'approved' CHECK (status IN ('approved', 'hidden')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (book_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_book_status_idx ON reviews (book_id, status);
+
+CREATE TABLE IF NOT EXISTS book_ratings
+(
+    book_id      INTEGER PRIMARY KEY REFERENCES books (id) ON DELETE CASCADE,
+    rating_count INTEGER NOT NULL DEFAULT 0,
+    rating_sum   INTEGER NOT NULL DEFAULT 0
+);
+
+-- Личные списки чтения: стандартные полки (хочу прочитать, читаю, прочитано, избранное)
+-- и именованные списки; публичный список открывается по ссылке с share_token
+CREATE TABLE IF NOT EXISTS reading_lists
+(
+    id          SERIAL PRIMARY KEY,
+    user_id     INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        TEXT        NOT NULL,
+    kind        VARCHAR(20) NOT NULL DEFAULT 'custom'
+        CHECK (kind IN ('want_to_read', 'reading', 'finished', 'favourites', 'custom')),
+    visibility  VARCHAR(10) NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'public')),
+    share_token TEXT        NOT NULL UNIQUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Имена уникальны только среди своих списков: стандартные полки создаются лениво,
+-- и список с тем же именем не должен мешать их созданию
+DROP INDEX IF EXISTS reading_lists_user_name_uq;
+CREATE UNIQUE INDEX IF NOT EXISTS reading_lists_user_custom_name_uq ON reading_lists (user_id, lower(name)) WHERE kind = 'custom';
+CREATE UNIQUE INDEX IF NOT EXISTS reading_lists_user_shelf_uq ON reading_lists (user_id, kind) WHERE kind <> 'custom';
+
+CREATE TABLE IF NOT EXISTS reading_list_items
+(
+    list_id  INTEGER     NOT NULL REFERENCES reading_lists (id) ON DELETE CASCADE,
+    book_id  INTEGER     NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    position INTEGER     NOT NULL,
+    note     TEXT        NOT NULL DEFAULT '',
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (list_id, book_id)
+);
+
+CREATE INDEX IF NOT EXISTS reading_list_items_book_idx ON reading_list_items (book_id);
+
+-- Рекомендации: похожие книги по совместным выдачам и спискам чтения.
+-- Таблица пересчитывается фоновой задачей только для книг, затронутых новой активностью
+CREATE TABLE IF NOT EXISTS book_similarities
+(
+    book_id         INTEGER          NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    similar_book_id INTEGER          NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    score           DOUBLE PRECISION NOT NULL,
+    computed_at     TIMESTAMPTZ      NOT NULL DEFAULT now(),
+    PRIMARY KEY (book_id, similar_book_id)
+);
+
+CREATE TABLE IF NOT EXISTS recommendation_state
+(
+    id           BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    refreshed_at TIMESTAMPTZ NULL
+);
+
+INSERT INTO recommendation_state (id) VALUES (TRUE) ON CONFLICT DO NOTHING;
+
+-- Похожие книги раньше считались и по приватным спискам: один раз пересчитываем всё заново
+ALTER TABLE recommendation_state ADD COLUMN IF NOT EXISTS public_lists_only BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE recommendation_state SET refreshed_at = NULL, public_lists_only = TRUE WHERE NOT public_lists_only;
+
+CREATE INDEX IF NOT EXISTS digital_loans_checked_out_idx ON digital_loans (checked_out_at);
+CREATE INDEX IF NOT EXISTS reading_list_items_added_idx ON reading_list_items (added_at);
+
+-- История запусков фоновых задач планировщика. Уникальность (job_name, scheduled_for)
+-- гарантирует, что плановый запуск выполнится один раз даже при нескольких экземплярах
+CREATE TABLE IF NOT EXISTS job_runs
+(
+    id            BIGSERIAL PRIMARY KEY,
+    job_name      VARCHAR(64) NOT NULL,
+    trigger       VARCHAR(10) NOT NULL,
+    scheduled_for TIMESTAMPTZ NULL,
+    started_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at   TIMESTAMPTZ NULL,
+    status        VARCHAR(10) NOT NULL DEFAULT 'running',
+    error         TEXT        NOT NULL DEFAULT '',
+    instance      TEXT        NOT NULL DEFAULT '',
+    UNIQUE (job_name, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_idx ON job_runs (job_name, started_at DESC);
+
+-- Очередь фоновой обработки (at-least-once). Обработчики забирают задачи через
+-- FOR UPDATE SKIP LOCKED; locked_until возвращает в очередь задачи упавших обработчиков
+CREATE TABLE IF NOT EXISTS tasks
+(
+    id              BIGSERIAL PRIMARY KEY,
+    kind            VARCHAR(64)  NOT NULL,
+    payload         JSONB        NOT NULL DEFAULT '{}',
+    status          VARCHAR(10)  NOT NULL DEFAULT 'queued',
+    attempts        INT          NOT NULL DEFAULT 0,
+    max_attempts    INT          NOT NULL CHECK (max_attempts > 0),
+    run_at          TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    locked_until    TIMESTAMPTZ  NULL,
+    last_error      TEXT         NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(200) NULL UNIQUE,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    finished_at     TIMESTAMPTZ  NULL
+);
+
+CREATE INDEX IF NOT EXISTS tasks_queued_idx ON tasks (run_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS tasks_running_idx ON tasks (locked_until) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS tasks_status_idx ON tasks (status, kind);
+
+-- Уведомления читателей: входящие (канал in_app), язык и настройки каналов
+CREATE TABLE IF NOT EXISTS notifications
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind       VARCHAR(40)  NOT NULL,
+    title      TEXT         NOT NULL,
+    body       TEXT         NOT NULL,
+    link       TEXT         NOT NULL DEFAULT '',
+    dedup_key  VARCHAR(200) NULL UNIQUE,
+    read_at    TIMESTAMPTZ  NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx ON notifications (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_settings
+(
+    user_id INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    locale  VARCHAR(2) NOT NULL DEFAULT 'ru' CHECK (locale IN ('ru', 'en'))
+);
+
+-- Отсутствие строки означает, что канал включён
+CREATE TABLE IF NOT EXISTS notification_preferences
+(
+    user_id INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind    VARCHAR(40) NOT NULL,
+    channel VARCHAR(10) NOT NULL,
+    enabled BOOLEAN     NOT NULL,
+    PRIMARY KEY (user_id, kind, channel)
+);
+
+-- Отметка о напоминании, чтобы не присылать его повторно
+ALTER TABLE digital_loans
+    ADD COLUMN IF NOT EXISTS reminder_sent_at TIMESTAMPTZ NULL;
+
+-- Исходящие вебхуки: подписки, transactional outbox и журнал доставок.
+-- Событие пишется в outbox той же транзакцией, что и изменение, поэтому не теряется при сбоях
+CREATE TABLE IF NOT EXISTS webhook_subscriptions
+(
+    id          SERIAL PRIMARY KEY,
+    url         TEXT        NOT NULL,
+    secret      TEXT        NOT NULL,
+    event_types TEXT[]      NOT NULL,
+    description TEXT        NOT NULL DEFAULT '',
+    active      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS outbox_events
+(
+    id            BIGSERIAL PRIMARY KEY,
+    event_type    VARCHAR(40) NOT NULL,
+    payload       JSONB       NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx ON outbox_events (id) WHERE dispatched_at IS NULL;
+
+-- Номер события в потоке /events/stream в порядке фиксации транзакций (id выдаётся до фиксации)
+CREATE SEQUENCE IF NOT EXISTS outbox_stream_seq;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS stream_seq BIGINT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_events_stream_seq_uq ON outbox_events (stream_seq);
+CREATE INDEX IF NOT EXISTS outbox_events_unsequenced_idx ON outbox_events (id) WHERE stream_seq IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              BIGSERIAL PRIMARY KEY,
+    subscription_id INTEGER     NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        BIGINT      NULL,
+    event_type      VARCHAR(40) NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(10) NOT NULL DEFAULT 'pending',
+    attempts        INT         NOT NULL DEFAULT 0,
+    response_status INT         NULL,
+    error           TEXT        NOT NULL DEFAULT '',
+    duration_ms     INT         NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id DESC);
+
+-- Тела ответов подписчиков больше не храним: журнал показывал бы ответы внутренних сервисов
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS response_body;
@@ -15,3 +15,37 @@ var (
 	ErrNotEnoughBalance            = errors.New("not enough balance")
 	ErrInvalidOperationType        = errors.New("invalid operation type")
 )
+
+var (
+	ErrFileTooLarge         = errors.New("file too large")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrImageTooLarge        = errors.New("image dimensions too large")
+)
+
+var (
+	ErrNoLicensesAvailable = errors.New("no digital licenses available for this title")
+	ErrAlreadyBorrowed     = errors.New("title is already borrowed by this user")
+	ErrLoanExpired         = errors.New("loan expired or returned")
+	ErrInvalidSignature    = errors.New("invalid or expired download signature")
+)
+
+var (
+	ErrAuthorHasBooks      = errors.New("author still has books")
+	ErrAuthorDeleted       = errors.New("author is deleted")
+	ErrPublisherHasBooks   = errors.New("publisher still has books")
+	ErrSubjectHasChildren  = errors.New("subject still has child subjects")
+	ErrSubjectCycle        = errors.New("subject cannot be moved under its own descendant")
+	ErrSeriesPositionTaken = errors.New("series position is already taken by another book")
+	ErrListNameTaken       = errors.New("reading list with this name already exists")
+)
+
+var (
+	ErrReviewExists    = errors.New("user has already reviewed this book")
+	ErrNotReviewAuthor = errors.New("review belongs to another user")
+)
+
+var ErrTaskNotRetryable = errors.New("only dead tasks can be retried")
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+var ErrVersionMismatch = errors.New("resource was modified by another request")
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "library"
+	// statsTimeout ограничивает запрос бизнес-показателей при сборе метрик.
+	statsTimeout = 5 * time.Second
+)
+
+// registry — собственный реестр вместо глобального, чтобы на /metrics попадало только то,
+// что регистрирует приложение.
+var registry = prometheus.NewRegistry()
+
+var (
+	// enabled выключает накопление метрик, если экспорт отключён в конфиге.
+	enabled atomic.Bool
+	params  models.MetricsParams
+	handler http.Handler
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP-запросы по методу, шаблону маршрута и коду ответа.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки HTTP-запроса.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Время выполнения функций репозитория.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 15),
+	}, []string{"function"})
+)
+
+// Init включает сбор метрик и регистрирует коллекторы процесса, HTTP и базы.
+// stats вызывается при каждом опросе /metrics для бизнес-показателей.
+func Init(cfg models.MetricsParams, db *sql.DB, stats func(ctx context.Context) (models.LibraryStats, error)) {
+	if !cfg.Enabled {
+		logger.Info.Println("metrics.Init: metrics disabled")
+		return
+	}
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, namespace),
+		httpRequests,
+		httpDuration,
+		queryDuration,
+		newStatsCollector(stats),
+	)
+	params = cfg
+	handler = promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog:      logger.Error,
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+	enabled.Store(true)
+	logger.Info.Println("metrics.Init: metrics enabled on /metrics")
+}
+
+// Enabled сообщает, включён ли экспорт метрик.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Credentials возвращает логин и пароль Basic-авторизации для /metrics (пустой логин — без авторизации).
+func Credentials() (string, string) {
+	return params.Username, params.Password
+}
+
+// Handler отдаёт метрики в формате Prometheus; nil, если сбор метрик выключен.
+func Handler() http.Handler {
+	return handler
+}
+
+// ObserveHTTPRequest учитывает обработанный запрос. route — шаблон маршрута (/books/:id),
+// а не фактический путь, чтобы число рядов не росло с каждым id.
+func ObserveHTTPRequest(method, route, status string, elapsed time.Duration) {
+	if !enabled.Load() {
+		return
+	}
+	httpRequests.WithLabelValues(method, route, status).Inc()
+	httpDuration.WithLabelValues(method, route, status).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery засекает время функции репозитория:
+//
+//	defer metrics.ObserveQuery("GetBooks")()
+func ObserveQuery(function string) func() {
+	if !enabled.Load() {
+		return func() {}
+	}
+	begin := time.Now()
+	return func() {
+		queryDuration.WithLabelValues(function).Observe(time.Since(begin).Seconds())
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"context"
+
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// statsCollector снимает бизнес-показатели из базы в момент опроса /metrics,
+// поэтому значения всегда актуальны и не требуют фоновой задачи.
+type statsCollector struct {
+	stats func(ctx context.Context) (models.LibraryStats, error)
+
+	books        *prometheus.Desc
+	users        *prometheus.Desc
+	activeLoans  *prometheus.Desc
+	overdueLoans *prometheus.Desc
+	up           *prometheus.Desc
+}
+
+func newStatsCollector(stats func(ctx context.Context) (models.LibraryStats, error)) *statsCollector {
+	return &statsCollector{
+		stats:        stats,
+		books:        prometheus.NewDesc(namespace+"_books", "Книги в каталоге (без удалённых).", nil, nil),
+		users:        prometheus.NewDesc(namespace+"_users", "Зарегистрированные читатели (без удалённых).", nil, nil),
+		activeLoans:  prometheus.NewDesc(namespace+"_active_loans", "Действующие выдачи электронных книг.", nil, nil),
+		overdueLoans: prometheus.NewDesc(namespace+"_overdue_loans", "Просроченные выдачи, ещё не закрытые фоновой задачей.", nil, nil),
+		up:           prometheus.NewDesc(namespace+"_stats_up", "1, если бизнес-показатели удалось получить из базы.", nil, nil),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.books
+	ch <- c.users
+	ch <- c.activeLoans
+	ch <- c.overdueLoans
+	ch <- c.up
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	s, err := c.stats(ctx)
+	if err != nil {
+		logger.Warn.Printf("metrics.statsCollector: cannot collect library stats: %v", err)
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(c.books, prometheus.GaugeValue, float64(s.Books))
+	ch <- prometheus.MustNewConstMetric(c.users, prometheus.GaugeValue, float64(s.Users))
+	ch <- prometheus.MustNewConstMetric(c.activeLoans, prometheus.GaugeValue, float64(s.ActiveLoans))
+	ch <- prometheus.MustNewConstMetric(c.overdueLoans, prometheus.GaugeValue, float64(s.OverdueLoans))
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	"Library/internal/service"
 	"Library/logger"
 	"Library/utils"
 	"github.com/gin-gonic/gin"
@@ -45,6 +47,18 @@ func JWTAuthMiddleware(c *gin.Context) {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token: " + err.Error()})
 		return
 	}
+	// 3.1. Токен удалённого пользователя больше не действует
+	active, err := service.IsActiveUser(c.Request.Context(), claims.UserID)
+	if err != nil {
+		logger.Error.Printf("JWTAuthMiddleware: cannot check userID=%d: %v", claims.UserID, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "cannot verify token owner"})
+		return
+	}
+	if !active {
+		logger.Warn.Printf("JWTAuthMiddleware: token of deleted userID=%d", claims.UserID)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user is deleted"})
+		return
+	}
 	logger.Info.Printf("JWTAuthMiddleware: token valid userID=%d username=%q role=%q",
 		claims.UserID, claims.Username, claims.Role)
 
@@ -56,3 +70,36 @@ func JWTAuthMiddleware(c *gin.Context) {
 	// 5. Продолжаем цепочку handlers
 	c.Next()
 }
+
+// GetUserID возвращает ID пользователя, положенный в контекст JWTAuthMiddleware.
+func GetUserID(c *gin.Context) int {
+	return c.GetInt(ctxUserIDKey)
+}
+
+// GetUserRole возвращает роль пользователя из контекста.
+func GetUserRole(c *gin.Context) string {
+	return c.GetString(ctxRoleKey)
+}
+
+// OptionalJWT разбирает токен, если он передан, но не отклоняет анонимные запросы.
+// Нужен публичным маршрутам, поведение которых зависит от роли (например, ?include_deleted).
+func OptionalJWT(c *gin.Context) {
+	parts := strings.Fields(c.GetHeader(authHeaderKey))
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		claims, err := utils.ParseToken(parts[1])
+		if err == nil {
+			var active bool
+			if active, err = service.IsActiveUser(c.Request.Context(), claims.UserID); err == nil && !active {
+				err = errors.New("user is deleted")
+			}
+		}
+		if err == nil {
+			c.Set(ctxUserIDKey, claims.UserID)
+			c.Set(ctxUsernameKey, claims.Username)
+			c.Set(ctxRoleKey, claims.Role)
+		} else {
+			logger.Debug.Printf("OptionalJWT: ignoring invalid token: %v", err)
+		}
+	}
+	c.Next()
+}
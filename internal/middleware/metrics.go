@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"Library/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics учитывает число и длительность запросов по шаблону маршрута и коду ответа.
+// Запросы мимо маршрутов сводятся в один ряд, чтобы сканеры не раздували метрики.
+func Metrics(c *gin.Context) {
+	begin := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metrics.ObserveHTTPRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(begin))
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"Library/internal/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicaReads разрешает GET- и HEAD-запросам читать с реплик (если они настроены).
+// Запросы, которые что-то меняют, читают с основной базы, чтобы видеть свои же записи.
+func ReplicaReads(c *gin.Context) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		c.Request = c.Request.WithContext(db.AllowReplicaReads(c.Request.Context()))
+	}
+	c.Next()
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	ctxRequestIDKey = "requestID"
+)
+
+// RequestID присваивает каждому запросу идентификатор (или берёт его из X-Request-ID)
+// и возвращает его в ответе, чтобы связать логи, аудит и обращения клиентов.
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if id == "" || len(id) > 64 {
+		buf := make([]byte, 16)
+		_, _ = rand.Read(buf)
+		id = hex.EncodeToString(buf)
+	}
+	c.Set(ctxRequestIDKey, id)
+	c.Header(requestIDHeader, id)
+	c.Next()
+}
+
+// GetRequestID возвращает идентификатор текущего запроса.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(ctxRequestIDKey)
+}
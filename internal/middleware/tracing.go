@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+
+	"Library/internal/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Tracing открывает серверный спан на запрос, продолжая трассу из traceparent клиента.
+// Контекст со спаном кладётся в c.Request, поэтому обработчики передают в сервисы
+// c.Request.Context(), и спаны сервисов и SQL становятся дочерними.
+func Tracing(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	ctx := tracing.Extract(c.Request.Context(), c.Request.Header)
+	ctx, span := tracing.StartServer(ctx, c.Request.Method+" "+route,
+		attribute.String("http.request.method", c.Request.Method),
+		attribute.String("http.route", route),
+		attribute.String("url.path", c.Request.URL.Path),
+		attribute.String("request.id", GetRequestID(c)),
+	)
+	defer span.End()
+
+	if id := tracing.TraceID(ctx); id != "" {
+		c.Header("X-Trace-ID", id)
+	}
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+	}
+	if len(c.Errors) > 0 {
+		span.RecordError(c.Errors.Last())
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Действия, фиксируемые в журнале аудита.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionRevert  = "revert"
+	AuditActionMerge   = "merge"
+)
+
+// Типы сущностей в журнале аудита.
+const (
+	AuditEntityBook      = "book"
+	AuditEntityAuthor    = "author"
+	AuditEntityUser      = "user"
+	AuditEntityPublisher = "publisher"
+	AuditEntitySubject   = "subject"
+	AuditEntitySeries    = "series"
+	AuditEntityReview    = "review"
+)
+
+// AuditActor — кто и откуда выполняет изменение.
+// UserID == 0 означает анонимное действие (например, самостоятельная регистрация).
+type AuditActor struct {
+	UserID    int
+	RequestID string
+	IP        string
+}
+
+// AuditEvent — запись журнала аудита.
+// Before/After содержат только изменившиеся поля (для create/delete — весь снимок).
+type AuditEvent struct {
+	ID         int64          `db:"id"          json:"id"`
+	ActorID    *int           `db:"actor_id"    json:"actor_id"`
+	Action     string         `db:"action"      json:"action"`
+	EntityType string         `db:"entity_type" json:"entity_type"`
+	EntityID   int            `db:"entity_id"   json:"entity_id"`
+	Before     types.JSONText `db:"before"      json:"before,omitempty" swaggertype:"object"`
+	After      types.JSONText `db:"after"       json:"after,omitempty"  swaggertype:"object"`
+	RequestID  string         `db:"request_id"  json:"request_id"`
+	IP         string         `db:"ip"          json:"ip"`
+	CreatedAt  time.Time      `db:"created_at"  json:"created_at"`
+}
+
+// AuditFilter — параметры выборки журнала аудита.
+type AuditFilter struct {
+	ActorID    *int
+	Action     string
+	EntityType string
+	EntityID   *int
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// PurgeResult — сколько записей окончательно удалено очисткой мягко удалённых строк.
+type PurgeResult struct {
+	Books   int `json:"books"`
+	Authors int `json:"authors"`
+	Users   int `json:"users"`
+}
@@ -1,7 +1,30 @@
 package models
 
+import "time"
+
 type Author struct {
-	ID    int    `db:"id" json:"id"`
-	Name  string `db:"name" json:"name"`
-	Books []Book `json:"books,omitempty"`
+	ID          int           `db:"id" json:"id"`
+	Name        string        `db:"name" json:"name"`
+	BirthDate   *string       `db:"birth_date" json:"birth_date,omitempty"` // YYYY-MM-DD
+	DeathDate   *string       `db:"death_date" json:"death_date,omitempty"` // YYYY-MM-DD
+	Biography   string        `db:"biography" json:"biography,omitempty"`
+	Nationality string        `db:"nationality" json:"nationality,omitempty"`
+	Aliases     []AuthorAlias `db:"-" json:"aliases,omitempty"`
+	Books       []Book        `json:"books,omitempty"`
+	Version     int           `db:"version" json:"version"`
+	DeletedAt   *time.Time    `db:"deleted_at" json:"deleted_at,omitempty"`
+}
+
+// Виды альтернативных имён автора.
+const (
+	AuthorAliasPseudonym       = "pseudonym"       // литературный псевдоним
+	AuthorAliasTransliteration = "transliteration" // написание другой графикой (кириллица/латиница)
+	AuthorAliasAlternate       = "alternate"       // прочие варианты написания, в т.ч. имена слитых дублей
+)
+
+// AuthorAlias — альтернативное имя автора, по которому его тоже можно найти.
+type AuthorAlias struct {
+	AuthorID int    `db:"author_id" json:"-"`
+	Name     string `db:"name"      json:"name"`
+	Kind     string `db:"kind"      json:"kind"`
 }
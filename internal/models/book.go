@@ -1,9 +1,49 @@
 package models
 
+import "time"
+
 type Book struct {
-	ID         int    `db:"id"       json:"id"`
-	Name       string `db:"name"     json:"name"`
-	Title      string `db:"title"    json:"title"`
-	AuthorID   int    `db:"author_id" json:"author_id"`
-	AuthorName string `db:"author_name" json:"author"`
+	ID            int          `db:"id"       json:"id"`
+	Name          string       `db:"name"     json:"name"`
+	Title         string       `db:"title"    json:"title"`
+	AuthorID      int          `db:"author_id" json:"author_id"`
+	AuthorName    string       `db:"author_name" json:"author"`
+	PublisherID   *int         `db:"publisher_id"   json:"publisher_id,omitempty"`
+	PublisherName *string      `db:"publisher_name" json:"publisher,omitempty"`
+	PublishedYear *int         `db:"published_year" json:"published_year,omitempty"`
+	Edition       *string      `db:"edition"        json:"edition,omitempty"`
+	Language      *string      `db:"language"       json:"language,omitempty"`
+	PageCount     *int         `db:"page_count"     json:"page_count,omitempty"`
+	Format        *string      `db:"format"         json:"format,omitempty"`
+	DeweyClass    *string      `db:"dewey_class"    json:"dewey_class,omitempty"`
+	UDCClass      *string      `db:"udc_class"      json:"udc_class,omitempty"`
+	RatingAvg     *float64     `db:"rating_avg"     json:"rating_avg,omitempty"`
+	RatingCount   int          `db:"rating_count"   json:"rating_count"`
+	Version       int          `db:"version" json:"version"`
+	DeletedAt     *time.Time   `db:"deleted_at" json:"deleted_at,omitempty"`
+	NextInSeries  []SeriesLink `db:"-" json:"next_in_series,omitempty"`
 }
+
+// Допустимые форматы издания.
+const (
+	BookFormatHardcover = "hardcover"
+	BookFormatPaperback = "paperback"
+	BookFormatEbook     = "ebook"
+	BookFormatAudiobook = "audiobook"
+)
+
+// BookFilter — параметры выборки списка книг.
+type BookFilter struct {
+	IncludeDeleted bool
+	PublisherID    *int
+	Language       string
+	YearFrom       *int
+	YearTo         *int
+	Sort           string
+}
+
+// Порядок сортировки списка книг.
+const (
+	BookSortID    = "id"
+	BookSortShelf = "shelf" // расстановка на полке: по индексу Дьюи, затем УДК
+)
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Виды файлов, прикреплённых к книге.
+const (
+	BookFileKindCover = "cover"
+	BookFileKindFile  = "file"
+)
+
+// BookFile — метаданные обложки или файла книги; само содержимое лежит в BlobStore.
+type BookFile struct {
+	ID            int       `db:"id"             json:"id"`
+	BookID        int       `db:"book_id"        json:"book_id"`
+	Kind          string    `db:"kind"           json:"kind"`
+	FileName      string    `db:"file_name"      json:"file_name"`
+	ContentType   string    `db:"content_type"   json:"content_type"`
+	Size          int64     `db:"size"           json:"size"`
+	SHA256        string    `db:"sha256"         json:"sha256"`
+	ThumbnailHash *string   `db:"thumbnail_hash" json:"-"`
+	CreatedAt     time.Time `db:"created_at"     json:"created_at"`
+}
@@ -1,14 +1,26 @@
 package models
 
 type Configs struct {
-	AuthParams     AuthParams     `json:"auth_params"`
-	LogParams      LogParams      `json:"log_params"`
-	AppParams      AppParams      `json:"app_params"`
-	PostgresParams PostgresParams `json:"postgres_params"`
+	AuthParams       AuthParams       `json:"auth_params"`
+	LogParams        LogParams        `json:"log_params"`
+	AppParams        AppParams        `json:"app_params"`
+	PostgresParams   PostgresParams   `json:"postgres_params"`
+	StorageParams    StorageParams    `json:"storage_params"`
+	LendingParams    LendingParams    `json:"lending_params"`
+	SoftDeleteParams SoftDeleteParams `json:"soft_delete_params"`
+	RecommendParams  RecommendParams  `json:"recommend_params"`
+	SchedulerParams  SchedulerParams  `json:"scheduler_params"`
+	QueueParams      QueueParams      `json:"queue_params"`
+	NotifyParams     NotifyParams     `json:"notify_params"`
+	WebhookParams    WebhookParams    `json:"webhook_params"`
+	MetricsParams    MetricsParams    `json:"metrics_params"`
+	TracingParams    TracingParams    `json:"tracing_params"`
 }
 type AuthParams struct {
 	JwtSecretKey  string `json:"jwt_secret_key"`
 	JwtTtlMinutes int    `json:"jwt_ttl_minutes"`
+	// DownloadSigningKey — ключ HMAC для подписи ссылок на скачивание e-книг.
+	DownloadSigningKey string `json:"download_signing_key"`
 }
 
 type LogParams struct {
@@ -30,12 +42,145 @@ type AppParams struct {
 	AppVersion string `json:"app_version"`
 	PortRun    string `json:"port_run"`
 	GinMode    string `json:"gin_mode"`
+
+	// Параметры HTTP-сервера; нулевые значения заменяются значениями по умолчанию.
+	ReadTimeoutSeconds       int `json:"read_timeout_seconds"`
+	ReadHeaderTimeoutSeconds int `json:"read_header_timeout_seconds"`
+	WriteTimeoutSeconds      int `json:"write_timeout_seconds"`
+	IdleTimeoutSeconds       int `json:"idle_timeout_seconds"`
+	MaxHeaderBytes           int `json:"max_header_bytes"`
+	// ShutdownTimeoutSeconds — сколько ждать завершения запросов и фоновых задач при остановке.
+	ShutdownTimeoutSeconds int `json:"shutdown_timeout_seconds"`
+	// ShutdownDelaySeconds — сколько после сигнала отвечать 503 на /readyz, продолжая обслуживать
+	// запросы, чтобы балансировщик успел вывести экземпляр из ротации.
+	ShutdownDelaySeconds int `json:"shutdown_delay_seconds"`
 }
 
+// PostgresParams — подключение к PostgreSQL. Адрес и учётные данные берутся из .env (DB_*),
+// остальное — из postgres_params в configs.json; нулевые значения заменяются значениями по умолчанию.
 type PostgresParams struct {
 	User     string `json:"user"`
 	Host     string `json:"host"`
 	Port     string `json:"port"`
 	Password string `json:"-"`
 	Database string `json:"database"`
+
+	// SSLMode — disable, allow, prefer, require, verify-ca или verify-full (по умолчанию disable).
+	SSLMode     string `json:"sslmode"`
+	SSLRootCert string `json:"sslrootcert"`
+	SSLCert     string `json:"sslcert"`
+	SSLKey      string `json:"sslkey"`
+
+	// Пул соединений (применяется и к репликам).
+	MaxOpenConns           int `json:"max_open_conns"`
+	MaxIdleConns           int `json:"max_idle_conns"`
+	ConnMaxLifetimeSeconds int `json:"conn_max_lifetime_seconds"`
+	ConnMaxIdleTimeSeconds int `json:"conn_max_idle_time_seconds"`
+
+	// ConnectAttempts — сколько раз пытаться подключиться при старте, пока Postgres поднимается;
+	// пауза между попытками растёт вдвое до ConnectRetryMaxSeconds.
+	ConnectAttempts        int `json:"connect_attempts"`
+	ConnectTimeoutSeconds  int `json:"connect_timeout_seconds"`
+	ConnectRetryMaxSeconds int `json:"connect_retry_max_seconds"`
+
+	// ReplicaHosts — реплики для чтения ("host" или "host:port", учётные данные как у основной базы).
+	// На реплики уходят только чтения из GET-запросов; всё остальное — на основную базу.
+	ReplicaHosts []string `json:"replica_hosts"`
+}
+
+type StorageParams struct {
+	Driver         string `json:"driver"`
+	Directory      string `json:"directory"`
+	MaxCoverSizeMB int    `json:"max_cover_size_mb"`
+	MaxFileSizeMB  int    `json:"max_file_size_mb"`
+	ThumbnailWidth int    `json:"thumbnail_width"`
+	// MaxCoverMegapixels — предел ширина×высота обложки, проверяемый по заголовку до декодирования.
+	MaxCoverMegapixels int `json:"max_cover_megapixels"`
+}
+
+type LendingParams struct {
+	LoanDays               int `json:"loan_days"`
+	DownloadLinkTTLMinutes int `json:"download_link_ttl_minutes"`
+	DefaultLicenseCount    int `json:"default_license_count"`
+	ExpiryCheckMinutes     int `json:"expiry_check_minutes"`
+}
+
+type SoftDeleteParams struct {
+	RetentionDays        int `json:"retention_days"`
+	PurgeIntervalMinutes int `json:"purge_interval_minutes"`
+}
+
+type RecommendParams struct {
+	RefreshIntervalMinutes int `json:"refresh_interval_minutes"`
+	// TopN — сколько похожих книг хранится для каждой книги.
+	TopN int `json:"top_n"`
+}
+
+// SchedulerParams — расписания фоновых задач. Ключ — имя задачи (см. GET /jobs).
+type SchedulerParams struct {
+	Jobs map[string]JobParams `json:"jobs"`
+}
+
+type JobParams struct {
+	// Schedule — cron-выражение из 5 полей ("*/5 * * * *"), @hourly/@daily/... или "@every 10m".
+	Schedule string `json:"schedule"`
+	Disabled bool   `json:"disabled"`
+}
+
+// QueueParams — очередь фоновой обработки в PostgreSQL. Нулевые значения заменяются значениями по умолчанию.
+type QueueParams struct {
+	Workers             int `json:"workers"`
+	PollIntervalSeconds int `json:"poll_interval_seconds"`
+	MaxAttempts         int `json:"max_attempts"`
+	// Пауза перед повтором: base * 2^(попытка-1), но не больше max.
+	BaseBackoffSeconds int `json:"base_backoff_seconds"`
+	MaxBackoffSeconds  int `json:"max_backoff_seconds"`
+	// LockTimeoutSeconds — через сколько задачу упавшего обработчика заберёт другой.
+	LockTimeoutSeconds int `json:"lock_timeout_seconds"`
+	// RetentionDays — сколько хранить выполненные задачи.
+	RetentionDays int `json:"retention_days"`
+}
+
+// NotifyParams — доставка уведомлений. Sender: "file" (письма складываются в Directory) или "smtp".
+type NotifyParams struct {
+	Sender       string `json:"sender"`
+	Directory    string `json:"directory"`
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"smtp_password"`
+	From         string `json:"from"`
+	// DueReminderHours — за сколько часов до окончания выдачи напоминать читателю.
+	DueReminderHours int `json:"due_reminder_hours"`
+}
+
+// WebhookParams — исходящие вебхуки. Повторы доставки выполняет очередь задач (queue_params).
+type WebhookParams struct {
+	// RelayIntervalSeconds — как часто переносить события из outbox в очередь доставки.
+	RelayIntervalSeconds int `json:"relay_interval_seconds"`
+	TimeoutSeconds       int `json:"timeout_seconds"`
+	MaxAttempts          int `json:"max_attempts"`
+	// RetentionDays — сколько хранить разосланные события outbox и журнал доставок.
+	RetentionDays int `json:"retention_days"`
+}
+
+// MetricsParams — экспорт метрик Prometheus на /metrics. Если Username задан,
+// эндпоинт закрывается Basic-авторизацией.
+type MetricsParams struct {
+	Enabled  bool   `json:"enabled"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// TracingParams — трассировка OpenTelemetry. Exporter: "" (выключено), "stdout" (спаны в stdout,
+// для локальной отладки) или "otlp" (OTLP/HTTP на Endpoint).
+type TracingParams struct {
+	Exporter string `json:"exporter"`
+	// Endpoint — адрес коллектора: host:port или полный URL (https://collector:4318/v1/traces).
+	Endpoint string            `json:"endpoint"`
+	Insecure bool              `json:"insecure"`
+	Headers  map[string]string `json:"headers"`
+	// SampleRatio — доля трассируемых запросов (0..1); 0 означает «все». Решение вызывающего
+	// сервиса из traceparent соблюдается.
+	SampleRatio float64 `json:"sample_ratio"`
 }
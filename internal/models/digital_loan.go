@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// DigitalLoan — выдача электронной книги читателю.
+// Лицензия считается занятой, пока ReturnedAt пуст и ExpiresAt не наступил.
+type DigitalLoan struct {
+	ID           int        `db:"id"             json:"id"`
+	BookID       int        `db:"book_id"        json:"book_id"`
+	BookTitle    string     `db:"book_title"     json:"book_title,omitempty"`
+	UserID       int        `db:"user_id"        json:"user_id"`
+	CheckedOutAt time.Time  `db:"checked_out_at" json:"checked_out_at"`
+	ExpiresAt    time.Time  `db:"expires_at"     json:"expires_at"`
+	ReturnedAt   *time.Time `db:"returned_at"    json:"returned_at,omitempty"`
+}
+
+// DownloadLink — подписанная ссылка на скачивание файла по выдаче.
+type DownloadLink struct {
+	FileID      int       `json:"file_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// DigitalLoanWithLinks — выдача вместе со ссылками на файлы.
+type DigitalLoanWithLinks struct {
+	DigitalLoan
+	Links []DownloadLink `json:"links"`
+}
+
+// DigitalAvailability — состояние пула лицензий на издание.
+type DigitalAvailability struct {
+	BookID       int `db:"book_id"       json:"book_id"`
+	LicenseCount int `db:"license_count" json:"license_count"`
+	ActiveLoans  int `db:"active_loans"  json:"active_loans"`
+	Available    int `db:"-"             json:"available"`
+}
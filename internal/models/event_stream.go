@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// StreamEvent — событие изменения каталога в потоке GET /events/stream.
+// ID — номер события outbox в потоке (в порядке фиксации транзакций), он же Last-Event-ID
+// для продолжения потока.
+type StreamEvent struct {
+	ID         int64           `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data" swaggertype:"object"`
+	ActorID    *int            `json:"actor_id,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	// EntityID нужен для проверки прав: читатель видит события о пользователях только про себя.
+	EntityID int `json:"-"`
+}
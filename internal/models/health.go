@@ -0,0 +1,21 @@
+package models
+
+// Статусы проверок готовности.
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheck — результат одной проверки.
+type HealthCheck struct {
+	Status    string          `json:"status"`
+	LatencyMS float64         `json:"latency_ms"`
+	Error     string          `json:"error,omitempty"`
+	Details   map[string]bool `json:"details,omitempty"`
+}
+
+// HealthReport — ответ /readyz: общий статус и результат каждой проверки.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
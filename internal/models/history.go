@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// BookHistory — снимок записи книги на момент конкретной версии.
+type BookHistory struct {
+	BookID        int        `db:"book_id"    json:"book_id"`
+	Version       int        `db:"version"    json:"version"`
+	Name          string     `db:"name"       json:"name"`
+	Title         string     `db:"title"      json:"title"`
+	AuthorID      int        `db:"author_id"  json:"author_id"`
+	PublisherID   *int       `db:"publisher_id"   json:"publisher_id,omitempty"`
+	PublishedYear *int       `db:"published_year" json:"published_year,omitempty"`
+	Edition       *string    `db:"edition"        json:"edition,omitempty"`
+	Language      *string    `db:"language"       json:"language,omitempty"`
+	PageCount     *int       `db:"page_count"     json:"page_count,omitempty"`
+	Format        *string    `db:"format"         json:"format,omitempty"`
+	DeweyClass    *string    `db:"dewey_class"    json:"dewey_class,omitempty"`
+	UDCClass      *string    `db:"udc_class"      json:"udc_class,omitempty"`
+	DeletedAt     *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	Operation     string     `db:"operation"  json:"operation"`
+	ChangedBy     *int       `db:"changed_by" json:"changed_by"`
+	ChangedAt     time.Time  `db:"changed_at" json:"changed_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// JobInfo — фоновая задача планировщика и её состояние на этом экземпляре.
+type JobInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Schedule    string     `json:"schedule"`
+	Enabled     bool       `json:"enabled"`
+	Running     bool       `json:"running"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	LastRun     *JobRun    `json:"last_run,omitempty"`
+}
+
+// JobRun — запись истории запусков задачи.
+type JobRun struct {
+	ID           int64      `db:"id"            json:"id"`
+	JobName      string     `db:"job_name"      json:"job_name"`
+	Trigger      string     `db:"trigger"       json:"trigger"`
+	ScheduledFor *time.Time `db:"scheduled_for" json:"scheduled_for,omitempty"`
+	StartedAt    time.Time  `db:"started_at"    json:"started_at"`
+	FinishedAt   *time.Time `db:"finished_at"   json:"finished_at,omitempty"`
+	Status       string     `db:"status"        json:"status"`
+	Error        string     `db:"error"         json:"error,omitempty"`
+	Instance     string     `db:"instance"      json:"instance"`
+}
+
+// Источник запуска задачи.
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+)
+
+// Статусы запуска задачи.
+const (
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusSkipped   = "skipped"
+)
@@ -0,0 +1,9 @@
+package models
+
+// LibraryStats — бизнес-показатели для метрик: сколько книг, читателей и выдач сейчас в системе.
+type LibraryStats struct {
+	Books        int `db:"books"`
+	Users        int `db:"users"`
+	ActiveLoans  int `db:"active_loans"`
+	OverdueLoans int `db:"overdue_loans"`
+}
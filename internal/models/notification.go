@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Виды уведомлений.
+const (
+	NotificationKindLoanDueSoon = "loan_due_soon"
+)
+
+// Каналы доставки уведомлений.
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+)
+
+// Языки шаблонов уведомлений.
+const (
+	LocaleRU = "ru"
+	LocaleEN = "en"
+)
+
+// Notification — уведомление во входящих читателя.
+type Notification struct {
+	ID        int64      `db:"id"         json:"id"`
+	UserID    int        `db:"user_id"    json:"-"`
+	Kind      string     `db:"kind"       json:"kind"`
+	Title     string     `db:"title"      json:"title"`
+	Body      string     `db:"body"       json:"body"`
+	Link      string     `db:"link"       json:"link,omitempty"`
+	ReadAt    *time.Time `db:"read_at"    json:"read_at,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// NotificationInbox — страница входящих и число непрочитанных.
+type NotificationInbox struct {
+	UnreadCount int            `json:"unread_count"`
+	Items       []Notification `json:"items"`
+}
+
+// NotificationPreference — включён ли канал для вида уведомлений.
+type NotificationPreference struct {
+	Kind    string `db:"kind"    json:"kind"`
+	Channel string `db:"channel" json:"channel"`
+	Enabled bool   `db:"enabled" json:"enabled"`
+}
+
+// NotificationSettings — язык уведомлений и настройки каналов читателя.
+// В ответе перечислены все сочетания вида и канала; по умолчанию всё включено.
+type NotificationSettings struct {
+	Locale      string                   `json:"locale"`
+	Preferences []NotificationPreference `json:"preferences"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import "encoding/xml"
+
+// Типы ссылок и rel-ы, используемые в OPDS-каталоге.
+const (
+	OPDSNavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	OPDSAcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	OPDSEntryType       = "application/atom+xml;type=entry;profile=opds-catalog"
+	OPDSOpenSearchType  = "application/opensearchdescription+xml"
+	OPDS2Type           = "application/opds+json"
+
+	OPDSAcquisitionRel = "http://opds-spec.org/acquisition"
+	OPDSBorrowRel      = "http://opds-spec.org/acquisition/borrow"
+)
+
+// AtomFeed — OPDS 1.2 фид (Atom).
+type AtomFeed struct {
+	XMLName   xml.Name    `xml:"feed"`
+	Xmlns     string      `xml:"xmlns,attr"`
+	XmlnsDC   string      `xml:"xmlns:dc,attr,omitempty"`
+	XmlnsOPDS string      `xml:"xmlns:opds,attr,omitempty"`
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Author    *AtomAuthor `xml:"author,omitempty"`
+	Links     []AtomLink  `xml:"link"`
+	Entries   []AtomEntry `xml:"entry"`
+}
+
+// AtomEntry — элемент фида: либо ссылка навигации, либо книга.
+// Пространства имён заполняются, только когда entry отдаётся отдельным документом.
+type AtomEntry struct {
+	XMLName   xml.Name     `xml:"entry"`
+	Xmlns     string       `xml:"xmlns,attr,omitempty"`
+	XmlnsOPDS string       `xml:"xmlns:opds,attr,omitempty"`
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Updated   string       `xml:"updated"`
+	Authors   []AtomAuthor `xml:"author,omitempty"`
+	Content   *AtomContent `xml:"content,omitempty"`
+	Links     []AtomLink   `xml:"link"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type AtomLink struct {
+	Rel                 string                    `xml:"rel,attr,omitempty"`
+	Href                string                    `xml:"href,attr"`
+	Type                string                    `xml:"type,attr,omitempty"`
+	Title               string                    `xml:"title,attr,omitempty"`
+	IndirectAcquisition []OPDSIndirectAcquisition `xml:"opds:indirectAcquisition,omitempty"`
+}
+
+// OPDSIndirectAcquisition сообщает клиенту, какой формат он получит, пройдя по ссылке выдачи.
+type OPDSIndirectAcquisition struct {
+	Type string `xml:"type,attr"`
+}
+
+// OpenSearchDescription — описание поиска для OPDS-клиентов.
+type OpenSearchDescription struct {
+	XMLName        xml.Name      `xml:"OpenSearchDescription"`
+	Xmlns          string        `xml:"xmlns,attr"`
+	ShortName      string        `xml:"ShortName"`
+	Description    string        `xml:"Description"`
+	InputEncoding  string        `xml:"InputEncoding"`
+	OutputEncoding string        `xml:"OutputEncoding"`
+	URL            OpenSearchURL `xml:"Url"`
+}
+
+type OpenSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// OPDS2Feed — OPDS 2.0 фид (JSON).
+type OPDS2Feed struct {
+	Metadata     OPDS2Metadata      `json:"metadata"`
+	Links        []OPDS2Link        `json:"links"`
+	Navigation   []OPDS2Link        `json:"navigation,omitempty"`
+	Publications []OPDS2Publication `json:"publications,omitempty"`
+}
+
+type OPDS2Metadata struct {
+	Title         string   `json:"title"`
+	Identifier    string   `json:"identifier,omitempty"`
+	Modified      string   `json:"modified,omitempty"`
+	Author        []string `json:"author,omitempty"`
+	NumberOfItems int      `json:"numberOfItems,omitempty"`
+}
+
+type OPDS2Link struct {
+	Rel       string `json:"rel,omitempty"`
+	Href      string `json:"href"`
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Templated bool   `json:"templated,omitempty"`
+}
+
+type OPDS2Publication struct {
+	Metadata OPDS2Metadata `json:"metadata"`
+	Links    []OPDS2Link   `json:"links"`
+}
@@ -0,0 +1,8 @@
+package models
+
+type Publisher struct {
+	ID      int    `db:"id"      json:"id"`
+	Name    string `db:"name"    json:"name"`
+	Country string `db:"country" json:"country,omitempty"`
+	Website string `db:"website" json:"website,omitempty"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ReadingList — личный список книг читателя: одна из стандартных полок или именованный список.
+type ReadingList struct {
+	ID         int               `db:"id"          json:"id"`
+	UserID     int               `db:"user_id"     json:"user_id"`
+	Name       string            `db:"name"        json:"name"`
+	Kind       string            `db:"kind"        json:"kind"`
+	Visibility string            `db:"visibility"  json:"visibility"`
+	ShareToken string            `db:"share_token" json:"-"`
+	ShareURL   string            `db:"-"           json:"share_url,omitempty"`
+	ItemCount  int               `db:"item_count"  json:"item_count"`
+	CreatedAt  time.Time         `db:"created_at"  json:"created_at"`
+	UpdatedAt  time.Time         `db:"updated_at"  json:"updated_at"`
+	Items      []ReadingListItem `db:"-"           json:"items,omitempty"`
+}
+
+// ReadingListItem — книга в списке, её место и заметка читателя.
+type ReadingListItem struct {
+	Position int       `db:"position" json:"position"`
+	Note     string    `db:"note"     json:"note,omitempty"`
+	AddedAt  time.Time `db:"added_at" json:"added_at"`
+	Book
+}
+
+// Виды списков. Стандартные полки создаются у каждого читателя автоматически
+// и не удаляются; custom — списки, созданные самим читателем.
+const (
+	ReadingListWantToRead = "want_to_read"
+	ReadingListReading    = "reading"
+	ReadingListFinished   = "finished"
+	ReadingListFavourites = "favourites"
+	ReadingListCustom     = "custom"
+)
+
+// Видимость списка: публичный список доступен любому по ссылке share_url.
+const (
+	ReadingListPrivate = "private"
+	ReadingListPublic  = "public"
+)
@@ -0,0 +1,16 @@
+package models
+
+// Recommendation — рекомендованная книга и причина, по которой она попала в выдачу.
+type Recommendation struct {
+	Score  float64 `db:"score"  json:"score"`
+	Reason string  `db:"reason" json:"reason"`
+	Book
+}
+
+// Причины рекомендации в порядке убывания приоритета.
+const (
+	RecommendReasonCoBorrowed  = "co_borrowed"  // эту книгу брали те же читатели
+	RecommendReasonSameAuthor  = "same_author"  // тот же автор
+	RecommendReasonSameSubject = "same_subject" // общие рубрики
+	RecommendReasonPopular     = "popular"      // часто берут в последнее время
+)
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Review — отзыв читателя о книге с оценкой от 1 до 5.
+type Review struct {
+	ID        int       `db:"id"         json:"id"`
+	BookID    int       `db:"book_id"    json:"book_id"`
+	UserID    int       `db:"user_id"    json:"user_id"`
+	Username  string    `db:"username"   json:"username"`
+	Rating    int       `db:"rating"     json:"rating"`
+	Text      string    `db:"text"       json:"text"`
+	Status    string    `db:"status"     json:"status"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Статусы модерации отзыва. Новый отзыв публикуется сразу,
+// библиотекарь может скрыть его и вернуть обратно.
+const (
+	ReviewStatusApproved = "approved"
+	ReviewStatusHidden   = "hidden"
+)
@@ -0,0 +1,26 @@
+package models
+
+// Series — книжная серия (цикл) с упорядоченным списком книг.
+type Series struct {
+	ID          int          `db:"id"          json:"id"`
+	Name        string       `db:"name"        json:"name"`
+	Description string       `db:"description" json:"description,omitempty"`
+	Books       []SeriesBook `db:"-"           json:"books,omitempty"`
+}
+
+// SeriesBook — книга в серии и её место в порядке чтения.
+// Позиция дробная, чтобы вставлять повести между томами (например, 2.5).
+type SeriesBook struct {
+	Position float64 `db:"position" json:"position"`
+	Book
+}
+
+// SeriesLink — ссылка на следующую книгу серии для карточки книги.
+type SeriesLink struct {
+	SeriesID   int     `db:"series_id"   json:"series_id"`
+	SeriesName string  `db:"series_name" json:"series_name"`
+	Position   float64 `db:"position"    json:"position"`
+	BookID     int     `db:"book_id"     json:"book_id"`
+	Title      string  `db:"title"       json:"title"`
+	Href       string  `db:"-"           json:"href"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Subject — узел иерархического рубрикатора (тема/жанр).
+// Depth и Path заполняются только при выборке дерева.
+type Subject struct {
+	ID       int    `db:"id"        json:"id"`
+	Name     string `db:"name"      json:"name"`
+	ParentID *int   `db:"parent_id" json:"parent_id"`
+	Depth    int    `db:"depth"     json:"depth"`
+	Path     string `db:"path"      json:"path,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Статусы задачи в очереди. Неудачная попытка возвращает задачу в queued с отложенным run_at,
+// после исчерпания попыток задача попадает в dead и ждёт ручного повтора.
+const (
+	TaskStatusQueued    = "queued"
+	TaskStatusRunning   = "running"
+	TaskStatusSucceeded = "succeeded"
+	TaskStatusDead      = "dead"
+)
+
+// Task — задача очереди фоновой обработки.
+type Task struct {
+	ID             int64          `db:"id"              json:"id"`
+	Kind           string         `db:"kind"            json:"kind"`
+	Payload        types.JSONText `db:"payload"         json:"payload" swaggertype:"object"`
+	Status         string         `db:"status"          json:"status"`
+	Attempts       int            `db:"attempts"        json:"attempts"`
+	MaxAttempts    int            `db:"max_attempts"    json:"max_attempts"`
+	RunAt          time.Time      `db:"run_at"          json:"run_at"`
+	LockedUntil    *time.Time     `db:"locked_until"    json:"locked_until,omitempty"`
+	LastError      string         `db:"last_error"      json:"last_error,omitempty"`
+	IdempotencyKey *string        `db:"idempotency_key" json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time      `db:"created_at"      json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"      json:"updated_at"`
+	FinishedAt     *time.Time     `db:"finished_at"     json:"finished_at,omitempty"`
+}
+
+// TaskFilter — фильтр списка задач для администратора.
+type TaskFilter struct {
+	Status string
+	Kind   string
+	Limit  int
+	Offset int
+}
+
+// TaskStats — число задач каждого вида в каждом статусе.
+type TaskStats struct {
+	Kind   string `db:"kind"   json:"kind"`
+	Status string `db:"status" json:"status"`
+	Count  int    `db:"count"  json:"count"`
+}
+
+// TaskOptions — необязательные параметры постановки задачи.
+type TaskOptions struct {
+	// IdempotencyKey защищает от дублей при повторной постановке той же задачи.
+	IdempotencyKey string
+	RunAt          time.Time
+	MaxAttempts    int
+}
+
+// EnqueueTaskRequest — ручная постановка задачи администратором.
+type EnqueueTaskRequest struct {
+	Kind           string          `json:"kind"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	IdempotencyKey string          `json:"idempotency_key"`
+	RunAt          *time.Time      `json:"run_at"`
+	MaxAttempts    int             `json:"max_attempts"`
+}
@@ -1,9 +1,13 @@
 package models
 
+import "time"
+
 type User struct {
-	ID       int    `db:"id" json:"id,omitempty"`
-	Username string `db:"username" json:"username"`
-	Email    string `db:"email" json:"email"`
-	Password string `db:"password" json:"password"`
-	Role     string `db:"role" json:"-"`
+	ID        int        `db:"id" json:"id,omitempty"`
+	Username  string     `db:"username" json:"username"`
+	Email     string     `db:"email" json:"email"`
+	Password  string     `db:"password" json:"password"`
+	Role      string     `db:"role" json:"-"`
+	Version   int        `db:"version" json:"version"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+// Виды событий вне журнала аудита. События каталога называются <сущность>.<действие>:
+// book.created, author.merged, user.deleted и т. д.
+const (
+	WebhookEventLoanCheckedOut = "loan.checked_out"
+	WebhookEventLoanReturned   = "loan.returned"
+	WebhookEventLoanExpired    = "loan.expired"
+	WebhookEventPing           = "ping"
+)
+
+// Статусы доставки вебхука.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookSubscription — подписка внешней системы на события.
+// EventTypes допускает маски "*" и "book.*". Secret показывается только при создании и смене.
+type WebhookSubscription struct {
+	ID          int            `db:"id"          json:"id"`
+	URL         string         `db:"url"         json:"url"`
+	Secret      string         `db:"secret"      json:"secret,omitempty"`
+	EventTypes  pq.StringArray `db:"event_types" json:"event_types" swaggertype:"array,string"`
+	Description string         `db:"description" json:"description"`
+	Active      bool           `db:"active"      json:"active"`
+	CreatedAt   time.Time      `db:"created_at"  json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at"  json:"updated_at"`
+}
+
+// WebhookSubscriptionInput — тело создания и изменения подписки.
+// Пустой Secret при создании генерируется; RotateSecret при изменении выдаёт новый.
+type WebhookSubscriptionInput struct {
+	URL          string   `json:"url"`
+	Secret       string   `json:"secret"`
+	EventTypes   []string `json:"event_types"`
+	Description  string   `json:"description"`
+	Active       *bool    `json:"active"`
+	RotateSecret bool     `json:"rotate_secret"`
+}
+
+// OutboxEvent — событие, записанное в той же транзакции, что и изменение.
+// StreamSeq — номер события в потоке /events/stream в порядке фиксации транзакций.
+type OutboxEvent struct {
+	ID        int64          `db:"id"`
+	StreamSeq int64          `db:"stream_seq"`
+	EventType string         `db:"event_type"`
+	Payload   types.JSONText `db:"payload"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// WebhookDelivery — попытка доставки события подписчику (журнал доставок).
+type WebhookDelivery struct {
+	ID             int64          `db:"id"              json:"id"`
+	SubscriptionID int            `db:"subscription_id" json:"subscription_id"`
+	EventID        *int64         `db:"event_id"        json:"event_id,omitempty"`
+	EventType      string         `db:"event_type"      json:"event_type"`
+	Payload        types.JSONText `db:"payload"         json:"payload" swaggertype:"object"`
+	Status         string         `db:"status"          json:"status"`
+	Attempts       int            `db:"attempts"        json:"attempts"`
+	ResponseStatus *int           `db:"response_status" json:"response_status,omitempty"`
+	Error          string         `db:"error"           json:"error,omitempty"`
+	DurationMS     *int           `db:"duration_ms"     json:"duration_ms,omitempty"`
+	CreatedAt      time.Time      `db:"created_at"      json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"      json:"updated_at"`
+}
+
+// WebhookAttempt — результат одной HTTP-попытки доставки.
+type WebhookAttempt struct {
+	Status         string
+	ResponseStatus *int
+	Error          string
+	DurationMS     int
+}
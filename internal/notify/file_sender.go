@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const defaultSinkDirectory = "notifications"
+
+// FileSender складывает письма в каталог по одному файлу .eml — для разработки и тестов.
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender создаёт отправителя, пишущего в каталог dir (создаёт его при необходимости).
+func NewFileSender(dir string) (*FileSender, error) {
+	if dir == "" {
+		dir = defaultSinkDirectory
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+func (s *FileSender) Send(_ context.Context, msg Message) error {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\nDate: %s\r\n\r\n%s\r\n",
+		msg.To, msg.Subject, now.Format(time.RFC1123Z), msg.Body)
+
+	// Пишем во временный файл и переименовываем, чтобы читатель каталога не увидел письмо наполовину
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, name))
+}
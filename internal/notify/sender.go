@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"Library/internal/models"
+	"Library/logger"
+)
+
+// Message — письмо одному получателю.
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Sender доставляет письма. Реализация выбирается в конфиге (notify_params.sender).
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var sender Sender
+
+// InitSender создаёт отправителя согласно конфигу.
+func InitSender(cfg models.NotifyParams) error {
+	switch cfg.Sender {
+	case "", "file":
+		s, err := NewFileSender(cfg.Directory)
+		if err != nil {
+			logger.Error.Printf("InitSender: cannot init file sender at %q: %v", cfg.Directory, err)
+			return err
+		}
+		sender = s
+	case "smtp":
+		s, err := NewSMTPSender(cfg)
+		if err != nil {
+			logger.Error.Printf("InitSender: cannot init smtp sender: %v", err)
+			return err
+		}
+		sender = s
+	default:
+		return fmt.Errorf("unknown notification sender %q", cfg.Sender)
+	}
+	logger.Info.Printf("InitSender: using %q notification sender", cfg.Sender)
+	return nil
+}
+
+// GetSender возвращает текущего отправителя.
+func GetSender() Sender {
+	if sender == nil {
+		logger.Warn.Println("GetSender: warning: returning nil notification sender")
+	}
+	return sender
+}
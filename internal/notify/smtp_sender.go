@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"Library/internal/models"
+)
+
+const defaultSMTPPort = 587
+
+// SMTPSender отправляет письма через SMTP-сервер (STARTTLS, если сервер его поддерживает).
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPSender создаёт отправителя по параметрам notify_params.
+func NewSMTPSender(cfg models.NotifyParams) (*SMTPSender, error) {
+	if cfg.SMTPHost == "" || cfg.From == "" {
+		return nil, errors.New("smtp_host and from are required for smtp sender")
+	}
+	port := cfg.SMTPPort
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	s := &SMTPSender{addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(port)), from: cfg.From}
+	if cfg.SMTPUsername != "" {
+		s.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return s, nil
+}
+
+func (s *SMTPSender) Send(_ context.Context, msg Message) error {
+	// Переводы строк в заголовках позволили бы подставить чужие заголовки
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("invalid characters in message headers")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(b.String()))
+}
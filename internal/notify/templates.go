@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"Library/internal/models"
+)
+
+type messageTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+func mustTemplate(title, body string) messageTemplate {
+	return messageTemplate{
+		title: template.Must(template.New("title").Parse(title)),
+		body:  template.Must(template.New("body").Parse(body)),
+	}
+}
+
+// templates — тексты уведомлений по видам и языкам. Данные шаблона формирует сервис.
+var templates = map[string]map[string]messageTemplate{
+	models.NotificationKindLoanDueSoon: {
+		models.LocaleRU: mustTemplate(
+			`Срок выдачи «{{.BookTitle}}» скоро истекает`,
+			`Здравствуйте, {{.Username}}!
+
+Электронная выдача книги «{{.BookTitle}}» закончится {{.ExpiresAt.Format "02.01.2006 в 15:04 MST"}}.
+После этого ссылки на скачивание перестанут работать, а лицензия вернётся в библиотеку.`,
+		),
+		models.LocaleEN: mustTemplate(
+			`Your loan of "{{.BookTitle}}" is due soon`,
+			`Hello, {{.Username}}!
+
+Your e-book loan of "{{.BookTitle}}" ends on {{.ExpiresAt.Format "Jan 2, 2006 at 15:04 MST"}}.
+After that the download links will stop working and the licence will return to the library.`,
+		),
+	},
+}
+
+// Kinds возвращает виды уведомлений, для которых есть шаблоны.
+func Kinds() []string {
+	kinds := make([]string, 0, len(templates))
+	for k := range templates {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Render подставляет данные в шаблон нужного языка; неизвестный язык заменяется русским.
+func Render(kind, locale string, data interface{}) (title, body string, err error) {
+	byLocale, ok := templates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("no templates for notification kind %q", kind)
+	}
+	t, ok := byLocale[locale]
+	if !ok {
+		t = byLocale[models.LocaleRU]
+	}
+
+	var tb, bb strings.Builder
+	if err := t.title.Execute(&tb, data); err != nil {
+		return "", "", err
+	}
+	if err := t.body.Execute(&bb, data); err != nil {
+		return "", "", err
+	}
+	return tb.String(), bb.String(), nil
+}
@@ -0,0 +1,192 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Handler обрабатывает задачу одного вида. Доставка «хотя бы один раз»: обработчик
+// должен быть идемпотентным, потому что после сбоя задача может выполниться повторно.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Options — параметры пула обработчиков.
+type Options struct {
+	Workers      int
+	PollInterval time.Duration
+	LockTimeout  time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// permanentError — ошибка, повтор которой бессмыслен (например, битый payload).
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как неисправимую: задача сразу уходит в dead без повторов.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+var (
+	mu       sync.RWMutex
+	handlers = map[string]Handler{}
+
+	// wake будит простаивающий обработчик, когда задачу поставили в очередь на этом экземпляре.
+	wake = make(chan struct{}, 1)
+
+	wg      sync.WaitGroup
+	running atomic.Bool
+)
+
+// Register связывает вид задачи с обработчиком. Вызывать до Start.
+func Register(kind string, h Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	handlers[kind] = h
+}
+
+// Registered сообщает, есть ли обработчик для вида задачи.
+func Registered(kind string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := handlers[kind]
+	return ok
+}
+
+// Notify сообщает пулу, что в очереди появилась задача, не дожидаясь очередного опроса.
+func Notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start запускает opts.Workers обработчиков; они останавливаются при отмене ctx.
+func Start(ctx context.Context, opts Options) {
+	logger.Info.Printf("queue.Start: %d workers, poll every %s", opts.Workers, opts.PollInterval)
+	running.Store(true)
+	go func() {
+		<-ctx.Done()
+		running.Store(false)
+	}()
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(ctx, opts)
+		}()
+	}
+}
+
+// Running сообщает, работают ли обработчики очереди.
+func Running() bool {
+	return running.Load()
+}
+
+// Wait ждёт, пока после отмены контекста Start обработчики доделают текущие задачи.
+func Wait() {
+	wg.Wait()
+}
+
+func worker(ctx context.Context, opts Options) {
+	for ctx.Err() == nil {
+		tasks, err := repository.ClaimTasks(ctx, 1, opts.LockTimeout)
+		if err == nil && len(tasks) > 0 {
+			process(ctx, opts, tasks[0])
+			continue
+		}
+
+		timer := time.NewTimer(opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func process(ctx context.Context, opts Options, t models.Task) {
+	// Остановка сервиса начатую задачу не прерывает (её дожидается Wait), и итог задачи
+	// должен записаться даже после отмены ctx, иначе она провисит в running до конца блокировки
+	ctx, span := tracing.Start(context.WithoutCancel(ctx), "task "+t.Kind,
+		attribute.Int64("task.id", t.ID), attribute.Int("task.attempt", t.Attempts))
+	defer span.End()
+
+	mu.RLock()
+	h, ok := handlers[t.Kind]
+	mu.RUnlock()
+	if !ok {
+		logger.Error.Printf("queue: task ID=%d has unknown kind %q", t.ID, t.Kind)
+		repository.KillTask(ctx, t.ID, t.Attempts, fmt.Sprintf("no handler for kind %q", t.Kind))
+		return
+	}
+	// Попытки могли кончиться, пока задача висела на упавшем обработчике
+	if t.Attempts > t.MaxAttempts {
+		repository.KillTask(ctx, t.ID, t.Attempts, "lock expired on last attempt: "+t.LastError)
+		return
+	}
+
+	// Задача не должна пережить свою блокировку, иначе её параллельно заберёт другой обработчик
+	runCtx, cancel := context.WithTimeout(ctx, opts.LockTimeout)
+	defer cancel()
+
+	begin := time.Now()
+	err := safeRun(runCtx, h, json.RawMessage(t.Payload))
+	if err == nil {
+		logger.Info.Printf("queue: task ID=%d kind=%s done in %s (attempt %d)", t.ID, t.Kind, time.Since(begin), t.Attempts)
+		repository.CompleteTask(ctx, t.ID, t.Attempts)
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	var perm permanentError
+	if errors.As(err, &perm) || t.Attempts >= t.MaxAttempts {
+		logger.Error.Printf("queue: task ID=%d kind=%s dead after %d attempts: %v", t.ID, t.Kind, t.Attempts, err)
+		repository.KillTask(ctx, t.ID, t.Attempts, err.Error())
+		return
+	}
+	delay := backoff(opts, t.Attempts)
+	logger.Warn.Printf("queue: task ID=%d kind=%s attempt %d failed, retry in %s: %v", t.ID, t.Kind, t.Attempts, delay, err)
+	repository.RetryTaskLater(ctx, t.ID, t.Attempts, err.Error(), time.Now().Add(delay))
+}
+
+// backoff — экспоненциальная пауза с разбросом до 20%, чтобы повторы не приходили пачкой.
+func backoff(opts Options, attempt int) time.Duration {
+	d := opts.BaseBackoff
+	for i := 1; i < attempt && d < opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > opts.MaxBackoff {
+		d = opts.MaxBackoff
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// safeRun не даёт панике в обработчике уронить процесс.
+func safeRun(ctx context.Context, h Handler, payload json.RawMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, payload)
+}
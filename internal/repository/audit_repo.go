@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"Library/internal/db"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// auditSkipColumns — колонки, которые никогда не попадают в журнал.
+// Рейтинг книги вычисляется из отзывов и изменением книги не считается.
+var auditSkipColumns = map[string]bool{
+	"password":     true,
+	"rating_avg":   true,
+	"rating_count": true,
+}
+
+// auditSnapshot превращает модель в map по db-тегам (а не json-тегам),
+// чтобы в журнал попадали и скрытые из API поля вроде role.
+func auditSnapshot(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	out := make(map[string]interface{}, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		col := rt.Field(i).Tag.Get("db")
+		if col == "" || col == "-" || auditSkipColumns[col] {
+			continue
+		}
+		out[col] = rv.Field(i).Interface()
+	}
+	return out
+}
+
+// auditDiff возвращает JSON до/после; для обновлений — только изменившиеся поля.
+func auditDiff(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	b, a := auditSnapshot(before), auditSnapshot(after)
+	if b != nil && a != nil {
+		for k, bv := range b {
+			bj, _ := json.Marshal(bv)
+			aj, _ := json.Marshal(a[k])
+			if string(bj) == string(aj) {
+				delete(b, k)
+				delete(a, k)
+			}
+		}
+	}
+
+	var beforeJSON, afterJSON json.RawMessage
+	var err error
+	if b != nil {
+		if beforeJSON, err = json.Marshal(b); err != nil {
+			return nil, nil, err
+		}
+	}
+	if a != nil {
+		if afterJSON, err = json.Marshal(a); err != nil {
+			return nil, nil, err
+		}
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+// insertAuditEvent пишет событие аудита в ту же транзакцию, что и само изменение,
+// и там же кладёт в outbox событие для вебхуков.
+func insertAuditEvent(ctx context.Context, tx *sqlx.Tx, actor models.AuditActor, action, entityType string, entityID int, before, after interface{}) error {
+	beforeJSON, afterJSON, err := auditDiff(before, after)
+	if err != nil {
+		logger.Error.Printf("repo.insertAuditEvent: diff error %s/%d: %v", entityType, entityID, err)
+		return err
+	}
+
+	var actorID *int
+	if actor.UserID != 0 {
+		actorID = &actor.UserID
+	}
+
+	_, err = tx.ExecContext(ctx, `
+      INSERT INTO audit_events (actor_id, action, entity_type, entity_id, before, after, request_id, ip)
+      VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `, actorID, action, entityType, entityID, nullJSON(beforeJSON), nullJSON(afterJSON), actor.RequestID, actor.IP)
+	if err != nil {
+		logger.Error.Printf("repo.insertAuditEvent: insert error %s/%d: %v", entityType, entityID, err)
+		return translateError(err)
+	}
+	logger.Debug.Printf("repo.insertAuditEvent: %s %s/%d by actor=%d", action, entityType, entityID, actor.UserID)
+	return insertEntityOutboxEvent(ctx, tx, actor, action, entityType, entityID, before, after)
+}
+
+func nullJSON(j json.RawMessage) interface{} {
+	if j == nil {
+		return nil
+	}
+	return []byte(j)
+}
+
+// GetAuditEvents возвращает события аудита по фильтру (новые сверху).
+func GetAuditEvents(ctx context.Context, f models.AuditFilter) ([]models.AuditEvent, error) {
+	defer metrics.ObserveQuery("GetAuditEvents")()
+	logger.Debug.Printf("repo.GetAuditEvents: start filter=%+v", f)
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ActorID != nil {
+		add("actor_id = $%d", *f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.EntityType != "" {
+		add("entity_type = $%d", f.EntityType)
+	}
+	if f.EntityID != nil {
+		add("entity_id = $%d", *f.EntityID)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+
+	query := `SELECT id, actor_id, action, entity_type, entity_id, before, after, request_id, ip, created_at
+                FROM audit_events`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit, f.Offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	events := []models.AuditEvent{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &events, query, args...); err != nil {
+		logger.Error.Printf("repo.GetAuditEvents: query error: %v", err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetAuditEvents: returned %d events", len(events))
+	return events, nil
+}
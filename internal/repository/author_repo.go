@@ -1,16 +1,33 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+
 	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
 	"Library/internal/models"
 	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-// GetAllAuthors возвращает всех авторов.
-func GetAllAuthors() ([]models.Author, error) {
-	logger.Debug.Println("repo.GetAllAuthors: executing SELECT id, name FROM authors")
+// authorColumns — колонки автора; даты отдаются строкой YYYY-MM-DD.
+const authorColumns = `id, name,
+        to_char(birth_date, 'YYYY-MM-DD') AS birth_date,
+        to_char(death_date, 'YYYY-MM-DD') AS death_date,
+        biography, nationality, version, deleted_at`
+
+// GetAllAuthors возвращает всех авторов; удалённых — только при includeDeleted.
+func GetAllAuthors(ctx context.Context, includeDeleted bool) ([]models.Author, error) {
+	defer metrics.ObserveQuery("GetAllAuthors")()
+	logger.Debug.Printf("repo.GetAllAuthors: executing SELECT FROM authors include_deleted=%t", includeDeleted)
 	var authors []models.Author
-	err := db.GetDBConn().Select(&authors, `SELECT id, name FROM authors`)
+	err := db.GetReadConn(ctx).SelectContext(ctx, &authors,
+		`SELECT `+authorColumns+` FROM authors WHERE ($1 OR deleted_at IS NULL) ORDER BY id`, includeDeleted,
+	)
 	if err != nil {
 		logger.Error.Printf("repo.GetAllAuthors: query error: %v", err)
 		return nil, err
@@ -19,65 +36,129 @@ func GetAllAuthors() ([]models.Author, error) {
 	return authors, nil
 }
 
-// GetAuthorByID возвращает автора по ID.
-func GetAuthorByID(authorID int) (models.Author, error) {
-	logger.Debug.Printf("repo.GetAuthorByID: executing SELECT id, name FROM authors WHERE id=%d", authorID)
+// GetAuthorByID возвращает автора по ID; удалённого — только при includeDeleted.
+func GetAuthorByID(ctx context.Context, authorID int, includeDeleted bool) (models.Author, error) {
+	defer metrics.ObserveQuery("GetAuthorByID")()
+	logger.Debug.Printf("repo.GetAuthorByID: executing SELECT FROM authors WHERE id=%d include_deleted=%t", authorID, includeDeleted)
 	var author models.Author
-	err := db.GetDBConn().Get(&author, `SELECT id, name FROM authors WHERE id = $1`, authorID)
+	err := db.GetReadConn(ctx).GetContext(ctx, &author,
+		`SELECT `+authorColumns+` FROM authors WHERE id = $1 AND ($2 OR deleted_at IS NULL)`, authorID, includeDeleted,
+	)
 	if err != nil {
 		logger.Error.Printf("repo.GetAuthorByID: query error id=%d: %v", authorID, err)
-		return models.Author{}, err
+		return models.Author{}, translateError(err)
 	}
 	logger.Info.Printf("repo.GetAuthorByID: found author ID=%d name=%q", author.ID, author.Name)
 	return author, nil
 }
 
-// CreateAuthor добавляет нового автора.
-func CreateAuthor(author *models.Author) error {
-	logger.Debug.Printf("repo.CreateAuthor: executing INSERT INTO authors (name) VALUES (%q)", author.Name)
-	_, err := db.GetDBConn().Exec(`INSERT INTO authors (name) VALUES ($1)`, author.Name)
+// CreateAuthor добавляет нового автора и пишет событие аудита в той же транзакции.
+func CreateAuthor(ctx context.Context, author *models.Author, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("CreateAuthor")()
+	logger.Debug.Printf("repo.CreateAuthor: executing INSERT INTO authors name=%q", author.Name)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+          INSERT INTO authors (name, birth_date, death_date, biography, nationality)
+          VALUES ($1, $2, $3, $4, $5)
+          RETURNING id, version`,
+			author.Name, author.BirthDate, author.DeathDate, author.Biography, author.Nationality,
+		).Scan(&author.ID, &author.Version); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionCreate, models.AuditEntityAuthor, author.ID, nil, author)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.CreateAuthor: insert error name=%q: %v", author.Name, err)
-		return err
+		return translateError(err)
 	}
-	logger.Info.Printf("repo.CreateAuthor: created author name=%q", author.Name)
+	logger.Info.Printf("repo.CreateAuthor: created author ID=%d name=%q", author.ID, author.Name)
 	return nil
 }
 
-// UpdateAuthor обновляет имя автора.
-func UpdateAuthor(author *models.Author) error {
-	logger.Debug.Printf("repo.UpdateAuthor: executing UPDATE authors SET name=%q WHERE id=%d", author.Name, author.ID)
-	_, err := db.GetDBConn().Exec(`UPDATE authors SET name = $1 WHERE id = $2`, author.Name, author.ID)
+// UpdateAuthor обновляет профиль автора и пишет событие аудита в той же транзакции.
+// author.Version — ожидаемая версия строки (0 — без проверки).
+func UpdateAuthor(ctx context.Context, author *models.Author, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("UpdateAuthor")()
+	logger.Debug.Printf("repo.UpdateAuthor: executing UPDATE authors name=%q WHERE id=%d", author.Name, author.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Author
+		if err := tx.GetContext(ctx, &before, `SELECT `+authorColumns+` FROM authors WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, author.ID); err != nil {
+			return err
+		}
+		if author.Version != 0 && author.Version != before.Version {
+			return errs.ErrVersionMismatch
+		}
+		res, err := tx.ExecContext(ctx, `
+          UPDATE authors
+             SET name = $1, birth_date = $2, death_date = $3, biography = $4, nationality = $5,
+                 version = version + 1
+           WHERE id = $6 AND version = $7 AND deleted_at IS NULL`,
+			author.Name, author.BirthDate, author.DeathDate, author.Biography, author.Nationality,
+			author.ID, before.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrNotFound
+		}
+		author.Version = before.Version + 1
+		return recordChange(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityAuthor, author.ID, before, author)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.UpdateAuthor: update error id=%d: %v", author.ID, err)
-		return err
+		return translateError(err)
 	}
 	logger.Info.Printf("repo.UpdateAuthor: updated author ID=%d name=%q", author.ID, author.Name)
 	return nil
 }
 
-// DeleteAuthorByID удаляет автора по ID.
-func DeleteAuthorByID(authorID int) error {
-	logger.Debug.Printf("repo.DeleteAuthorByID: executing DELETE FROM authors WHERE id=%d", authorID)
-	_, err := db.GetDBConn().Exec(`DELETE FROM authors WHERE id = $1`, authorID)
+// DeleteAuthorByID помечает автора удалённым (soft delete) и пишет событие аудита в той же транзакции.
+// Автора, у которого остались неудалённые книги, удалить нельзя.
+func DeleteAuthorByID(ctx context.Context, authorID int, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("DeleteAuthorByID")()
+	logger.Debug.Printf("repo.DeleteAuthorByID: executing UPDATE authors SET deleted_at=now() WHERE id=%d", authorID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Author
+		if err := tx.GetContext(ctx, &before, `SELECT `+authorColumns+` FROM authors WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, authorID); err != nil {
+			return err
+		}
+		var books int
+		if err := tx.GetContext(ctx, &books, `SELECT count(*) FROM books WHERE author_id = $1 AND deleted_at IS NULL`, authorID); err != nil {
+			return err
+		}
+		if books > 0 {
+			return errs.ErrAuthorHasBooks
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE authors SET deleted_at = now(), version = version + 1 WHERE id = $1`, authorID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionDelete, models.AuditEntityAuthor, authorID, before, nil)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.DeleteAuthorByID: delete error id=%d: %v", authorID, err)
-		return err
+		return translateError(err)
 	}
 	logger.Info.Printf("repo.DeleteAuthorByID: deleted author ID=%d", authorID)
 	return nil
 }
 
-func SearchAuthorsByName(fragment string) ([]models.Author, error) {
+// SearchAuthorsByName ищет неудалённых авторов по фрагменту имени или любого из альтернативных имён.
+func SearchAuthorsByName(ctx context.Context, fragment string) ([]models.Author, error) {
+	defer metrics.ObserveQuery("SearchAuthorsByName")()
 	logger.Debug.Printf("repo.SearchAuthorsByName: executing SELECT for fragment=%q", fragment)
 
 	query := `
-        SELECT id, name
-          FROM authors
-         WHERE name ILIKE '%' || $1 || '%'
+        SELECT ` + authorColumns + `
+          FROM authors a
+         WHERE (a.name ILIKE '%' || $1 || '%'
+                OR EXISTS (SELECT 1 FROM author_aliases al
+                            WHERE al.author_id = a.id AND al.name ILIKE '%' || $1 || '%'))
+           AND a.deleted_at IS NULL
+         ORDER BY a.id
     `
 	var authors []models.Author
-	err := db.GetDBConn().Select(&authors, query, fragment)
+	err := db.GetReadConn(ctx).SelectContext(ctx, &authors, query, fragment)
 	if err != nil {
 		logger.Error.Printf("repo.SearchAuthorsByName: query error fragment=%q: %v", fragment, err)
 		return nil, translateError(err)
@@ -86,3 +167,240 @@ func SearchAuthorsByName(fragment string) ([]models.Author, error) {
 	logger.Info.Printf("repo.SearchAuthorsByName: found %d authors matching %q", len(authors), fragment)
 	return authors, nil
 }
+
+// RestoreAuthorByID снимает пометку об удалении и пишет событие аудита в той же транзакции.
+func RestoreAuthorByID(ctx context.Context, authorID int, actor models.AuditActor) (models.Author, error) {
+	defer metrics.ObserveQuery("RestoreAuthorByID")()
+	logger.Debug.Printf("repo.RestoreAuthorByID: executing UPDATE authors SET deleted_at=NULL WHERE id=%d", authorID)
+	var author models.Author
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Author
+		if err := tx.GetContext(ctx, &before,
+			`SELECT `+authorColumns+` FROM authors WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE`, authorID,
+		); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &author,
+			`UPDATE authors SET deleted_at = NULL, version = version + 1 WHERE id = $1 RETURNING `+authorColumns, authorID,
+		); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionRestore, models.AuditEntityAuthor, authorID, before, author)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.RestoreAuthorByID: restore error id=%d: %v", authorID, err)
+		return models.Author{}, translateError(err)
+	}
+	logger.Info.Printf("repo.RestoreAuthorByID: restored author ID=%d", authorID)
+	return author, nil
+}
+
+// PatchAuthor обновляет только переданные колонки автора (merge patch) и пишет событие аудита.
+// version — ожидаемая версия строки (0 — без проверки).
+func PatchAuthor(ctx context.Context, authorID, version int, fields map[string]interface{}, actor models.AuditActor) (models.Author, error) {
+	defer metrics.ObserveQuery("PatchAuthor")()
+	logger.Debug.Printf("repo.PatchAuthor: patching author id=%d fields=%d", authorID, len(fields))
+	var author models.Author
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Author
+		if err := tx.GetContext(ctx, &before, `SELECT `+authorColumns+` FROM authors WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, authorID); err != nil {
+			return err
+		}
+		if version != 0 && version != before.Version {
+			return errs.ErrVersionMismatch
+		}
+		query, args, err := buildPatchUpdate("authors", authorPatchColumns, fields, authorID, before.Version)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrNotFound
+		}
+		if err := tx.GetContext(ctx, &author, `SELECT `+authorColumns+` FROM authors WHERE id = $1`, authorID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityAuthor, authorID, before, author)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.PatchAuthor: patch error id=%d: %v", authorID, err)
+		return models.Author{}, translateError(err)
+	}
+	logger.Info.Printf("repo.PatchAuthor: patched author ID=%d version=%d", authorID, author.Version)
+	return author, nil
+}
+
+// authorAliasesSnapshot — набор альтернативных имён автора для журнала аудита.
+type authorAliasesSnapshot struct {
+	Aliases []models.AuthorAlias `db:"aliases"`
+}
+
+// GetAuthorAliases возвращает альтернативные имена указанных авторов.
+func GetAuthorAliases(ctx context.Context, authorIDs []int64) ([]models.AuthorAlias, error) {
+	defer metrics.ObserveQuery("GetAuthorAliases")()
+	logger.Debug.Printf("repo.GetAuthorAliases: executing SELECT for author_ids=%v", authorIDs)
+	aliases := []models.AuthorAlias{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &aliases, `
+      SELECT author_id, name, kind FROM author_aliases
+       WHERE author_id = ANY($1)
+       ORDER BY author_id, kind, name`, pq.Int64Array(authorIDs),
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetAuthorAliases: query error: %v", err)
+		return nil, translateError(err)
+	}
+	return aliases, nil
+}
+
+// SetAuthorAliases заменяет набор альтернативных имён автора и пишет событие аудита в той же транзакции.
+func SetAuthorAliases(ctx context.Context, authorID int, aliases []models.AuthorAlias, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("SetAuthorAliases")()
+	logger.Debug.Printf("repo.SetAuthorAliases: replacing %d aliases of author_id=%d", len(aliases), authorID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var locked int
+		if err := tx.GetContext(ctx, &locked, `SELECT id FROM authors WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, authorID); err != nil {
+			return err
+		}
+		var before authorAliasesSnapshot
+		if err := tx.SelectContext(ctx, &before.Aliases,
+			`SELECT author_id, name, kind FROM author_aliases WHERE author_id = $1 ORDER BY kind, name`, authorID,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM author_aliases WHERE author_id = $1`, authorID); err != nil {
+			return err
+		}
+		for _, al := range aliases {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO author_aliases (author_id, name, kind) VALUES ($1, $2, $3)`, authorID, al.Name, al.Kind,
+			); err != nil {
+				return err
+			}
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityAuthor, authorID,
+			before, authorAliasesSnapshot{Aliases: aliases})
+	})
+	if err != nil {
+		logger.Error.Printf("repo.SetAuthorAliases: error author_id=%d: %v", authorID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.SetAuthorAliases: author_id=%d now has %d aliases", authorID, len(aliases))
+	return nil
+}
+
+// MergeAuthors сливает дубли sourceIDs в автора targetID одной транзакцией:
+// книги дублей переходят к targetID, их имена и псевдонимы становятся его альтернативными
+// именами, пустые поля профиля заполняются из дублей, а сами дубли помечаются удалёнными.
+func MergeAuthors(ctx context.Context, targetID int, sourceIDs []int64, actor models.AuditActor) (models.Author, error) {
+	defer metrics.ObserveQuery("MergeAuthors")()
+	logger.Debug.Printf("repo.MergeAuthors: merging %v into author_id=%d", sourceIDs, targetID)
+	var target models.Author
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Author
+		if err := tx.GetContext(ctx, &before,
+			`SELECT `+authorColumns+` FROM authors WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, targetID,
+		); err != nil {
+			return err
+		}
+		var sources []models.Author
+		if err := tx.SelectContext(ctx, &sources,
+			`SELECT `+authorColumns+` FROM authors WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE`,
+			pq.Int64Array(sourceIDs),
+		); err != nil {
+			return err
+		}
+		if len(sources) != len(sourceIDs) {
+			return fmt.Errorf("%w: unknown or deleted source author id", errs.ErrValidationFailed)
+		}
+		src := pq.Int64Array(sourceIDs)
+
+		// Книги, включая удалённые: иначе дубль нельзя будет окончательно удалить.
+		var books []models.Book
+		if err := tx.SelectContext(ctx, &books, bookSelectSQL+" WHERE b.author_id = ANY($1) ORDER BY b.id FOR UPDATE OF b", src); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE books SET author_id = $1, version = version + 1 WHERE author_id = ANY($2)`, targetID, src,
+		); err != nil {
+			return err
+		}
+		for i := range books {
+			var after models.Book
+			if err := tx.GetContext(ctx, &after, bookByIDSQL, books[i].ID); err != nil {
+				return err
+			}
+			if err := recordChange(ctx, tx, actor, models.AuditActionMerge, models.AuditEntityBook, after.ID, books[i], after); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+          INSERT INTO book_authors (book_id, author_id)
+          SELECT book_id, $1 FROM book_authors WHERE author_id = ANY($2)
+          ON CONFLICT DO NOTHING`, targetID, src,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM book_authors WHERE author_id = ANY($1)`, src); err != nil {
+			return err
+		}
+
+		// Имена и псевдонимы дублей остаются доступны для поиска.
+		if _, err := tx.ExecContext(ctx, `
+          INSERT INTO author_aliases (author_id, name, kind)
+          SELECT $1, name, kind FROM author_aliases WHERE author_id = ANY($2)
+          UNION ALL
+          SELECT $1, name, 'alternate' FROM authors WHERE id = ANY($2)
+          ON CONFLICT DO NOTHING`, targetID, src,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM author_aliases WHERE author_id = $1 AND lower(name) = lower($2)`, targetID, before.Name); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM author_aliases WHERE author_id = ANY($1)`, src); err != nil {
+			return err
+		}
+
+		if err := tx.GetContext(ctx, &target, `
+          UPDATE authors t
+             SET birth_date  = COALESCE(t.birth_date, s.birth_date),
+                 death_date  = COALESCE(t.death_date, s.death_date),
+                 biography   = CASE WHEN t.biography = '' THEN COALESCE(s.biography, '') ELSE t.biography END,
+                 nationality = CASE WHEN t.nationality = '' THEN COALESCE(s.nationality, '') ELSE t.nationality END,
+                 version     = t.version + 1
+            FROM (SELECT (array_agg(birth_date ORDER BY id) FILTER (WHERE birth_date IS NOT NULL))[1] AS birth_date,
+                         (array_agg(death_date ORDER BY id) FILTER (WHERE death_date IS NOT NULL))[1] AS death_date,
+                         (array_agg(biography ORDER BY id) FILTER (WHERE biography <> ''))[1] AS biography,
+                         (array_agg(nationality ORDER BY id) FILTER (WHERE nationality <> ''))[1] AS nationality
+                    FROM authors WHERE id = ANY($2)) s
+           WHERE t.id = $1
+       RETURNING t.id, t.name,
+                 to_char(t.birth_date, 'YYYY-MM-DD') AS birth_date,
+                 to_char(t.death_date, 'YYYY-MM-DD') AS death_date,
+                 t.biography, t.nationality, t.version, t.deleted_at`, targetID, src,
+		); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE authors SET deleted_at = now(), version = version + 1 WHERE id = ANY($1)`, src,
+		); err != nil {
+			return err
+		}
+		for _, s := range sources {
+			if err := recordChange(ctx, tx, actor, models.AuditActionMerge, models.AuditEntityAuthor, s.ID, s, nil); err != nil {
+				return err
+			}
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionMerge, models.AuditEntityAuthor, targetID, before, target)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.MergeAuthors: merge error target_id=%d: %v", targetID, err)
+		return models.Author{}, translateError(err)
+	}
+	logger.Info.Printf("repo.MergeAuthors: merged %d authors into author ID=%d", len(sourceIDs), targetID)
+	return target, nil
+}
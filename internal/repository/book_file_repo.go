@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const bookFileColumns = `id, book_id, kind, file_name, content_type, size, sha256, thumbnail_hash, created_at`
+
+const insertBookFileSQL = `
+      INSERT INTO book_files (book_id, kind, file_name, content_type, size, sha256, thumbnail_hash)
+      VALUES ($1, $2, $3, $4, $5, $6, $7)
+      RETURNING id, created_at
+    `
+
+// lockBlobs берёт транзакционные advisory-блокировки объектов BlobStore по хешам.
+// Их же берёт ReleaseBlob, поэтому объект не удалится между проверкой «уже сохранён»
+// и вставкой ссылки на него. Порядок хешей фиксирован, чтобы не было взаимоблокировок.
+func lockBlobs(ctx context.Context, tx *sqlx.Tx, hashes ...string) error {
+	_, err := tx.ExecContext(ctx, `
+      SELECT pg_advisory_xact_lock(hashtextextended('blob:' || h, 0))
+        FROM (SELECT DISTINCT unnest($1::text[]) AS h ORDER BY 1) s`, pq.StringArray(hashes),
+	)
+	return err
+}
+
+func blobHashes(f *models.BookFile) []string {
+	hashes := []string{f.SHA256}
+	if f.ThumbnailHash != nil {
+		hashes = append(hashes, *f.ThumbnailHash)
+	}
+	return hashes
+}
+
+// CreateBookFile сохраняет метаданные файла книги. store кладёт содержимое в BlobStore
+// и вызывается под блокировкой хеша в той же транзакции, что и вставка.
+func CreateBookFile(ctx context.Context, f *models.BookFile, store func() error) error {
+	defer metrics.ObserveQuery("CreateBookFile")()
+	logger.Debug.Printf("repo.CreateBookFile: executing INSERT INTO book_files book_id=%d kind=%q sha256=%s",
+		f.BookID, f.Kind, f.SHA256)
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := lockBlobs(ctx, tx, blobHashes(f)...); err != nil {
+			return err
+		}
+		if err := store(); err != nil {
+			return err
+		}
+		return tx.QueryRowContext(ctx,
+			insertBookFileSQL, f.BookID, f.Kind, f.FileName, f.ContentType, f.Size, f.SHA256, f.ThumbnailHash,
+		).Scan(&f.ID, &f.CreatedAt)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.CreateBookFile: insert error book_id=%d: %v", f.BookID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreateBookFile: created file ID=%d book_id=%d", f.ID, f.BookID)
+	return nil
+}
+
+// ReplaceBookCover одной транзакцией заменяет обложку книги: строка книги блокируется,
+// чтобы параллельные загрузки не оставили две обложки, store кладёт содержимое в BlobStore
+// под блокировкой хешей. Возвращает прежнюю обложку (nil, если её не было); её объекты
+// вызывающий освобождает через ReleaseBlob уже после фиксации.
+func ReplaceBookCover(ctx context.Context, f *models.BookFile, store func() error) (*models.BookFile, error) {
+	defer metrics.ObserveQuery("ReplaceBookCover")()
+	logger.Debug.Printf("repo.ReplaceBookCover: book_id=%d sha256=%s", f.BookID, f.SHA256)
+
+	var old *models.BookFile
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var id int
+		if err := tx.GetContext(ctx, &id,
+			`SELECT id FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, f.BookID,
+		); err != nil {
+			return err
+		}
+		if err := lockBlobs(ctx, tx, blobHashes(f)...); err != nil {
+			return err
+		}
+		if err := store(); err != nil {
+			return err
+		}
+
+		var prev models.BookFile
+		err := tx.GetContext(ctx, &prev, `
+          DELETE FROM book_files WHERE book_id = $1 AND kind = $2
+          RETURNING `+bookFileColumns, f.BookID, models.BookFileKindCover,
+		)
+		switch translateError(err) {
+		case nil:
+			old = &prev
+		case errs.ErrNotFound:
+		default:
+			return err
+		}
+
+		return tx.QueryRowContext(ctx,
+			insertBookFileSQL, f.BookID, f.Kind, f.FileName, f.ContentType, f.Size, f.SHA256, f.ThumbnailHash,
+		).Scan(&f.ID, &f.CreatedAt)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.ReplaceBookCover: error book_id=%d: %v", f.BookID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.ReplaceBookCover: created cover ID=%d book_id=%d", f.ID, f.BookID)
+	return old, nil
+}
+
+// GetBookFilesByBookID возвращает файлы книги (без обложки).
+func GetBookFilesByBookID(ctx context.Context, bookID int) ([]models.BookFile, error) {
+	defer metrics.ObserveQuery("GetBookFilesByBookID")()
+	logger.Debug.Printf("repo.GetBookFilesByBookID: executing SELECT for book_id=%d", bookID)
+	files := []models.BookFile{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &files,
+		`SELECT `+bookFileColumns+` FROM book_files WHERE book_id = $1 AND kind = $2 ORDER BY id`,
+		bookID, models.BookFileKindFile,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBookFilesByBookID: query error book_id=%d: %v", bookID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetBookFilesByBookID: returned %d files for book_id=%d", len(files), bookID)
+	return files, nil
+}
+
+// GetBookFilesByBookIDs возвращает файлы (без обложек) сразу нескольких книг, упорядоченные по book_id и id.
+func GetBookFilesByBookIDs(ctx context.Context, bookIDs []int64) ([]models.BookFile, error) {
+	defer metrics.ObserveQuery("GetBookFilesByBookIDs")()
+	logger.Debug.Printf("repo.GetBookFilesByBookIDs: executing SELECT for %d books", len(bookIDs))
+	files := []models.BookFile{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &files,
+		`SELECT `+bookFileColumns+` FROM book_files WHERE book_id = ANY($1) AND kind = $2 ORDER BY book_id, id`,
+		pq.Int64Array(bookIDs), models.BookFileKindFile,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBookFilesByBookIDs: query error: %v", err)
+		return nil, translateError(err)
+	}
+	return files, nil
+}
+
+// GetBookFileByID возвращает файл книги по ID.
+func GetBookFileByID(ctx context.Context, bookID, fileID int) (models.BookFile, error) {
+	defer metrics.ObserveQuery("GetBookFileByID")()
+	logger.Debug.Printf("repo.GetBookFileByID: executing SELECT for book_id=%d id=%d", bookID, fileID)
+	var f models.BookFile
+	err := db.GetReadConn(ctx).GetContext(ctx, &f,
+		`SELECT `+bookFileColumns+` FROM book_files WHERE book_id = $1 AND id = $2 AND kind = $3`,
+		bookID, fileID, models.BookFileKindFile,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBookFileByID: query error book_id=%d id=%d: %v", bookID, fileID, err)
+		return models.BookFile{}, translateError(err)
+	}
+	return f, nil
+}
+
+// GetBookCover возвращает обложку книги. У удалённой книги обложки как будто нет: ErrNotFound.
+func GetBookCover(ctx context.Context, bookID int) (models.BookFile, error) {
+	defer metrics.ObserveQuery("GetBookCover")()
+	logger.Debug.Printf("repo.GetBookCover: executing SELECT for book_id=%d", bookID)
+	var f models.BookFile
+	err := db.GetReadConn(ctx).GetContext(ctx, &f, `
+      SELECT `+bookFileColumns+`
+        FROM book_files
+       WHERE book_id = $1 AND kind = $2
+         AND EXISTS (SELECT 1 FROM books b WHERE b.id = book_files.book_id AND b.deleted_at IS NULL)
+    `, bookID, models.BookFileKindCover,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBookCover: query error book_id=%d: %v", bookID, err)
+		return models.BookFile{}, translateError(err)
+	}
+	return f, nil
+}
+
+// DeleteBookFileByID удаляет запись о файле книги.
+func DeleteBookFileByID(ctx context.Context, fileID int) error {
+	defer metrics.ObserveQuery("DeleteBookFileByID")()
+	logger.Debug.Printf("repo.DeleteBookFileByID: executing DELETE FROM book_files WHERE id=%d", fileID)
+	_, err := db.GetDBConn().ExecContext(ctx, `DELETE FROM book_files WHERE id = $1`, fileID)
+	if err != nil {
+		logger.Error.Printf("repo.DeleteBookFileByID: delete error id=%d: %v", fileID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.DeleteBookFileByID: deleted file ID=%d", fileID)
+	return nil
+}
+
+// ReleaseBlob вызывает remove, если на объект с данным хешем больше не ссылается ни одна
+// запись (ни как на содержимое, ни как на миниатюру). Подсчёт и удаление идут под той же
+// блокировкой хеша, что и загрузки, поэтому одновременная загрузка того же содержимого
+// либо дождётся удаления и сохранит объект заново, либо её ссылка будет учтена.
+// Возвращает true, если объект удалён.
+func ReleaseBlob(ctx context.Context, hash string, remove func() error) (bool, error) {
+	defer metrics.ObserveQuery("ReleaseBlob")()
+	removed := false
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := lockBlobs(ctx, tx, hash); err != nil {
+			return err
+		}
+		var n int
+		if err := tx.GetContext(ctx, &n,
+			`SELECT count(*) FROM book_files WHERE sha256 = $1 OR thumbnail_hash = $1`, hash,
+		); err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+		removed = true
+		return remove()
+	})
+	if err != nil {
+		logger.Error.Printf("repo.ReleaseBlob: error hash=%s: %v", hash, err)
+		return false, translateError(err)
+	}
+	return removed, nil
+}
@@ -1,30 +1,87 @@
 package repository
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
 	"Library/internal/models"
 	"Library/logger"
-)
 
-// GetAllBooks возвращает список всех книг.
-func GetAllBooks() ([]models.Book, error) {
-	logger.Debug.Println("repo.GetAllBooks: executing SELECT id, name, title, author FROM books")
+	"github.com/jmoiron/sqlx"
+)
 
-	const sql = `
+// bookSelectSQL — общая часть выборки книги вместе с именем автора.
+const bookSelectSQL = `
       SELECT 
         b.id,
         b.name,
         b.title,
         b.author_id,
-        a.name AS author_name
+        a.name AS author_name,
+        b.publisher_id,
+        p.name AS publisher_name,
+        b.published_year,
+        b.edition,
+        b.language,
+        b.page_count,
+        b.format,
+        b.dewey_class,
+        b.udc_class,
+        round(r.rating_sum::numeric / NULLIF(r.rating_count, 0), 2) AS rating_avg,
+        COALESCE(r.rating_count, 0) AS rating_count,
+        b.version,
+        b.deleted_at
       FROM books b
       JOIN authors a ON a.id = b.author_id
+      LEFT JOIN publishers p ON p.id = b.publisher_id
+      LEFT JOIN book_ratings r ON r.book_id = b.id
     `
 
-	var books []models.Book
-	err := db.GetDBConn().Select(&books,
-		sql,
+// GetAllBooks возвращает список книг по фильтру; удалённые — только при f.IncludeDeleted.
+func GetAllBooks(ctx context.Context, f models.BookFilter) ([]models.Book, error) {
+	defer metrics.ObserveQuery("GetAllBooks")()
+	logger.Debug.Printf("repo.GetAllBooks: executing SELECT FROM books filter=%+v", f)
+
+	var (
+		where []string
+		args  []interface{}
 	)
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if !f.IncludeDeleted {
+		where = append(where, "b.deleted_at IS NULL")
+	}
+	if f.PublisherID != nil {
+		add("b.publisher_id = $%d", *f.PublisherID)
+	}
+	if f.Language != "" {
+		add("b.language = $%d", f.Language)
+	}
+	if f.YearFrom != nil {
+		add("b.published_year >= $%d", *f.YearFrom)
+	}
+	if f.YearTo != nil {
+		add("b.published_year <= $%d", *f.YearTo)
+	}
+
+	query := bookSelectSQL
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if f.Sort == models.BookSortShelf {
+		query += " ORDER BY b.dewey_class NULLS LAST, b.udc_class NULLS LAST, b.title, b.id"
+	} else {
+		query += " ORDER BY b.id"
+	}
+
+	var books []models.Book
+	err := db.GetReadConn(ctx).SelectContext(ctx, &books, query, args...)
 	if err != nil {
 		logger.Error.Printf("repo.GetAllBooks: query error: %v", err)
 		return nil, translateError(err)
@@ -33,22 +90,14 @@ func GetAllBooks() ([]models.Book, error) {
 	return books, nil
 }
 
-// GetBookByID возвращает книгу по ID.
-func GetBookByID(bookID int) (models.Book, error) {
-	logger.Debug.Printf("repo.GetBookByID: executing SELECT id, name, title, author FROM books WHERE id=%d", bookID)
-
-	const sql = `
-      SELECT 
-        b.id, b.name, b.title, b.author_id,
-        a.name AS author_name
-      FROM books b
-      JOIN authors a ON a.id = b.author_id
-      WHERE b.id = $1
-    `
+// GetBookByID возвращает книгу по ID; удалённую — только при includeDeleted.
+func GetBookByID(ctx context.Context, bookID int, includeDeleted bool) (models.Book, error) {
+	defer metrics.ObserveQuery("GetBookByID")()
+	logger.Debug.Printf("repo.GetBookByID: executing SELECT FROM books WHERE id=%d include_deleted=%t", bookID, includeDeleted)
 
 	var b models.Book
-	err := db.GetDBConn().Get(&b,
-		sql, bookID,
+	err := db.GetReadConn(ctx).GetContext(ctx, &b,
+		bookSelectSQL+` WHERE b.id = $1 AND ($2 OR b.deleted_at IS NULL)`, bookID, includeDeleted,
 	)
 	if err != nil {
 		logger.Error.Printf("repo.GetBookByID: query error id=%d: %v", bookID, err)
@@ -58,58 +107,120 @@ func GetBookByID(bookID int) (models.Book, error) {
 	return b, nil
 }
 
-// CreateBook сохраняет новую книгу.
-func CreateBook(book *models.Book) error {
-	logger.Debug.Printf("repo.CreateBook: executing INSERT INTO books (name, title, author) VALUES (%q, %q, %q)",
+// bookByIDSQL выбирает книгу по ID независимо от пометки об удалении.
+const bookByIDSQL = bookSelectSQL + ` WHERE b.id = $1`
+
+// lockActiveAuthor не даёт удалить автора до конца транзакции (DeleteAuthorByID берёт
+// FOR UPDATE) и возвращает errs.ErrAuthorDeleted, если автор уже удалён.
+// Несуществующего автора отклонит внешний ключ.
+func lockActiveAuthor(ctx context.Context, tx *sqlx.Tx, authorID int) error {
+	var deleted bool
+	err := tx.GetContext(ctx, &deleted, `SELECT deleted_at IS NOT NULL FROM authors WHERE id = $1 FOR SHARE`, authorID)
+	if translateError(err) == errs.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return errs.ErrAuthorDeleted
+	}
+	return nil
+}
+
+// CreateBook сохраняет новую книгу и пишет событие аудита в той же транзакции.
+// Книгу удалённого автора создать нельзя (errs.ErrAuthorDeleted).
+func CreateBook(ctx context.Context, book *models.Book, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("CreateBook")()
+	logger.Debug.Printf("repo.CreateBook: executing INSERT INTO books (name, title, author_id) VALUES (%q, %q, %d)",
 		book.Name, book.Title, book.AuthorID)
 
 	const sql = `
-      INSERT INTO books (name, title, author_id)
-      VALUES ($1, $2, $3) RETURNING id
+      INSERT INTO books (name, title, author_id,
+                         publisher_id, published_year, edition, language, page_count, format,
+                         dewey_class, udc_class)
+      VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id
     `
 
-	err := db.GetDBConn().QueryRow(
-		sql, book.Name, book.Title, book.AuthorID,
-	).Scan(&book.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := lockActiveAuthor(ctx, tx, book.AuthorID); err != nil {
+			return err
+		}
+		if err := tx.QueryRowContext(ctx, sql, book.Name, book.Title, book.AuthorID,
+			book.PublisherID, book.PublishedYear, book.Edition, book.Language, book.PageCount, book.Format,
+			book.DeweyClass, book.UDCClass,
+		).Scan(&book.ID); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, book, bookByIDSQL, book.ID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionCreate, models.AuditEntityBook, book.ID, nil, book)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.CreateBook: insert error name=%q title=%q: %v", book.Name, book.Title, err)
 		return translateError(err)
 	}
 
-	const selectSQL = `
-      SELECT 
-        b.id, b.name, b.title, b.author_id,
-        a.name AS author_name
-      FROM books b
-      JOIN authors a ON a.id = b.author_id
-      WHERE b.id = $1
-    `
-
-	if err := db.GetDBConn().Get(book, selectSQL, book.ID); err != nil {
-		return translateError(err)
-	}
-
 	logger.Info.Printf("repo.CreateBook: created book ID=%d title=%q", book.ID, book.Title)
 	return nil
 }
 
-// UpdateBook обновляет существующую книгу.
-func UpdateBook(book *models.Book) error {
-	logger.Debug.Printf("repo.UpdateBook: executing UPDATE books SET name=%q, title=%q, author=%q WHERE id=%d",
+// UpdateBook обновляет существующую книгу и пишет событие аудита в той же транзакции.
+// book.Version — ожидаемая версия строки (0 — без проверки); при расхождении
+// возвращается errs.ErrVersionMismatch. После успеха book содержит новую версию.
+func UpdateBook(ctx context.Context, book *models.Book, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("UpdateBook")()
+	logger.Debug.Printf("repo.UpdateBook: executing UPDATE books SET name=%q, title=%q, author_id=%d WHERE id=%d",
 		book.Name, book.Title, book.AuthorID, book.ID,
 	)
 
 	const sql = `
       UPDATE books
-         SET name      = $1,
-             title     = $2,
-             author_id = $3
-       WHERE id        = $4
+         SET name           = $1,
+             title          = $2,
+             author_id      = $3,
+             publisher_id   = $4,
+             published_year = $5,
+             edition        = $6,
+             language       = $7,
+             page_count     = $8,
+             format         = $9,
+             dewey_class    = $10,
+             udc_class      = $11,
+             version        = version + 1
+       WHERE id             = $12
+         AND version        = $13
+         AND deleted_at IS NULL
     `
 
-	_, err := db.GetDBConn().Exec(
-		sql, book.Name, book.Title, book.AuthorID, book.ID,
-	)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Book
+		if err := tx.GetContext(ctx, &before, bookByIDSQL+" AND b.deleted_at IS NULL FOR UPDATE OF b", book.ID); err != nil {
+			return err
+		}
+		if book.Version != 0 && book.Version != before.Version {
+			return errs.ErrVersionMismatch
+		}
+		if book.AuthorID != before.AuthorID {
+			if err := lockActiveAuthor(ctx, tx, book.AuthorID); err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx, sql, book.Name, book.Title, book.AuthorID,
+			book.PublisherID, book.PublishedYear, book.Edition, book.Language, book.PageCount, book.Format,
+			book.DeweyClass, book.UDCClass, book.ID, before.Version)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrNotFound
+		}
+		if err := tx.GetContext(ctx, book, bookByIDSQL, book.ID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityBook, book.ID, before, book)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.UpdateBook: exec error ID=%d: %v", book.ID, err)
 		return translateError(err)
@@ -118,12 +229,20 @@ func UpdateBook(book *models.Book) error {
 	return nil
 }
 
-// DeleteBookByID удаляет книгу по ID.
-func DeleteBookByID(bookID int) error {
-	logger.Debug.Printf("repo.DeleteBookByID: executing DELETE FROM books WHERE id=%d", bookID)
-	_, err := db.GetDBConn().Exec(
-		`DELETE FROM books WHERE id = $1`, bookID,
-	)
+// DeleteBookByID помечает книгу удалённой (soft delete) и пишет событие аудита в той же транзакции.
+func DeleteBookByID(ctx context.Context, bookID int, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("DeleteBookByID")()
+	logger.Debug.Printf("repo.DeleteBookByID: executing UPDATE books SET deleted_at=now() WHERE id=%d", bookID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Book
+		if err := tx.GetContext(ctx, &before, bookByIDSQL+" AND b.deleted_at IS NULL FOR UPDATE OF b", bookID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE books SET deleted_at = now(), version = version + 1 WHERE id = $1`, bookID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionDelete, models.AuditEntityBook, bookID, before, nil)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.DeleteBookByID: delete error ID=%d: %v", bookID, err)
 		return translateError(err)
@@ -132,22 +251,15 @@ func DeleteBookByID(bookID int) error {
 	return nil
 }
 
-func SearchBooksByName(fragment string) ([]models.Book, error) {
+// SearchBooksByName ищет неудалённые книги по фрагменту названия.
+func SearchBooksByName(ctx context.Context, fragment string) ([]models.Book, error) {
+	defer metrics.ObserveQuery("SearchBooksByName")()
 	logger.Debug.Printf("repo.SearchBooksByTitle: executing SELECT for fragment=%q", fragment)
 
-	const sql = `
-      SELECT 
-        b.id,
-        b.name,
-        b.title,
-        b.author_id,
-        a.name AS author_name
-      FROM books b
-      JOIN authors a ON a.id = b.author_id
-      WHERE b.name ILIKE '%' || $1 || '%'
-    `
 	var books []models.Book
-	err := db.GetDBConn().Select(&books, sql, fragment)
+	err := db.GetReadConn(ctx).SelectContext(ctx, &books,
+		bookSelectSQL+` WHERE b.name ILIKE '%' || $1 || '%' AND b.deleted_at IS NULL`, fragment,
+	)
 	if err != nil {
 		logger.Error.Printf("repo.SearchBooksByTitle: query error fragment=%q: %v", fragment, err)
 		return nil, translateError(err)
@@ -156,3 +268,112 @@ func SearchBooksByName(fragment string) ([]models.Book, error) {
 	logger.Info.Printf("repo.SearchBooksByTitle: found %d books matching %q", len(books), fragment)
 	return books, nil
 }
+
+// GetBooksByAuthorID возвращает неудалённые книги указанного автора.
+func GetBooksByAuthorID(ctx context.Context, authorID int) ([]models.Book, error) {
+	defer metrics.ObserveQuery("GetBooksByAuthorID")()
+	logger.Debug.Printf("repo.GetBooksByAuthorID: executing SELECT for author_id=%d", authorID)
+
+	var books []models.Book
+	err := db.GetReadConn(ctx).SelectContext(ctx, &books,
+		bookSelectSQL+` WHERE b.author_id = $1 AND b.deleted_at IS NULL ORDER BY b.title`, authorID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBooksByAuthorID: query error author_id=%d: %v", authorID, err)
+		return nil, translateError(err)
+	}
+
+	logger.Info.Printf("repo.GetBooksByAuthorID: found %d books for author_id=%d", len(books), authorID)
+	return books, nil
+}
+
+// GetNewestBooks возвращает последние добавленные неудалённые книги.
+func GetNewestBooks(ctx context.Context, limit int) ([]models.Book, error) {
+	defer metrics.ObserveQuery("GetNewestBooks")()
+	logger.Debug.Printf("repo.GetNewestBooks: executing SELECT with limit=%d", limit)
+
+	var books []models.Book
+	err := db.GetReadConn(ctx).SelectContext(ctx, &books,
+		bookSelectSQL+` WHERE b.deleted_at IS NULL ORDER BY b.id DESC LIMIT $1`, limit,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetNewestBooks: query error limit=%d: %v", limit, err)
+		return nil, translateError(err)
+	}
+
+	logger.Info.Printf("repo.GetNewestBooks: returned %d books", len(books))
+	return books, nil
+}
+
+// RestoreBookByID снимает пометку об удалении и пишет событие аудита в той же транзакции.
+// Пока автор книги удалён, восстановить её нельзя (errs.ErrAuthorDeleted).
+func RestoreBookByID(ctx context.Context, bookID int, actor models.AuditActor) (models.Book, error) {
+	defer metrics.ObserveQuery("RestoreBookByID")()
+	logger.Debug.Printf("repo.RestoreBookByID: executing UPDATE books SET deleted_at=NULL WHERE id=%d", bookID)
+	var book models.Book
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Book
+		if err := tx.GetContext(ctx, &before, bookByIDSQL+" AND b.deleted_at IS NOT NULL FOR UPDATE OF b", bookID); err != nil {
+			return err
+		}
+		if err := lockActiveAuthor(ctx, tx, before.AuthorID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE books SET deleted_at = NULL, version = version + 1 WHERE id = $1`, bookID); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &book, bookByIDSQL, bookID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionRestore, models.AuditEntityBook, bookID, before, book)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.RestoreBookByID: restore error ID=%d: %v", bookID, err)
+		return models.Book{}, translateError(err)
+	}
+	logger.Info.Printf("repo.RestoreBookByID: restored book ID=%d", bookID)
+	return book, nil
+}
+
+// PatchBook обновляет только переданные колонки книги (merge patch) и пишет событие аудита.
+// version — ожидаемая версия строки (0 — без проверки).
+func PatchBook(ctx context.Context, bookID, version int, fields map[string]interface{}, actor models.AuditActor) (models.Book, error) {
+	defer metrics.ObserveQuery("PatchBook")()
+	logger.Debug.Printf("repo.PatchBook: patching book id=%d fields=%d", bookID, len(fields))
+	var book models.Book
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Book
+		if err := tx.GetContext(ctx, &before, bookByIDSQL+" AND b.deleted_at IS NULL FOR UPDATE OF b", bookID); err != nil {
+			return err
+		}
+		if version != 0 && version != before.Version {
+			return errs.ErrVersionMismatch
+		}
+		if authorID, ok := fields["author_id"].(int); ok && authorID != before.AuthorID {
+			if err := lockActiveAuthor(ctx, tx, authorID); err != nil {
+				return err
+			}
+		}
+		query, args, err := buildPatchUpdate("books", bookPatchColumns, fields, bookID, before.Version)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrNotFound
+		}
+		if err := tx.GetContext(ctx, &book, bookByIDSQL, bookID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityBook, bookID, before, book)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.PatchBook: patch error ID=%d: %v", bookID, err)
+		return models.Book{}, translateError(err)
+	}
+	logger.Info.Printf("repo.PatchBook: patched book ID=%d version=%d", bookID, book.Version)
+	return book, nil
+}
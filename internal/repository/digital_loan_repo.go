@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const digitalLoanColumns = `
+        l.id, l.book_id, b.title AS book_title, l.user_id,
+        l.checked_out_at, l.expires_at, l.returned_at
+`
+
+// CreateDigitalLoan выдаёт читателю лицензию на издание.
+// Строка пула лицензий блокируется FOR UPDATE, чтобы параллельные выдачи
+// не превысили license_count.
+func CreateDigitalLoan(ctx context.Context, loan *models.DigitalLoan, defaultLicenses int) error {
+	defer metrics.ObserveQuery("CreateDigitalLoan")()
+	logger.Debug.Printf("repo.CreateDigitalLoan: start book_id=%d user_id=%d", loan.BookID, loan.UserID)
+
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO digital_titles (book_id, license_count) VALUES ($1, $2) ON CONFLICT (book_id) DO NOTHING`,
+			loan.BookID, defaultLicenses,
+		); err != nil {
+			return err
+		}
+
+		var licenses int
+		if err := tx.GetContext(ctx, &licenses,
+			`SELECT license_count FROM digital_titles WHERE book_id = $1 FOR UPDATE`, loan.BookID,
+		); err != nil {
+			return err
+		}
+
+		var active struct {
+			Total  int `db:"total"`
+			ByUser int `db:"by_user"`
+		}
+		if err := tx.GetContext(ctx, &active, `
+          SELECT count(*)                               AS total,
+                 count(*) FILTER (WHERE user_id = $2)   AS by_user
+            FROM digital_loans
+           WHERE book_id = $1
+             AND returned_at IS NULL
+             AND expires_at > now()
+        `, loan.BookID, loan.UserID); err != nil {
+			return err
+		}
+		if active.ByUser > 0 {
+			return errs.ErrAlreadyBorrowed
+		}
+		if active.Total >= licenses {
+			logger.Warn.Printf("repo.CreateDigitalLoan: no licenses left book_id=%d (%d/%d)", loan.BookID, active.Total, licenses)
+			return errs.ErrNoLicensesAvailable
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+          INSERT INTO digital_loans (book_id, user_id, expires_at)
+          VALUES ($1, $2, $3)
+          RETURNING id, checked_out_at
+        `, loan.BookID, loan.UserID, loan.ExpiresAt).Scan(&loan.ID, &loan.CheckedOutAt); err != nil {
+			return err
+		}
+		return insertOutboxEvent(ctx, tx, models.WebhookEventLoanCheckedOut, loanEventData(*loan), models.AuditActor{UserID: loan.UserID})
+	})
+	if err != nil {
+		logger.Error.Printf("repo.CreateDigitalLoan: error book_id=%d user_id=%d: %v", loan.BookID, loan.UserID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreateDigitalLoan: created loan ID=%d book_id=%d user_id=%d", loan.ID, loan.BookID, loan.UserID)
+	return nil
+}
+
+// GetDigitalLoanByID возвращает выдачу по ID. Читает с основной базы: по выдаче проверяется доступ
+// к скачиванию, и только что оформленная или возвращённая выдача должна быть видна сразу.
+func GetDigitalLoanByID(ctx context.Context, loanID int) (models.DigitalLoan, error) {
+	defer metrics.ObserveQuery("GetDigitalLoanByID")()
+	logger.Debug.Printf("repo.GetDigitalLoanByID: executing SELECT for id=%d", loanID)
+	var l models.DigitalLoan
+	err := db.GetDBConn().GetContext(ctx, &l, `
+      SELECT `+digitalLoanColumns+`
+        FROM digital_loans l
+        JOIN books b ON b.id = l.book_id
+       WHERE l.id = $1
+    `, loanID)
+	if err != nil {
+		logger.Error.Printf("repo.GetDigitalLoanByID: query error id=%d: %v", loanID, err)
+		return models.DigitalLoan{}, translateError(err)
+	}
+	return l, nil
+}
+
+// GetActiveDigitalLoansByUser возвращает действующие выдачи читателя.
+func GetActiveDigitalLoansByUser(ctx context.Context, userID int) ([]models.DigitalLoan, error) {
+	defer metrics.ObserveQuery("GetActiveDigitalLoansByUser")()
+	logger.Debug.Printf("repo.GetActiveDigitalLoansByUser: executing SELECT for user_id=%d", userID)
+	loans := []models.DigitalLoan{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &loans, `
+      SELECT `+digitalLoanColumns+`
+        FROM digital_loans l
+        JOIN books b ON b.id = l.book_id
+       WHERE l.user_id = $1
+         AND l.returned_at IS NULL
+         AND l.expires_at > now()
+       ORDER BY l.expires_at
+    `, userID)
+	if err != nil {
+		logger.Error.Printf("repo.GetActiveDigitalLoansByUser: query error user_id=%d: %v", userID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetActiveDigitalLoansByUser: returned %d loans for user_id=%d", len(loans), userID)
+	return loans, nil
+}
+
+// ReturnDigitalLoan досрочно возвращает лицензию в пул.
+func ReturnDigitalLoan(ctx context.Context, loanID, userID int) error {
+	defer metrics.ObserveQuery("ReturnDigitalLoan")()
+	logger.Debug.Printf("repo.ReturnDigitalLoan: executing UPDATE for id=%d user_id=%d", loanID, userID)
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var loan models.DigitalLoan
+		err := tx.GetContext(ctx, &loan, `
+          UPDATE digital_loans
+             SET returned_at = now()
+           WHERE id = $1
+             AND user_id = $2
+             AND returned_at IS NULL
+             AND expires_at > now()
+          RETURNING id, book_id, user_id, checked_out_at, expires_at, returned_at
+        `, loanID, userID)
+		if err != nil {
+			if translateError(err) != errs.ErrNotFound {
+				logger.Error.Printf("repo.ReturnDigitalLoan: exec error id=%d: %v", loanID, err)
+			}
+			return translateError(err)
+		}
+		if err := insertOutboxEvent(ctx, tx, models.WebhookEventLoanReturned, loanEventData(loan), models.AuditActor{UserID: userID}); err != nil {
+			return err
+		}
+		logger.Info.Printf("repo.ReturnDigitalLoan: returned loan ID=%d", loanID)
+		return nil
+	})
+}
+
+// ExpireDigitalLoans закрывает просроченные выдачи, возвращая лицензии в пул.
+func ExpireDigitalLoans(ctx context.Context, now time.Time) (int64, error) {
+	defer metrics.ObserveQuery("ExpireDigitalLoans")()
+	logger.Debug.Println("repo.ExpireDigitalLoans: executing UPDATE for expired loans")
+	var expired []models.DigitalLoan
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.SelectContext(ctx, &expired, `
+          UPDATE digital_loans
+             SET returned_at = expires_at
+           WHERE returned_at IS NULL
+             AND expires_at <= $1
+          RETURNING id, book_id, user_id, checked_out_at, expires_at, returned_at
+        `, now); err != nil {
+			logger.Error.Printf("repo.ExpireDigitalLoans: exec error: %v", err)
+			return translateError(err)
+		}
+		for _, loan := range expired {
+			if err := insertOutboxEvent(ctx, tx, models.WebhookEventLoanExpired, loanEventData(loan), models.AuditActor{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	logger.Info.Printf("repo.ExpireDigitalLoans: expired %d loans", len(expired))
+	return int64(len(expired)), nil
+}
+
+// loanEventData — данные события выдачи для вебхуков.
+func loanEventData(l models.DigitalLoan) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             l.ID,
+		"book_id":        l.BookID,
+		"user_id":        l.UserID,
+		"checked_out_at": l.CheckedOutAt,
+		"expires_at":     l.ExpiresAt,
+		"returned_at":    l.ReturnedAt,
+	}
+}
+
+// SetDigitalLicenseCount задаёт число одновременных лицензий на издание.
+func SetDigitalLicenseCount(ctx context.Context, bookID, count int) error {
+	defer metrics.ObserveQuery("SetDigitalLicenseCount")()
+	logger.Debug.Printf("repo.SetDigitalLicenseCount: executing UPSERT book_id=%d count=%d", bookID, count)
+	_, err := db.GetDBConn().ExecContext(ctx, `
+      INSERT INTO digital_titles (book_id, license_count) VALUES ($1, $2)
+      ON CONFLICT (book_id) DO UPDATE SET license_count = EXCLUDED.license_count
+    `, bookID, count)
+	if err != nil {
+		logger.Error.Printf("repo.SetDigitalLicenseCount: exec error book_id=%d: %v", bookID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.SetDigitalLicenseCount: book_id=%d licenses=%d", bookID, count)
+	return nil
+}
+
+// GetDigitalAvailability возвращает размер пула и число занятых лицензий.
+func GetDigitalAvailability(ctx context.Context, bookID, defaultLicenses int) (models.DigitalAvailability, error) {
+	defer metrics.ObserveQuery("GetDigitalAvailability")()
+	logger.Debug.Printf("repo.GetDigitalAvailability: executing SELECT for book_id=%d", bookID)
+	var a models.DigitalAvailability
+	err := db.GetReadConn(ctx).GetContext(ctx, &a, `
+      SELECT b.id AS book_id,
+             COALESCE(t.license_count, $2) AS license_count,
+             (SELECT count(*)
+                FROM digital_loans l
+               WHERE l.book_id = b.id
+                 AND l.returned_at IS NULL
+                 AND l.expires_at > now()) AS active_loans
+        FROM books b
+        LEFT JOIN digital_titles t ON t.book_id = b.id
+       WHERE b.id = $1
+         AND b.deleted_at IS NULL
+    `, bookID, defaultLicenses)
+	if err != nil {
+		logger.Error.Printf("repo.GetDigitalAvailability: query error book_id=%d: %v", bookID, err)
+		return models.DigitalAvailability{}, translateError(err)
+	}
+	a.Available = a.LicenseCount - a.ActiveLoans
+	if a.Available < 0 {
+		a.Available = 0
+	}
+	return a, nil
+}
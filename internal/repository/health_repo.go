@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"Library/internal/db"
+	"Library/internal/metrics"
+
+	"github.com/lib/pq"
+)
+
+// requiredSchemaObjects — таблицы и колонки ("таблица.колонка"), по которым проверяется,
+// что schema.sql применён полностью. Дополнять при изменении схемы: достаточно одного
+// объекта из каждого нового блока.
+var requiredSchemaObjects = []string{
+	"books", "authors", "book_authors", "users", "book_files", "digital_titles", "digital_loans",
+	"audit_events", "books_history", "authors_history", "users_history", "publishers", "subjects",
+	"book_subjects", "series", "series_books", "author_aliases", "reviews", "book_ratings",
+	"reading_lists", "reading_list_items", "book_similarities", "recommendation_state",
+	"job_runs", "tasks", "notifications", "notification_settings", "notification_preferences",
+	"webhook_subscriptions", "outbox_events", "webhook_deliveries",
+	"books.deleted_at", "books.version", "books.publisher_id", "books.dewey_class",
+	"authors.nationality", "digital_loans.reminder_sent_at",
+}
+
+// PingDB проверяет, что база отвечает.
+func PingDB(ctx context.Context) error {
+	defer metrics.ObserveQuery("PingDB")()
+	return db.GetDBConn().PingContext(ctx)
+}
+
+// GetMissingSchemaObjects возвращает объекты схемы, которых нет в базе.
+func GetMissingSchemaObjects(ctx context.Context) ([]string, error) {
+	defer metrics.ObserveQuery("GetMissingSchemaObjects")()
+	missing := []string{}
+	err := db.GetDBConn().SelectContext(ctx, &missing, `
+      SELECT o
+        FROM unnest($1::text[]) AS o
+       WHERE CASE
+                 WHEN position('.' IN o) > 0 THEN NOT EXISTS (
+                     SELECT 1
+                       FROM information_schema.columns
+                      WHERE table_schema = current_schema()
+                        AND table_name = split_part(o, '.', 1)
+                        AND column_name = split_part(o, '.', 2))
+                 ELSE to_regclass(o) IS NULL
+             END`, pq.StringArray(requiredSchemaObjects),
+	)
+	return missing, err
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// historyInsertSQL копирует текущее состояние строки в таблицу истории.
+// Версия строки уникальна в пределах записи, поэтому (id, version) — ключ истории.
+var historyInsertSQL = map[string]string{
+	models.AuditEntityBook: `
+      INSERT INTO books_history (book_id, version, name, title, author_id,
+                                 publisher_id, published_year, edition, language, page_count, format,
+                                 dewey_class, udc_class, deleted_at, operation, changed_by)
+      SELECT id, version, name, title, author_id,
+             publisher_id, published_year, edition, language, page_count, format,
+             dewey_class, udc_class, deleted_at, $2, $3
+        FROM books WHERE id = $1`,
+	models.AuditEntityAuthor: `
+      INSERT INTO authors_history (author_id, version, name, birth_date, death_date, biography, nationality,
+                                   deleted_at, operation, changed_by)
+      SELECT id, version, name, birth_date, death_date, biography, nationality,
+             deleted_at, $2, $3
+        FROM authors WHERE id = $1`,
+	models.AuditEntityUser: `
+      INSERT INTO users_history (user_id, version, username, email, role, deleted_at, operation, changed_by)
+      SELECT id, version, username, email, role, deleted_at, $2, $3 FROM users WHERE id = $1`,
+}
+
+// recordChange фиксирует изменение в журнале аудита и в истории записи
+// в той же транзакции, что и само изменение.
+func recordChange(ctx context.Context, tx *sqlx.Tx, actor models.AuditActor, action, entityType string, entityID int, before, after interface{}) error {
+	if err := insertAuditEvent(ctx, tx, actor, action, entityType, entityID, before, after); err != nil {
+		return err
+	}
+	var changedBy *int
+	if actor.UserID != 0 {
+		changedBy = &actor.UserID
+	}
+	if _, err := tx.ExecContext(ctx, historyInsertSQL[entityType], entityID, action, changedBy); err != nil {
+		logger.Error.Printf("repo.recordChange: history insert error %s/%d: %v", entityType, entityID, err)
+		return err
+	}
+	return nil
+}
+
+const bookHistoryColumns = `
+        book_id, version, name, title, COALESCE(author_id, 0) AS author_id,
+        publisher_id, published_year, edition, language, page_count, format,
+        dewey_class, udc_class, deleted_at, operation, changed_by, changed_at`
+
+// GetBookHistory возвращает все сохранённые версии книги, начиная с последней.
+func GetBookHistory(ctx context.Context, bookID int) ([]models.BookHistory, error) {
+	defer metrics.ObserveQuery("GetBookHistory")()
+	logger.Debug.Printf("repo.GetBookHistory: executing SELECT for book_id=%d", bookID)
+	history := []models.BookHistory{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &history,
+		`SELECT `+bookHistoryColumns+` FROM books_history WHERE book_id = $1 ORDER BY version DESC`, bookID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBookHistory: query error book_id=%d: %v", bookID, err)
+		return nil, translateError(err)
+	}
+	if len(history) == 0 {
+		return nil, errs.ErrNotFound
+	}
+	logger.Info.Printf("repo.GetBookHistory: returned %d versions for book_id=%d", len(history), bookID)
+	return history, nil
+}
+
+// GetBookAsOf восстанавливает книгу такой, какой она была в момент asOf.
+// Имя автора берётся из истории авторов на тот же момент.
+// Если книга в тот момент не существовала или была удалена — errs.ErrNotFound.
+func GetBookAsOf(ctx context.Context, bookID int, asOf time.Time) (models.Book, error) {
+	defer metrics.ObserveQuery("GetBookAsOf")()
+	logger.Debug.Printf("repo.GetBookAsOf: executing SELECT for book_id=%d as_of=%s", bookID, asOf.Format(time.RFC3339))
+	var b models.Book
+	err := db.GetReadConn(ctx).GetContext(ctx, &b, `
+      SELECT h.book_id AS id, h.name, h.title, h.author_id, h.version, h.deleted_at,
+             h.publisher_id, h.published_year, h.edition, h.language, h.page_count, h.format,
+             h.dewey_class, h.udc_class,
+             (SELECT p.name FROM publishers p WHERE p.id = h.publisher_id) AS publisher_name,
+             COALESCE(
+               (SELECT ah.name FROM authors_history ah
+                 WHERE ah.author_id = h.author_id AND ah.changed_at <= $2
+                 ORDER BY ah.version DESC LIMIT 1),
+               (SELECT a.name FROM authors a WHERE a.id = h.author_id),
+               ''
+             ) AS author_name
+        FROM books_history h
+       WHERE h.book_id = $1
+         AND h.changed_at <= $2
+       ORDER BY h.version DESC
+       LIMIT 1
+    `, bookID, asOf)
+	if err != nil {
+		logger.Error.Printf("repo.GetBookAsOf: query error book_id=%d: %v", bookID, err)
+		return models.Book{}, translateError(err)
+	}
+	if b.DeletedAt != nil {
+		return models.Book{}, errs.ErrNotFound
+	}
+	return b, nil
+}
+
+// RevertBook возвращает поля книги к сохранённой версии toVersion, создавая новую версию.
+// version — ожидаемая текущая версия строки (0 — без проверки).
+func RevertBook(ctx context.Context, bookID, toVersion, version int, actor models.AuditActor) (models.Book, error) {
+	defer metrics.ObserveQuery("RevertBook")()
+	logger.Debug.Printf("repo.RevertBook: reverting book id=%d to version=%d", bookID, toVersion)
+	var book models.Book
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Book
+		if err := tx.GetContext(ctx, &before, bookByIDSQL+" AND b.deleted_at IS NULL FOR UPDATE OF b", bookID); err != nil {
+			return err
+		}
+		if version != 0 && version != before.Version {
+			return errs.ErrVersionMismatch
+		}
+
+		var target models.BookHistory
+		if err := tx.GetContext(ctx, &target,
+			`SELECT `+bookHistoryColumns+` FROM books_history WHERE book_id = $1 AND version = $2`, bookID, toVersion,
+		); err != nil {
+			return err
+		}
+		var authorAlive bool
+		if err := tx.GetContext(ctx, &authorAlive,
+			`SELECT EXISTS (SELECT 1 FROM authors WHERE id = $1 AND deleted_at IS NULL)`, target.AuthorID,
+		); err != nil {
+			return err
+		}
+		if !authorAlive {
+			return fmt.Errorf("%w: author of version %d no longer exists", errs.ErrValidationFailed, toVersion)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+          UPDATE books
+             SET name = $1, title = $2, author_id = $3,
+                 publisher_id = (SELECT id FROM publishers WHERE id = $4),
+                 published_year = $5, edition = $6, language = $7, page_count = $8, format = $9,
+                 dewey_class = $10, udc_class = $11,
+                 version = version + 1
+           WHERE id = $12`,
+			target.Name, target.Title, target.AuthorID,
+			target.PublisherID, target.PublishedYear, target.Edition, target.Language, target.PageCount, target.Format,
+			target.DeweyClass, target.UDCClass, bookID,
+		); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &book, bookByIDSQL, bookID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionRevert, models.AuditEntityBook, bookID, before, book)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.RevertBook: revert error id=%d: %v", bookID, err)
+		return models.Book{}, translateError(err)
+	}
+	logger.Info.Printf("repo.RevertBook: book ID=%d reverted to version=%d as version=%d", bookID, toVersion, book.Version)
+	return book, nil
+}
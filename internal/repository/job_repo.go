@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+)
+
+// jobLockKey — ключ advisory-блокировки задачи; одинаков на всех экземплярах.
+func jobLockKey(jobName string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("library-job:" + jobName))
+	return int64(h.Sum64())
+}
+
+// TryJobLock пытается захватить сессионную advisory-блокировку задачи на отдельном соединении.
+// Блокировка держится до вызова release или до обрыва соединения, поэтому упавший
+// экземпляр не мешает остальным. ok == false — задачу сейчас выполняет другой экземпляр.
+func TryJobLock(ctx context.Context, jobName string) (release func(), ok bool, err error) {
+	defer metrics.ObserveQuery("TryJobLock")()
+	conn, err := db.GetDBConn().Connx(ctx)
+	if err != nil {
+		logger.Error.Printf("repo.TryJobLock: conn error job=%s: %v", jobName, err)
+		return nil, false, err
+	}
+	key := jobLockKey(jobName)
+	if err := conn.GetContext(ctx, &ok, `SELECT pg_try_advisory_lock($1)`, key); err != nil {
+		conn.Close()
+		logger.Error.Printf("repo.TryJobLock: lock error job=%s: %v", jobName, err)
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			logger.Warn.Printf("repo.TryJobLock: unlock error job=%s: %v", jobName, err)
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// StartJobRun записывает начало запуска. Для планового запуска started == false,
+// если этот слот расписания уже выполнил другой экземпляр.
+func StartJobRun(ctx context.Context, jobName, trigger string, scheduledFor *time.Time, instance string) (id int64, started bool, err error) {
+	defer metrics.ObserveQuery("StartJobRun")()
+	err = db.GetDBConn().GetContext(ctx, &id, `
+      INSERT INTO job_runs (job_name, trigger, scheduled_for, instance)
+      VALUES ($1, $2, $3, $4)
+      ON CONFLICT (job_name, scheduled_for) DO NOTHING
+      RETURNING id`, jobName, trigger, scheduledFor, instance,
+	)
+	if err != nil {
+		if translateError(err) == errs.ErrNotFound {
+			return 0, false, nil
+		}
+		logger.Error.Printf("repo.StartJobRun: insert error job=%s: %v", jobName, err)
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// FinishJobRun фиксирует результат запуска.
+func FinishJobRun(ctx context.Context, id int64, status, errText string) error {
+	defer metrics.ObserveQuery("FinishJobRun")()
+	if _, err := db.GetDBConn().ExecContext(ctx,
+		`UPDATE job_runs SET finished_at = now(), status = $1, error = $2 WHERE id = $3`, status, errText, id,
+	); err != nil {
+		logger.Error.Printf("repo.FinishJobRun: update error id=%d: %v", id, err)
+		return err
+	}
+	return nil
+}
+
+// GetJobRuns возвращает последние запуски задачи, новые сначала.
+func GetJobRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	defer metrics.ObserveQuery("GetJobRuns")()
+	logger.Debug.Printf("repo.GetJobRuns: executing SELECT for job=%s limit=%d", jobName, limit)
+	runs := []models.JobRun{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &runs, `
+      SELECT id, job_name, trigger, scheduled_for, started_at, finished_at, status, error, instance
+        FROM job_runs
+       WHERE job_name = $1
+       ORDER BY started_at DESC, id DESC
+       LIMIT $2`, jobName, limit,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetJobRuns: query error job=%s: %v", jobName, err)
+		return nil, translateError(err)
+	}
+	return runs, nil
+}
+
+// GetLatestJobRuns возвращает последний запуск каждой задачи.
+func GetLatestJobRuns(ctx context.Context) ([]models.JobRun, error) {
+	defer metrics.ObserveQuery("GetLatestJobRuns")()
+	runs := []models.JobRun{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &runs, `
+      SELECT DISTINCT ON (job_name)
+             id, job_name, trigger, scheduled_for, started_at, finished_at, status, error, instance
+        FROM job_runs
+       ORDER BY job_name, started_at DESC, id DESC`,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetLatestJobRuns: query error: %v", err)
+		return nil, translateError(err)
+	}
+	return runs, nil
+}
+
+// PurgeJobRuns удаляет историю запусков старше olderThan.
+func PurgeJobRuns(ctx context.Context, olderThan time.Time) (int64, error) {
+	defer metrics.ObserveQuery("PurgeJobRuns")()
+	res, err := db.GetDBConn().ExecContext(ctx, `DELETE FROM job_runs WHERE started_at < $1`, olderThan)
+	if err != nil {
+		logger.Error.Printf("repo.PurgeJobRuns: delete error: %v", err)
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetNotificationSettings возвращает язык и явно заданные настройки каналов читателя.
+// Если читатель ничего не настраивал, locale пустой, а список пуст.
+func GetNotificationSettings(ctx context.Context, userID int) (locale string, prefs []models.NotificationPreference, err error) {
+	defer metrics.ObserveQuery("GetNotificationSettings")()
+	logger.Debug.Printf("repo.GetNotificationSettings: user_id=%d", userID)
+	err = db.GetReadConn(ctx).GetContext(ctx, &locale, `SELECT locale FROM notification_settings WHERE user_id = $1`, userID)
+	if err != nil && translateError(err) != errs.ErrNotFound {
+		logger.Error.Printf("repo.GetNotificationSettings: settings query error user_id=%d: %v", userID, err)
+		return "", nil, err
+	}
+
+	prefs = []models.NotificationPreference{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &prefs,
+		`SELECT kind, channel, enabled FROM notification_preferences WHERE user_id = $1`, userID,
+	); err != nil {
+		logger.Error.Printf("repo.GetNotificationSettings: preferences query error user_id=%d: %v", userID, err)
+		return "", nil, err
+	}
+	return locale, prefs, nil
+}
+
+// SaveNotificationSettings сохраняет язык и настройки каналов одной транзакцией.
+func SaveNotificationSettings(ctx context.Context, userID int, locale string, prefs []models.NotificationPreference) error {
+	defer metrics.ObserveQuery("SaveNotificationSettings")()
+	return db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+          INSERT INTO notification_settings (user_id, locale) VALUES ($1, $2)
+          ON CONFLICT (user_id) DO UPDATE SET locale = EXCLUDED.locale`, userID, locale,
+		); err != nil {
+			logger.Error.Printf("repo.SaveNotificationSettings: settings upsert error user_id=%d: %v", userID, err)
+			return translateError(err)
+		}
+		for _, p := range prefs {
+			if _, err := tx.ExecContext(ctx, `
+              INSERT INTO notification_preferences (user_id, kind, channel, enabled) VALUES ($1, $2, $3, $4)
+              ON CONFLICT (user_id, kind, channel) DO UPDATE SET enabled = EXCLUDED.enabled`,
+				userID, p.Kind, p.Channel, p.Enabled,
+			); err != nil {
+				logger.Error.Printf("repo.SaveNotificationSettings: preference upsert error user_id=%d: %v", userID, err)
+				return translateError(err)
+			}
+		}
+		logger.Info.Printf("repo.SaveNotificationSettings: user_id=%d locale=%s prefs=%d", userID, locale, len(prefs))
+		return nil
+	})
+}
+
+// InsertNotification кладёт уведомление во входящие. Повтор с тем же dedupKey игнорируется (created == false).
+func InsertNotification(ctx context.Context, n models.Notification, dedupKey string) (created bool, err error) {
+	defer metrics.ObserveQuery("InsertNotification")()
+	var key *string
+	if dedupKey != "" {
+		key = &dedupKey
+	}
+	res, err := db.GetDBConn().ExecContext(ctx, `
+      INSERT INTO notifications (user_id, kind, title, body, link, dedup_key)
+      VALUES ($1, $2, $3, $4, $5, $6)
+      ON CONFLICT (dedup_key) DO NOTHING`, n.UserID, n.Kind, n.Title, n.Body, n.Link, key,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.InsertNotification: insert error user_id=%d kind=%s: %v", n.UserID, n.Kind, err)
+		return false, translateError(err)
+	}
+	affected, _ := res.RowsAffected()
+	return affected > 0, nil
+}
+
+// GetUserNotifications возвращает входящие читателя (новые сверху).
+func GetUserNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	defer metrics.ObserveQuery("GetUserNotifications")()
+	logger.Debug.Printf("repo.GetUserNotifications: user_id=%d unread_only=%t", userID, unreadOnly)
+	items := []models.Notification{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &items, `
+      SELECT id, user_id, kind, title, body, link, read_at, created_at
+        FROM notifications
+       WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+       ORDER BY id DESC
+       LIMIT $3 OFFSET $4`, userID, unreadOnly, limit, offset,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetUserNotifications: query error user_id=%d: %v", userID, err)
+		return nil, translateError(err)
+	}
+	return items, nil
+}
+
+// CountUnreadNotifications возвращает число непрочитанных уведомлений.
+func CountUnreadNotifications(ctx context.Context, userID int) (int, error) {
+	defer metrics.ObserveQuery("CountUnreadNotifications")()
+	var n int
+	if err := db.GetReadConn(ctx).GetContext(ctx, &n,
+		`SELECT count(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID,
+	); err != nil {
+		logger.Error.Printf("repo.CountUnreadNotifications: query error user_id=%d: %v", userID, err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// SetNotificationRead отмечает уведомление прочитанным или снова непрочитанным.
+// Чужое уведомление неотличимо от несуществующего.
+func SetNotificationRead(ctx context.Context, userID int, id int64, read bool) error {
+	defer metrics.ObserveQuery("SetNotificationRead")()
+	res, err := db.GetDBConn().ExecContext(ctx, `
+      UPDATE notifications
+         SET read_at = CASE WHEN $3 THEN COALESCE(read_at, now()) END
+       WHERE id = $1 AND user_id = $2`, id, userID, read,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.SetNotificationRead: update error ID=%d: %v", id, err)
+		return translateError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrNotFound
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead отмечает прочитанными все уведомления читателя.
+func MarkAllNotificationsRead(ctx context.Context, userID int) (int64, error) {
+	defer metrics.ObserveQuery("MarkAllNotificationsRead")()
+	res, err := db.GetDBConn().ExecContext(ctx,
+		`UPDATE notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL`, userID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.MarkAllNotificationsRead: update error user_id=%d: %v", userID, err)
+		return 0, translateError(err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// ClaimDueLoanReminders отмечает напомненными активные выдачи, заканчивающиеся в ближайшие within,
+// и возвращает их. Отметка ставится атомарно, поэтому параллельные запуски не напомнят дважды.
+// Если напоминание отправить не удалось, отметку снимает ReleaseLoanReminder.
+func ClaimDueLoanReminders(ctx context.Context, within time.Duration) ([]models.DigitalLoan, error) {
+	defer metrics.ObserveQuery("ClaimDueLoanReminders")()
+	loans := []models.DigitalLoan{}
+	err := db.GetDBConn().SelectContext(ctx, &loans, `
+      UPDATE digital_loans l
+         SET reminder_sent_at = now()
+        FROM books b
+       WHERE b.id = l.book_id
+         AND l.returned_at IS NULL
+         AND l.reminder_sent_at IS NULL
+         AND l.expires_at > now()
+         AND l.expires_at <= now() + $1::float8 * interval '1 second'
+      RETURNING l.id, l.book_id, b.title AS book_title, l.user_id, l.checked_out_at, l.expires_at, l.returned_at`,
+		within.Seconds(),
+	)
+	if err != nil {
+		logger.Error.Printf("repo.ClaimDueLoanReminders: update error: %v", err)
+		return nil, err
+	}
+	return loans, nil
+}
+
+// ReleaseLoanReminder снимает отметку о напоминании, чтобы следующий запуск отправил его снова.
+func ReleaseLoanReminder(ctx context.Context, loanID int) error {
+	defer metrics.ObserveQuery("ReleaseLoanReminder")()
+	if _, err := db.GetDBConn().ExecContext(ctx,
+		`UPDATE digital_loans SET reminder_sent_at = NULL WHERE id = $1 AND returned_at IS NULL`, loanID,
+	); err != nil {
+		logger.Error.Printf("repo.ReleaseLoanReminder: update error loan ID=%d: %v", loanID, err)
+		return translateError(err)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"Library/internal/db"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// outboxVerbs — как действие аудита называется в типе события вебхука.
+var outboxVerbs = map[string]string{
+	models.AuditActionCreate:  "created",
+	models.AuditActionUpdate:  "updated",
+	models.AuditActionDelete:  "deleted",
+	models.AuditActionRestore: "restored",
+	models.AuditActionPurge:   "purged",
+	models.AuditActionRevert:  "updated",
+	models.AuditActionMerge:   "merged",
+}
+
+// outboxPublicColumns ограничивает поля сущности, уходящие внешним системам.
+// Для пользователей это только идентификаторы: email и роль за пределы библиотеки не передаются.
+var outboxPublicColumns = map[string][]string{
+	models.AuditEntityUser: {"id", "username", "deleted_at"},
+}
+
+// OutboxChannel — канал NOTIFY, в который уходит ID каждого нового события outbox.
+const OutboxChannel = "library_events"
+
+type outboxPayload struct {
+	Data      interface{} `json:"data"`
+	ActorID   *int        `json:"actor_id,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// insertOutboxEvent записывает событие в outbox в транзакции изменения.
+// Рассылку подписчикам выполняет RelayOutboxEvents уже после фиксации.
+func insertOutboxEvent(ctx context.Context, tx *sqlx.Tx, eventType string, data interface{}, actor models.AuditActor) error {
+	p := outboxPayload{Data: data, RequestID: actor.RequestID}
+	if actor.UserID != 0 {
+		p.ActorID = &actor.UserID
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		logger.Error.Printf("repo.insertOutboxEvent: marshal error %s: %v", eventType, err)
+		return err
+	}
+	// pg_notify доставляется слушателям только после фиксации транзакции
+	if _, err := tx.ExecContext(ctx, `
+      WITH ev AS (
+          INSERT INTO outbox_events (event_type, payload) VALUES ($1, $2) RETURNING id
+      )
+      SELECT pg_notify($3, id::text) FROM ev`, eventType, raw, OutboxChannel,
+	); err != nil {
+		logger.Error.Printf("repo.insertOutboxEvent: insert error %s: %v", eventType, err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// insertEntityOutboxEvent превращает изменение сущности из журнала аудита в событие <сущность>.<действие>.
+func insertEntityOutboxEvent(ctx context.Context, tx *sqlx.Tx, actor models.AuditActor, action, entityType string, entityID int, before, after interface{}) error {
+	verb, ok := outboxVerbs[action]
+	if !ok {
+		return nil
+	}
+	snapshot := auditSnapshot(after)
+	if snapshot == nil {
+		snapshot = auditSnapshot(before)
+	}
+	if snapshot == nil {
+		snapshot = map[string]interface{}{}
+	}
+	snapshot["id"] = entityID
+	if cols, ok := outboxPublicColumns[entityType]; ok {
+		public := make(map[string]interface{}, len(cols))
+		for _, c := range cols {
+			if v, ok := snapshot[c]; ok {
+				public[c] = v
+			}
+		}
+		snapshot = public
+	}
+	return insertOutboxEvent(ctx, tx, entityType+"."+verb, snapshot, actor)
+}
+
+// RelayOutboxEvents переносит до limit неразосланных событий в журнал доставок — по строке на каждую
+// подходящую активную подписку — и ставит задачи доставки в очередь. Всё выполняется одним запросом,
+// поэтому событие либо разослано целиком, либо остаётся в outbox. Возвращает число событий и доставок.
+func RelayOutboxEvents(ctx context.Context, limit int, taskKind string, maxAttempts int) (events, deliveries int, err error) {
+	defer metrics.ObserveQuery("RelayOutboxEvents")()
+	var res struct {
+		Events     int `db:"events"`
+		Deliveries int `db:"deliveries"`
+	}
+	err = db.GetDBConn().GetContext(ctx, &res, `
+      WITH ev AS (
+          SELECT id, event_type, payload, created_at
+            FROM outbox_events
+           WHERE dispatched_at IS NULL
+           ORDER BY id
+           LIMIT $1
+             FOR UPDATE SKIP LOCKED
+      ), marked AS (
+          UPDATE outbox_events o SET dispatched_at = now() FROM ev WHERE o.id = ev.id
+      ), created AS (
+          INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+          SELECT s.id, ev.id, ev.event_type,
+                 jsonb_build_object('id', ev.id, 'type', ev.event_type, 'occurred_at', ev.created_at) || ev.payload
+            FROM ev
+            JOIN webhook_subscriptions s
+              ON s.active
+             AND (ev.event_type = ANY (s.event_types)
+                  OR '*' = ANY (s.event_types)
+                  OR split_part(ev.event_type, '.', 1) || '.*' = ANY (s.event_types))
+          RETURNING id
+      ), queued AS (
+          INSERT INTO tasks (kind, payload, max_attempts)
+          SELECT $2, jsonb_build_object('delivery_id', id), $3 FROM created
+          RETURNING id
+      )
+      SELECT (SELECT count(*) FROM ev) AS events, (SELECT count(*) FROM queued) AS deliveries`,
+		limit, taskKind, maxAttempts,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.RelayOutboxEvents: error: %v", err)
+		return 0, 0, err
+	}
+	if res.Events > 0 {
+		logger.Debug.Printf("repo.RelayOutboxEvents: %d events -> %d deliveries", res.Events, res.Deliveries)
+	}
+	return res.Events, res.Deliveries, nil
+}
+
+const outboxEventColumns = `id, stream_seq, event_type, payload, created_at`
+
+// outboxSequencerLock — ключ advisory-блокировки, под которой события получают номера в потоке.
+const outboxSequencerLock int64 = 0x6c69627261727931 // "library1"
+
+// SequenceOutboxEvents нумерует до limit уже зафиксированных событий без номера в потоке.
+// ID из BIGSERIAL выдаются до фиксации, и транзакции фиксируются не в их порядке, поэтому
+// поток идёт по stream_seq: номера раздаются под общей блокировкой одной транзакцией за раз,
+// и если видно событие с номером N, то видны и все события с меньшими номерами.
+// Возвращает число пронумерованных событий.
+func SequenceOutboxEvents(ctx context.Context, limit int) (int, error) {
+	defer metrics.ObserveQuery("SequenceOutboxEvents")()
+	var n int64
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, outboxSequencerLock); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `
+          UPDATE outbox_events o
+             SET stream_seq = s.seq
+            FROM (SELECT id, nextval('outbox_stream_seq') AS seq
+                    FROM (SELECT id FROM outbox_events WHERE stream_seq IS NULL ORDER BY id LIMIT $1) p) s
+           WHERE o.id = s.id`, limit,
+		)
+		if err != nil {
+			return err
+		}
+		n, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		logger.Error.Printf("repo.SequenceOutboxEvents: error: %v", err)
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// GetOutboxEventsAfter возвращает до limit событий с номером в потоке больше afterSeq — для продолжения потока.
+func GetOutboxEventsAfter(ctx context.Context, afterSeq int64, limit int) ([]models.OutboxEvent, error) {
+	defer metrics.ObserveQuery("GetOutboxEventsAfter")()
+	events := []models.OutboxEvent{}
+	if err := db.GetDBConn().SelectContext(ctx, &events,
+		`SELECT `+outboxEventColumns+` FROM outbox_events WHERE stream_seq > $1 ORDER BY stream_seq LIMIT $2`, afterSeq, limit,
+	); err != nil {
+		logger.Error.Printf("repo.GetOutboxEventsAfter: query error after=%d: %v", afterSeq, err)
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetLastOutboxStreamSeq возвращает последний номер события в потоке (0, если событий нет).
+func GetLastOutboxStreamSeq(ctx context.Context) (int64, error) {
+	defer metrics.ObserveQuery("GetLastOutboxStreamSeq")()
+	var seq int64
+	if err := db.GetDBConn().GetContext(ctx, &seq, `SELECT COALESCE(max(stream_seq), 0) FROM outbox_events`); err != nil {
+		logger.Error.Printf("repo.GetLastOutboxStreamSeq: query error: %v", err)
+		return 0, err
+	}
+	return seq, nil
+}
+
+// ListenOutboxEvents подписывается на уведомления о новых событиях outbox.
+func ListenOutboxEvents(ctx context.Context) (*pq.Listener, error) {
+	defer metrics.ObserveQuery("ListenOutboxEvents")()
+	return db.Listen(OutboxChannel)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"Library/internal/errs"
+)
+
+// Колонки, которые разрешено менять частичным обновлением (PATCH).
+// Имена колонок попадают в SQL только из этих списков, значения — только
+// через плейсхолдеры, поэтому динамический UPDATE безопасен от инъекций.
+var (
+	bookPatchColumns = map[string]bool{
+		"name": true, "title": true, "author_id": true,
+		"publisher_id": true, "published_year": true, "edition": true,
+		"language": true, "page_count": true, "format": true,
+		"dewey_class": true, "udc_class": true,
+	}
+	authorPatchColumns = map[string]bool{
+		"name": true, "birth_date": true, "death_date": true, "biography": true, "nationality": true,
+	}
+	userPatchColumns = map[string]bool{"username": true, "email": true, "role": true, "password": true}
+)
+
+// buildPatchUpdate строит UPDATE только по переданным полям с проверкой версии строки.
+// Колонки сортируются, чтобы текст запроса не зависел от порядка обхода map.
+func buildPatchUpdate(table string, allowed map[string]bool, fields map[string]interface{}, id, version int) (string, []interface{}, error) {
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("%w: empty patch", errs.ErrValidationFailed)
+	}
+	cols := make([]string, 0, len(fields))
+	for col := range fields {
+		if !allowed[col] {
+			return "", nil, fmt.Errorf("%w: column %q is not patchable in %s", errs.ErrValidationFailed, col, table)
+		}
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	sets := make([]string, 0, len(cols)+1)
+	args := make([]interface{}, 0, len(cols)+2)
+	for i, col := range cols {
+		sets = append(sets, fmt.Sprintf("%s = $%d", col, i+1))
+		args = append(args, fields[col])
+	}
+	sets = append(sets, "version = version + 1")
+	args = append(args, id, version)
+
+	query := fmt.Sprintf(
+		"UPDATE %s SET %s WHERE id = $%d AND version = $%d AND deleted_at IS NULL",
+		table, strings.Join(sets, ", "), len(cols)+1, len(cols)+2,
+	)
+	return query, args, nil
+}
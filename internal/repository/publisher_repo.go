@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const publisherColumns = `id, name, country, website`
+
+// GetAllPublishers возвращает всех издателей.
+func GetAllPublishers(ctx context.Context) ([]models.Publisher, error) {
+	defer metrics.ObserveQuery("GetAllPublishers")()
+	logger.Debug.Println("repo.GetAllPublishers: executing SELECT FROM publishers")
+	publishers := []models.Publisher{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &publishers, `SELECT `+publisherColumns+` FROM publishers ORDER BY name`); err != nil {
+		logger.Error.Printf("repo.GetAllPublishers: query error: %v", err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetAllPublishers: returned %d publishers", len(publishers))
+	return publishers, nil
+}
+
+// GetPublisherByID возвращает издателя по ID.
+func GetPublisherByID(ctx context.Context, publisherID int) (models.Publisher, error) {
+	defer metrics.ObserveQuery("GetPublisherByID")()
+	logger.Debug.Printf("repo.GetPublisherByID: executing SELECT FROM publishers WHERE id=%d", publisherID)
+	var p models.Publisher
+	if err := db.GetReadConn(ctx).GetContext(ctx, &p, `SELECT `+publisherColumns+` FROM publishers WHERE id = $1`, publisherID); err != nil {
+		logger.Error.Printf("repo.GetPublisherByID: query error id=%d: %v", publisherID, err)
+		return models.Publisher{}, translateError(err)
+	}
+	return p, nil
+}
+
+// CreatePublisher добавляет издателя и пишет событие аудита в той же транзакции.
+func CreatePublisher(ctx context.Context, p *models.Publisher, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("CreatePublisher")()
+	logger.Debug.Printf("repo.CreatePublisher: executing INSERT INTO publishers name=%q", p.Name)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			`INSERT INTO publishers (name, country, website) VALUES ($1, $2, $3) RETURNING id`,
+			p.Name, p.Country, p.Website,
+		).Scan(&p.ID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionCreate, models.AuditEntityPublisher, p.ID, nil, p)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.CreatePublisher: insert error name=%q: %v", p.Name, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreatePublisher: created publisher ID=%d name=%q", p.ID, p.Name)
+	return nil
+}
+
+// UpdatePublisher обновляет издателя и пишет событие аудита в той же транзакции.
+func UpdatePublisher(ctx context.Context, p *models.Publisher, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("UpdatePublisher")()
+	logger.Debug.Printf("repo.UpdatePublisher: executing UPDATE publishers id=%d", p.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Publisher
+		if err := tx.GetContext(ctx, &before, `SELECT `+publisherColumns+` FROM publishers WHERE id = $1 FOR UPDATE`, p.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE publishers SET name = $1, country = $2, website = $3 WHERE id = $4`,
+			p.Name, p.Country, p.Website, p.ID,
+		); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityPublisher, p.ID, before, p)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.UpdatePublisher: update error id=%d: %v", p.ID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.UpdatePublisher: updated publisher ID=%d", p.ID)
+	return nil
+}
+
+// DeletePublisherByID удаляет издателя, если на него не ссылаются неудалённые книги.
+// У мягко удалённых книг ссылка обнуляется внешним ключом (ON DELETE SET NULL).
+func DeletePublisherByID(ctx context.Context, publisherID int, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("DeletePublisherByID")()
+	logger.Debug.Printf("repo.DeletePublisherByID: executing DELETE FROM publishers WHERE id=%d", publisherID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Publisher
+		if err := tx.GetContext(ctx, &before, `SELECT `+publisherColumns+` FROM publishers WHERE id = $1 FOR UPDATE`, publisherID); err != nil {
+			return err
+		}
+		var books int
+		if err := tx.GetContext(ctx, &books, `SELECT count(*) FROM books WHERE publisher_id = $1 AND deleted_at IS NULL`, publisherID); err != nil {
+			return err
+		}
+		if books > 0 {
+			return errs.ErrPublisherHasBooks
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM publishers WHERE id = $1`, publisherID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionDelete, models.AuditEntityPublisher, publisherID, before, nil)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.DeletePublisherByID: delete error id=%d: %v", publisherID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.DeletePublisherByID: deleted publisher ID=%d", publisherID)
+	return nil
+}
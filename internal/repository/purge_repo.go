@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetBlobHashesOfPurgeableBooks возвращает хеши файлов книг, которые будут удалены очисткой,
+// чтобы после неё освободить объекты в BlobStore.
+func GetBlobHashesOfPurgeableBooks(ctx context.Context, olderThan time.Time) ([]string, error) {
+	defer metrics.ObserveQuery("GetBlobHashesOfPurgeableBooks")()
+	var hashes []string
+	err := db.GetDBConn().SelectContext(ctx, &hashes, `
+      SELECT f.sha256 FROM book_files f JOIN books b ON b.id = f.book_id
+       WHERE b.deleted_at < $1
+      UNION
+      SELECT f.thumbnail_hash FROM book_files f JOIN books b ON b.id = f.book_id
+       WHERE b.deleted_at < $1 AND f.thumbnail_hash IS NOT NULL
+    `, olderThan)
+	if err != nil {
+		logger.Error.Printf("repo.GetBlobHashesOfPurgeableBooks: query error: %v", err)
+		return nil, translateError(err)
+	}
+	return hashes, nil
+}
+
+// PurgeSoftDeleted окончательно удаляет книги, авторов и пользователей,
+// помеченных удалёнными раньше olderThan. Авторы, на которых ещё ссылаются
+// книги, пропускаются. Каждое удаление фиксируется в журнале аудита.
+// Рейтинг книг, на которые писали отзывы удаляемые пользователи, пересчитывается в той же транзакции.
+func PurgeSoftDeleted(ctx context.Context, olderThan time.Time) (models.PurgeResult, error) {
+	defer metrics.ObserveQuery("PurgeSoftDeleted")()
+	logger.Debug.Printf("repo.PurgeSoftDeleted: purging rows deleted before %s", olderThan.Format(time.RFC3339))
+
+	var res models.PurgeResult
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Отзывы удаляемых пользователей удаляем сами, а не каскадом, чтобы знать, чей рейтинг
+		// пересчитать; книги, которые удаляются этой же очисткой, пропускаем.
+		var rated []int
+		if err := tx.SelectContext(ctx, &rated, `
+          WITH gone AS (
+              DELETE FROM reviews r
+               USING users u, books b
+               WHERE u.id = r.user_id AND u.deleted_at < $1
+                 AND b.id = r.book_id AND (b.deleted_at IS NULL OR b.deleted_at >= $1)
+              RETURNING r.book_id
+          )
+          SELECT DISTINCT book_id FROM gone ORDER BY book_id`, olderThan,
+		); err != nil {
+			return err
+		}
+
+		steps := []struct {
+			entity string
+			query  string
+			count  *int
+		}{
+			{models.AuditEntityBook, `DELETE FROM books WHERE deleted_at < $1 RETURNING id`, &res.Books},
+			{models.AuditEntityAuthor, `
+              DELETE FROM authors a
+               WHERE a.deleted_at < $1
+                 AND NOT EXISTS (SELECT 1 FROM books b WHERE b.author_id = a.id)
+              RETURNING a.id`, &res.Authors},
+			{models.AuditEntityUser, `DELETE FROM users WHERE deleted_at < $1 RETURNING id`, &res.Users},
+		}
+		for _, st := range steps {
+			var ids []int
+			if err := tx.SelectContext(ctx, &ids, st.query, olderThan); err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := insertAuditEvent(ctx, tx, models.AuditActor{}, models.AuditActionPurge, st.entity, id, nil, nil); err != nil {
+					return err
+				}
+			}
+			*st.count = len(ids)
+		}
+
+		for _, bookID := range rated {
+			if err := refreshBookRating(ctx, tx, bookID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error.Printf("repo.PurgeSoftDeleted: purge error: %v", err)
+		return models.PurgeResult{}, translateError(err)
+	}
+	logger.Info.Printf("repo.PurgeSoftDeleted: purged books=%d authors=%d users=%d", res.Books, res.Authors, res.Users)
+	return res, nil
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// readingListSelectSQL — список с числом книг; удалённые из каталога книги не считаются.
+const readingListSelectSQL = `
+      SELECT l.id, l.user_id, l.name, l.kind, l.visibility, l.share_token, l.created_at, l.updated_at,
+             (SELECT count(*)
+                FROM reading_list_items i
+                JOIN books b ON b.id = i.book_id
+               WHERE i.list_id = l.id AND b.deleted_at IS NULL) AS item_count
+        FROM reading_lists l
+    `
+
+// lockOwnReadingList блокирует список владельца; чужой список неотличим от несуществующего.
+func lockOwnReadingList(ctx context.Context, tx *sqlx.Tx, listID, userID int) (string, error) {
+	var kind string
+	err := tx.GetContext(ctx, &kind, `SELECT kind FROM reading_lists WHERE id = $1 AND user_id = $2 FOR UPDATE`, listID, userID)
+	return kind, err
+}
+
+// readingListNameTaken проверяет, что у читателя нет другого списка с таким же названием.
+func readingListNameTaken(ctx context.Context, tx *sqlx.Tx, userID, listID int, name string) error {
+	var taken bool
+	if err := tx.GetContext(ctx, &taken, `
+      SELECT EXISTS (SELECT 1 FROM reading_lists WHERE user_id = $1 AND lower(name) = lower($2) AND id <> $3)`,
+		userID, name, listID,
+	); err != nil {
+		return err
+	}
+	if taken {
+		return errs.ErrListNameTaken
+	}
+	return nil
+}
+
+// EnsureReadingShelves создаёт недостающие стандартные полки читателя.
+// Конфликт допустим только по самой полке; остальные ошибки не глотаются.
+func EnsureReadingShelves(ctx context.Context, userID int, shelves []models.ReadingList) error {
+	defer metrics.ObserveQuery("EnsureReadingShelves")()
+	logger.Debug.Printf("repo.EnsureReadingShelves: user_id=%d", userID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		for _, s := range shelves {
+			if _, err := tx.ExecContext(ctx, `
+              INSERT INTO reading_lists (user_id, name, kind, share_token) VALUES ($1, $2, $3, $4)
+              ON CONFLICT (user_id, kind) WHERE kind <> 'custom' DO NOTHING`, userID, s.Name, s.Kind, s.ShareToken,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error.Printf("repo.EnsureReadingShelves: error user_id=%d: %v", userID, err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// GetUserReadingLists возвращает списки читателя: сначала стандартные полки, затем именованные.
+func GetUserReadingLists(ctx context.Context, userID int) ([]models.ReadingList, error) {
+	defer metrics.ObserveQuery("GetUserReadingLists")()
+	logger.Debug.Printf("repo.GetUserReadingLists: executing SELECT for user_id=%d", userID)
+	lists := []models.ReadingList{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &lists,
+		readingListSelectSQL+` WHERE l.user_id = $1 ORDER BY l.kind = 'custom', l.id`, userID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetUserReadingLists: query error user_id=%d: %v", userID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetUserReadingLists: returned %d lists for user_id=%d", len(lists), userID)
+	return lists, nil
+}
+
+// GetReadingList возвращает список, принадлежащий читателю.
+func GetReadingList(ctx context.Context, listID, userID int) (models.ReadingList, error) {
+	defer metrics.ObserveQuery("GetReadingList")()
+	logger.Debug.Printf("repo.GetReadingList: executing SELECT for id=%d user_id=%d", listID, userID)
+	var l models.ReadingList
+	if err := db.GetReadConn(ctx).GetContext(ctx, &l, readingListSelectSQL+` WHERE l.id = $1 AND l.user_id = $2`, listID, userID); err != nil {
+		logger.Error.Printf("repo.GetReadingList: query error id=%d: %v", listID, err)
+		return models.ReadingList{}, translateError(err)
+	}
+	return l, nil
+}
+
+// GetPublicReadingList возвращает публичный список по токену ссылки.
+func GetPublicReadingList(ctx context.Context, token string) (models.ReadingList, error) {
+	defer metrics.ObserveQuery("GetPublicReadingList")()
+	logger.Debug.Println("repo.GetPublicReadingList: executing SELECT by share token")
+	var l models.ReadingList
+	err := db.GetReadConn(ctx).GetContext(ctx, &l,
+		readingListSelectSQL+` WHERE l.share_token = $1 AND l.visibility = $2`, token, models.ReadingListPublic,
+	)
+	if err != nil {
+		logger.Warn.Printf("repo.GetPublicReadingList: query error: %v", err)
+		return models.ReadingList{}, translateError(err)
+	}
+	return l, nil
+}
+
+// GetReadingListItems возвращает книги списка по порядку; удалённые из каталога пропускаются.
+func GetReadingListItems(ctx context.Context, listID int) ([]models.ReadingListItem, error) {
+	defer metrics.ObserveQuery("GetReadingListItems")()
+	logger.Debug.Printf("repo.GetReadingListItems: executing SELECT for list_id=%d", listID)
+	items := []models.ReadingListItem{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &items, `
+      SELECT i.position, i.note, i.added_at, q.*
+        FROM reading_list_items i
+        JOIN (`+bookSelectSQL+`) q ON q.id = i.book_id
+       WHERE i.list_id = $1
+         AND q.deleted_at IS NULL
+       ORDER BY i.position, i.added_at`, listID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetReadingListItems: query error list_id=%d: %v", listID, err)
+		return nil, translateError(err)
+	}
+	return items, nil
+}
+
+// CreateReadingList создаёт именованный список читателя.
+func CreateReadingList(ctx context.Context, l *models.ReadingList) error {
+	defer metrics.ObserveQuery("CreateReadingList")()
+	logger.Debug.Printf("repo.CreateReadingList: executing INSERT user_id=%d name=%q", l.UserID, l.Name)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := readingListNameTaken(ctx, tx, l.UserID, 0, l.Name); err != nil {
+			return err
+		}
+		var id int
+		if err := tx.GetContext(ctx, &id, `
+          INSERT INTO reading_lists (user_id, name, kind, visibility, share_token)
+          VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			l.UserID, l.Name, l.Kind, l.Visibility, l.ShareToken,
+		); err != nil {
+			return err
+		}
+		return tx.GetContext(ctx, l, readingListSelectSQL+` WHERE l.id = $1`, id)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.CreateReadingList: insert error user_id=%d: %v", l.UserID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreateReadingList: created list ID=%d user_id=%d", l.ID, l.UserID)
+	return nil
+}
+
+// UpdateReadingList меняет название и видимость списка. Стандартные полки не переименовываются.
+func UpdateReadingList(ctx context.Context, l *models.ReadingList) error {
+	defer metrics.ObserveQuery("UpdateReadingList")()
+	logger.Debug.Printf("repo.UpdateReadingList: executing UPDATE id=%d", l.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		kind, err := lockOwnReadingList(ctx, tx, l.ID, l.UserID)
+		if err != nil {
+			return err
+		}
+		if kind != models.ReadingListCustom {
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE reading_lists SET visibility = $1, updated_at = now() WHERE id = $2`, l.Visibility, l.ID,
+			); err != nil {
+				return err
+			}
+		} else {
+			if err := readingListNameTaken(ctx, tx, l.UserID, l.ID, l.Name); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE reading_lists SET name = $1, visibility = $2, updated_at = now() WHERE id = $3`,
+				l.Name, l.Visibility, l.ID,
+			); err != nil {
+				return err
+			}
+		}
+		return tx.GetContext(ctx, l, readingListSelectSQL+` WHERE l.id = $1`, l.ID)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.UpdateReadingList: update error id=%d: %v", l.ID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.UpdateReadingList: updated list ID=%d", l.ID)
+	return nil
+}
+
+// SetReadingListShareToken заменяет токен ссылки; прежняя ссылка перестаёт работать.
+func SetReadingListShareToken(ctx context.Context, listID, userID int, token string) error {
+	defer metrics.ObserveQuery("SetReadingListShareToken")()
+	logger.Debug.Printf("repo.SetReadingListShareToken: id=%d", listID)
+	res, err := db.GetDBConn().ExecContext(ctx,
+		`UPDATE reading_lists SET share_token = $1, updated_at = now() WHERE id = $2 AND user_id = $3`,
+		token, listID, userID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.SetReadingListShareToken: update error id=%d: %v", listID, err)
+		return translateError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteReadingList удаляет именованный список вместе с его элементами.
+func DeleteReadingList(ctx context.Context, listID, userID int) error {
+	defer metrics.ObserveQuery("DeleteReadingList")()
+	logger.Debug.Printf("repo.DeleteReadingList: executing DELETE id=%d", listID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		kind, err := lockOwnReadingList(ctx, tx, listID, userID)
+		if err != nil {
+			return err
+		}
+		if kind != models.ReadingListCustom {
+			return fmt.Errorf("%w: built-in shelves cannot be deleted", errs.ErrValidationFailed)
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM reading_lists WHERE id = $1`, listID)
+		return err
+	})
+	if err != nil {
+		logger.Error.Printf("repo.DeleteReadingList: delete error id=%d: %v", listID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.DeleteReadingList: deleted list ID=%d", listID)
+	return nil
+}
+
+// AddReadingListItem добавляет книгу в конец списка; для уже добавленной книги обновляет заметку.
+func AddReadingListItem(ctx context.Context, listID, userID, bookID int, note string) error {
+	defer metrics.ObserveQuery("AddReadingListItem")()
+	logger.Debug.Printf("repo.AddReadingListItem: list_id=%d book_id=%d", listID, bookID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := lockOwnReadingList(ctx, tx, listID, userID); err != nil {
+			return err
+		}
+		var exists bool
+		if err := tx.GetContext(ctx, &exists,
+			`SELECT EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)`, bookID,
+		); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: unknown book", errs.ErrNotFound)
+		}
+		if _, err := tx.ExecContext(ctx, `
+          INSERT INTO reading_list_items (list_id, book_id, position, note)
+          SELECT $1, $2, COALESCE(max(position), 0) + 1, $3 FROM reading_list_items WHERE list_id = $1
+          ON CONFLICT (list_id, book_id) DO UPDATE SET note = EXCLUDED.note`, listID, bookID, note,
+		); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE reading_lists SET updated_at = now() WHERE id = $1`, listID)
+		return err
+	})
+	if err != nil {
+		logger.Error.Printf("repo.AddReadingListItem: error list_id=%d book_id=%d: %v", listID, bookID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.AddReadingListItem: book_id=%d in list_id=%d", bookID, listID)
+	return nil
+}
+
+// RemoveReadingListItem убирает книгу из списка.
+func RemoveReadingListItem(ctx context.Context, listID, userID, bookID int) error {
+	defer metrics.ObserveQuery("RemoveReadingListItem")()
+	logger.Debug.Printf("repo.RemoveReadingListItem: list_id=%d book_id=%d", listID, bookID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := lockOwnReadingList(ctx, tx, listID, userID); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, `DELETE FROM reading_list_items WHERE list_id = $1 AND book_id = $2`, listID, bookID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrNotFound
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE reading_lists SET updated_at = now() WHERE id = $1`, listID)
+		return err
+	})
+	if err != nil {
+		logger.Error.Printf("repo.RemoveReadingListItem: error list_id=%d book_id=%d: %v", listID, bookID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.RemoveReadingListItem: removed book_id=%d from list_id=%d", bookID, listID)
+	return nil
+}
+
+// ReorderReadingList расставляет книги списка в переданном порядке.
+// bookIDs должен совпадать с набором видимых книг списка.
+func ReorderReadingList(ctx context.Context, listID, userID int, bookIDs []int64) error {
+	defer metrics.ObserveQuery("ReorderReadingList")()
+	logger.Debug.Printf("repo.ReorderReadingList: list_id=%d books=%v", listID, bookIDs)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := lockOwnReadingList(ctx, tx, listID, userID); err != nil {
+			return err
+		}
+		var current []int64
+		if err := tx.SelectContext(ctx, &current, `
+          SELECT i.book_id FROM reading_list_items i
+            JOIN books b ON b.id = i.book_id
+           WHERE i.list_id = $1 AND b.deleted_at IS NULL`, listID,
+		); err != nil {
+			return err
+		}
+		inList := make(map[int64]bool, len(current))
+		for _, id := range current {
+			inList[id] = true
+		}
+		if len(current) != len(bookIDs) {
+			return fmt.Errorf("%w: book_ids must list every book of the list exactly once", errs.ErrValidationFailed)
+		}
+		for _, id := range bookIDs {
+			if !inList[id] {
+				return fmt.Errorf("%w: book %d is not in the list", errs.ErrValidationFailed, id)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+          UPDATE reading_list_items i
+             SET position = o.ord
+            FROM unnest($2::int[]) WITH ORDINALITY AS o(book_id, ord)
+           WHERE i.list_id = $1 AND i.book_id = o.book_id`, listID, pq.Int64Array(bookIDs),
+		); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE reading_lists SET updated_at = now() WHERE id = $1`, listID)
+		return err
+	})
+	if err != nil {
+		logger.Error.Printf("repo.ReorderReadingList: error list_id=%d: %v", listID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.ReorderReadingList: reordered %d books in list_id=%d", len(bookIDs), listID)
+	return nil
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// interactionsSQL — пары (читатель, книга): книга была выдана читателю или лежит в одном из его списков.
+// Включает приватные списки, поэтому годится только для рекомендаций самому владельцу.
+const interactionsSQL = `
+      SELECT user_id, book_id FROM digital_loans
+      UNION
+      SELECT l.user_id, i.book_id
+        FROM reading_list_items i
+        JOIN reading_lists l ON l.id = i.list_id
+    `
+
+// publicInteractionsSQL — те же пары, но из списков берутся только публичные. По ним считается
+// общая таблица похожих книг, которую видит кто угодно через /books/:id/similar.
+const publicInteractionsSQL = `
+      SELECT user_id, book_id FROM digital_loans
+      UNION
+      SELECT l.user_id, i.book_id
+        FROM reading_list_items i
+        JOIN reading_lists l ON l.id = i.list_id
+       WHERE l.visibility = 'public'
+    `
+
+// activityOverlap — запас по времени при выборке новой активности: выдача, зафиксированная
+// уже после начала прошлого пересчёта, но с более ранним временем, не будет пропущена.
+const activityOverlap = 5 * time.Minute
+
+// similaritiesRecomputeSQL пересчитывает топ похожих книг для пачки книг.
+// Близость — косинусная мера по множествам читателей: общие / sqrt(n1 * n2).
+const similaritiesRecomputeSQL = `
+      WITH interactions AS (` + publicInteractionsSQL + `),
+      counts AS (
+          SELECT book_id, count(*)::float8 AS n FROM interactions GROUP BY book_id
+      ),
+      pairs AS (
+          SELECT a.book_id, b.book_id AS similar_book_id, count(*)::float8 AS together
+            FROM interactions a
+            JOIN interactions b ON b.user_id = a.user_id AND b.book_id <> a.book_id
+           WHERE a.book_id = ANY($1)
+           GROUP BY a.book_id, b.book_id
+      ),
+      ranked AS (
+          SELECT p.book_id, p.similar_book_id,
+                 p.together / sqrt(ca.n * cb.n) AS score,
+                 row_number() OVER (PARTITION BY p.book_id
+                                    ORDER BY p.together / sqrt(ca.n * cb.n) DESC, p.similar_book_id) AS rn
+            FROM pairs p
+            JOIN counts ca ON ca.book_id = p.book_id
+            JOIN counts cb ON cb.book_id = p.similar_book_id
+      )
+      INSERT INTO book_similarities (book_id, similar_book_id, score, computed_at)
+      SELECT book_id, similar_book_id, score, now() FROM ranked WHERE rn <= $2
+    `
+
+// recommendationsSQL собирает кандидатов из всех источников и оставляет для каждой книги
+// источник с наивысшим приоритетом. $1 — книги-основания, $2 — книги, которые не предлагаем, $3 — лимит.
+const recommendationsSQL = `
+      WITH base AS (SELECT unnest($1::int[]) AS book_id),
+      cand AS (
+          SELECT s.similar_book_id AS book_id, sum(s.score) AS score, 'co_borrowed' AS reason, 1 AS prio
+            FROM book_similarities s
+            JOIN base ON base.book_id = s.book_id
+           GROUP BY s.similar_book_id
+          UNION ALL
+          SELECT b.id, count(*)::float8, 'same_author', 2
+            FROM base
+            JOIN books mb ON mb.id = base.book_id
+            JOIN books b ON b.author_id = mb.author_id
+           GROUP BY b.id
+          UNION ALL
+          SELECT bs2.book_id, count(*)::float8, 'same_subject', 3
+            FROM base
+            JOIN book_subjects bs1 ON bs1.book_id = base.book_id
+            JOIN book_subjects bs2 ON bs2.subject_id = bs1.subject_id
+           GROUP BY bs2.book_id
+          UNION ALL
+          SELECT book_id, count(*)::float8, 'popular', 4
+            FROM digital_loans
+           WHERE $4 AND checked_out_at > now() - interval '90 days'
+           GROUP BY book_id
+      ),
+      best AS (
+          SELECT DISTINCT ON (book_id) book_id, score, reason, prio
+            FROM cand
+           WHERE book_id <> ALL($2::int[])
+           ORDER BY book_id, prio, score DESC
+      )
+      SELECT best.score, best.reason, q.*
+        FROM best
+        JOIN (` + bookSelectSQL + `) q ON q.id = best.book_id
+       WHERE q.deleted_at IS NULL
+       ORDER BY best.prio, best.score DESC, q.id
+       LIMIT $3
+    `
+
+// GetSimilarBooks возвращает книги, похожие на bookID.
+func GetSimilarBooks(ctx context.Context, bookID, limit int) ([]models.Recommendation, error) {
+	defer metrics.ObserveQuery("GetSimilarBooks")()
+	logger.Debug.Printf("repo.GetSimilarBooks: executing SELECT for book_id=%d limit=%d", bookID, limit)
+	recs := []models.Recommendation{}
+	ids := pq.Int64Array{int64(bookID)}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &recs, recommendationsSQL, ids, ids, limit, false); err != nil {
+		logger.Error.Printf("repo.GetSimilarBooks: query error book_id=%d: %v", bookID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetSimilarBooks: returned %d books for book_id=%d", len(recs), bookID)
+	return recs, nil
+}
+
+// GetUserRecommendations возвращает рекомендации читателю по его выдачам и спискам.
+// Уже знакомые читателю книги не предлагаются; без истории остаются только популярные.
+func GetUserRecommendations(ctx context.Context, userID, limit int) ([]models.Recommendation, error) {
+	defer metrics.ObserveQuery("GetUserRecommendations")()
+	logger.Debug.Printf("repo.GetUserRecommendations: executing SELECT for user_id=%d limit=%d", userID, limit)
+	var mine pq.Int64Array
+	if err := db.GetReadConn(ctx).GetContext(ctx, &mine, `
+      SELECT COALESCE(array_agg(DISTINCT book_id), '{}')
+        FROM (`+interactionsSQL+`) i
+       WHERE i.user_id = $1`, userID,
+	); err != nil {
+		logger.Error.Printf("repo.GetUserRecommendations: history query error user_id=%d: %v", userID, err)
+		return nil, translateError(err)
+	}
+
+	recs := []models.Recommendation{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &recs, recommendationsSQL, mine, mine, limit, true); err != nil {
+		logger.Error.Printf("repo.GetUserRecommendations: query error user_id=%d: %v", userID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetUserRecommendations: returned %d books for user_id=%d", len(recs), userID)
+	return recs, nil
+}
+
+// RefreshBookSimilarities пересчитывает похожие книги для книг, затронутых активностью
+// со времени прошлого пересчёта: это книги всех читателей, у которых появились новые выдачи,
+// книги в списках или изменились сами списки (например, видимость). При первом запуске
+// пересчитывается всё. Затронутые книги берутся с учётом приватных списков, чтобы книга,
+// чей список стал приватным, тоже была пересчитана.
+// Возвращает число пересчитанных книг.
+func RefreshBookSimilarities(ctx context.Context, topN, batchSize int) (int, error) {
+	defer metrics.ObserveQuery("RefreshBookSimilarities")()
+	logger.Debug.Printf("repo.RefreshBookSimilarities: start top_n=%d", topN)
+	var refreshed int
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		// Блокировка строки состояния не даёт двум экземплярам считать одновременно.
+		var since *time.Time
+		if err := tx.GetContext(ctx, &since, `SELECT refreshed_at FROM recommendation_state FOR UPDATE`); err != nil {
+			return err
+		}
+
+		var dirty []int64
+		var err error
+		if since == nil {
+			err = tx.SelectContext(ctx, &dirty, `SELECT DISTINCT book_id FROM (`+interactionsSQL+`) i ORDER BY book_id`)
+		} else {
+			err = tx.SelectContext(ctx, &dirty, `
+              SELECT DISTINCT i.book_id
+                FROM (`+interactionsSQL+`) i
+               WHERE i.user_id IN (
+                     SELECT user_id FROM digital_loans WHERE checked_out_at > $1
+                     UNION
+                     SELECT l.user_id
+                       FROM reading_list_items it
+                       JOIN reading_lists l ON l.id = it.list_id
+                      WHERE it.added_at > $1
+                     UNION
+                     SELECT user_id FROM reading_lists WHERE updated_at > $1)
+               ORDER BY i.book_id`, since.Add(-activityOverlap),
+			)
+		}
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(dirty); start += batchSize {
+			end := start + batchSize
+			if end > len(dirty) {
+				end = len(dirty)
+			}
+			batch := pq.Int64Array(dirty[start:end])
+			if _, err := tx.ExecContext(ctx, `DELETE FROM book_similarities WHERE book_id = ANY($1)`, batch); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, similaritiesRecomputeSQL, batch, topN); err != nil {
+				return err
+			}
+		}
+		refreshed = len(dirty)
+		_, err = tx.ExecContext(ctx, `UPDATE recommendation_state SET refreshed_at = now()`)
+		return err
+	})
+	if err != nil {
+		logger.Error.Printf("repo.RefreshBookSimilarities: error: %v", err)
+		return 0, translateError(err)
+	}
+	logger.Info.Printf("repo.RefreshBookSimilarities: recomputed %d books", refreshed)
+	return refreshed, nil
+}
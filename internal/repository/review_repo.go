@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const reviewSelectSQL = `
+      SELECT r.id, r.book_id, r.user_id, u.username, r.rating, r.text, r.status, r.created_at, r.updated_at
+        FROM reviews r
+        JOIN users u ON u.id = r.user_id
+    `
+
+// refreshBookRating пересчитывает рейтинг книги по одобренным отзывам.
+// Строка book_ratings блокируется отдельным запросом: агрегат считается уже после
+// получения блокировки и видит отзывы, зафиксированные параллельными транзакциями.
+func refreshBookRating(ctx context.Context, tx *sqlx.Tx, bookID int) error {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO book_ratings (book_id) VALUES ($1) ON CONFLICT DO NOTHING`, bookID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM book_ratings WHERE book_id = $1 FOR UPDATE`, bookID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+      UPDATE book_ratings
+         SET (rating_count, rating_sum) = (
+             SELECT count(*), COALESCE(sum(rating), 0)
+               FROM reviews
+              WHERE book_id = $1 AND status = $2)
+       WHERE book_id = $1`, bookID, models.ReviewStatusApproved,
+	)
+	return err
+}
+
+// GetBookReviews возвращает отзывы о книге, новые сначала; скрытые — только при includeHidden.
+func GetBookReviews(ctx context.Context, bookID int, includeHidden bool) ([]models.Review, error) {
+	defer metrics.ObserveQuery("GetBookReviews")()
+	logger.Debug.Printf("repo.GetBookReviews: executing SELECT for book_id=%d include_hidden=%t", bookID, includeHidden)
+	reviews := []models.Review{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &reviews,
+		reviewSelectSQL+` WHERE r.book_id = $1 AND ($2 OR r.status = $3) ORDER BY r.created_at DESC, r.id DESC`,
+		bookID, includeHidden, models.ReviewStatusApproved,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBookReviews: query error book_id=%d: %v", bookID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetBookReviews: returned %d reviews for book_id=%d", len(reviews), bookID)
+	return reviews, nil
+}
+
+// GetReviewByID возвращает отзыв по ID.
+func GetReviewByID(ctx context.Context, reviewID int) (models.Review, error) {
+	defer metrics.ObserveQuery("GetReviewByID")()
+	logger.Debug.Printf("repo.GetReviewByID: executing SELECT for id=%d", reviewID)
+	var r models.Review
+	if err := db.GetReadConn(ctx).GetContext(ctx, &r, reviewSelectSQL+` WHERE r.id = $1`, reviewID); err != nil {
+		logger.Error.Printf("repo.GetReviewByID: query error id=%d: %v", reviewID, err)
+		return models.Review{}, translateError(err)
+	}
+	return r, nil
+}
+
+// CreateReview сохраняет отзыв, пересчитывает рейтинг книги и пишет событие аудита в той же транзакции.
+// Второй отзыв того же читателя на ту же книгу отклоняется.
+func CreateReview(ctx context.Context, r *models.Review, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("CreateReview")()
+	logger.Debug.Printf("repo.CreateReview: executing INSERT INTO reviews book_id=%d user_id=%d", r.BookID, r.UserID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists,
+			`SELECT EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)`, r.BookID,
+		); err != nil {
+			return err
+		}
+		if !exists {
+			return errs.ErrNotFound
+		}
+
+		var id int
+		err := tx.GetContext(ctx, &id, `
+          INSERT INTO reviews (book_id, user_id, rating, text) VALUES ($1, $2, $3, $4)
+          ON CONFLICT (book_id, user_id) DO NOTHING
+          RETURNING id`, r.BookID, r.UserID, r.Rating, r.Text,
+		)
+		if translateError(err) == errs.ErrNotFound {
+			return errs.ErrReviewExists
+		} else if err != nil {
+			return err
+		}
+		if err := refreshBookRating(ctx, tx, r.BookID); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, r, reviewSelectSQL+` WHERE r.id = $1`, id); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionCreate, models.AuditEntityReview, r.ID, nil, r)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.CreateReview: insert error book_id=%d user_id=%d: %v", r.BookID, r.UserID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreateReview: created review ID=%d book_id=%d", r.ID, r.BookID)
+	return nil
+}
+
+// UpdateReview меняет оценку и текст отзыва. Править можно только свой отзыв.
+func UpdateReview(ctx context.Context, r *models.Review, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("UpdateReview")()
+	logger.Debug.Printf("repo.UpdateReview: executing UPDATE reviews id=%d", r.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Review
+		if err := tx.GetContext(ctx, &before, reviewSelectSQL+` WHERE r.id = $1 FOR UPDATE OF r`, r.ID); err != nil {
+			return err
+		}
+		if before.UserID != r.UserID {
+			return errs.ErrNotReviewAuthor
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE reviews SET rating = $1, text = $2, updated_at = now() WHERE id = $3`, r.Rating, r.Text, r.ID,
+		); err != nil {
+			return err
+		}
+		if err := refreshBookRating(ctx, tx, before.BookID); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, r, reviewSelectSQL+` WHERE r.id = $1`, r.ID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityReview, r.ID, before, r)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.UpdateReview: update error id=%d: %v", r.ID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.UpdateReview: updated review ID=%d", r.ID)
+	return nil
+}
+
+// DeleteReview удаляет отзыв. Читатель может удалить только свой отзыв, администратор — любой.
+func DeleteReview(ctx context.Context, reviewID, userID int, asAdmin bool, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("DeleteReview")()
+	logger.Debug.Printf("repo.DeleteReview: executing DELETE FROM reviews id=%d", reviewID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Review
+		if err := tx.GetContext(ctx, &before, reviewSelectSQL+` WHERE r.id = $1 FOR UPDATE OF r`, reviewID); err != nil {
+			return err
+		}
+		if !asAdmin && before.UserID != userID {
+			return errs.ErrNotReviewAuthor
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM reviews WHERE id = $1`, reviewID); err != nil {
+			return err
+		}
+		if err := refreshBookRating(ctx, tx, before.BookID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionDelete, models.AuditEntityReview, reviewID, before, nil)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.DeleteReview: delete error id=%d: %v", reviewID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.DeleteReview: deleted review ID=%d", reviewID)
+	return nil
+}
+
+// SetReviewStatus скрывает или одобряет отзыв и пересчитывает рейтинг книги.
+func SetReviewStatus(ctx context.Context, reviewID int, status string, actor models.AuditActor) (models.Review, error) {
+	defer metrics.ObserveQuery("SetReviewStatus")()
+	logger.Debug.Printf("repo.SetReviewStatus: id=%d status=%q", reviewID, status)
+	var r models.Review
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Review
+		if err := tx.GetContext(ctx, &before, reviewSelectSQL+` WHERE r.id = $1 FOR UPDATE OF r`, reviewID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE reviews SET status = $1 WHERE id = $2`, status, reviewID); err != nil {
+			return err
+		}
+		if err := refreshBookRating(ctx, tx, before.BookID); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &r, reviewSelectSQL+` WHERE r.id = $1`, reviewID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityReview, reviewID, before, r)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.SetReviewStatus: error id=%d: %v", reviewID, err)
+		return models.Review{}, translateError(err)
+	}
+	logger.Info.Printf("repo.SetReviewStatus: review ID=%d is now %s", reviewID, status)
+	return r, nil
+}
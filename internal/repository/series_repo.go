@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const seriesColumns = `id, name, description`
+
+// seriesMembership — снимок членства книги в серии для журнала аудита.
+type seriesMembership struct {
+	BookID   int      `db:"book_id"`
+	Position *float64 `db:"position"`
+}
+
+// GetAllSeries возвращает все серии без состава.
+func GetAllSeries(ctx context.Context) ([]models.Series, error) {
+	defer metrics.ObserveQuery("GetAllSeries")()
+	logger.Debug.Println("repo.GetAllSeries: executing SELECT FROM series")
+	series := []models.Series{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &series, `SELECT `+seriesColumns+` FROM series ORDER BY name`); err != nil {
+		logger.Error.Printf("repo.GetAllSeries: query error: %v", err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetAllSeries: returned %d series", len(series))
+	return series, nil
+}
+
+// GetSeriesByID возвращает серию по ID без состава.
+func GetSeriesByID(ctx context.Context, seriesID int) (models.Series, error) {
+	defer metrics.ObserveQuery("GetSeriesByID")()
+	logger.Debug.Printf("repo.GetSeriesByID: executing SELECT FROM series WHERE id=%d", seriesID)
+	var s models.Series
+	if err := db.GetReadConn(ctx).GetContext(ctx, &s, `SELECT `+seriesColumns+` FROM series WHERE id = $1`, seriesID); err != nil {
+		logger.Error.Printf("repo.GetSeriesByID: query error id=%d: %v", seriesID, err)
+		return models.Series{}, translateError(err)
+	}
+	return s, nil
+}
+
+// GetSeriesBooks возвращает неудалённые книги серии в порядке чтения.
+func GetSeriesBooks(ctx context.Context, seriesID int) ([]models.SeriesBook, error) {
+	defer metrics.ObserveQuery("GetSeriesBooks")()
+	logger.Debug.Printf("repo.GetSeriesBooks: executing SELECT for series_id=%d", seriesID)
+	books := []models.SeriesBook{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &books, `
+      SELECT sb.position, q.*
+        FROM series_books sb
+        JOIN (`+bookSelectSQL+`) q ON q.id = sb.book_id
+       WHERE sb.series_id = $1
+         AND q.deleted_at IS NULL
+       ORDER BY sb.position`, seriesID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetSeriesBooks: query error series_id=%d: %v", seriesID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetSeriesBooks: returned %d books for series_id=%d", len(books), seriesID)
+	return books, nil
+}
+
+// GetNextInSeries возвращает для каждой серии книги следующую по порядку неудалённую книгу.
+func GetNextInSeries(ctx context.Context, bookID int) ([]models.SeriesLink, error) {
+	defer metrics.ObserveQuery("GetNextInSeries")()
+	logger.Debug.Printf("repo.GetNextInSeries: executing SELECT for book_id=%d", bookID)
+	links := []models.SeriesLink{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &links, `
+      SELECT s.id AS series_id, s.name AS series_name, nxt.position, nxt.book_id, nxt.title
+        FROM series_books cur
+        JOIN series s ON s.id = cur.series_id
+        JOIN LATERAL (
+             SELECT sb.book_id, sb.position, b.title
+               FROM series_books sb
+               JOIN books b ON b.id = sb.book_id
+              WHERE sb.series_id = cur.series_id
+                AND sb.position > cur.position
+                AND b.deleted_at IS NULL
+              ORDER BY sb.position
+              LIMIT 1
+        ) nxt ON true
+       WHERE cur.book_id = $1
+       ORDER BY s.name`, bookID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetNextInSeries: query error book_id=%d: %v", bookID, err)
+		return nil, translateError(err)
+	}
+	return links, nil
+}
+
+// CreateSeries добавляет серию и пишет событие аудита в той же транзакции.
+func CreateSeries(ctx context.Context, s *models.Series, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("CreateSeries")()
+	logger.Debug.Printf("repo.CreateSeries: executing INSERT INTO series name=%q", s.Name)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			`INSERT INTO series (name, description) VALUES ($1, $2) RETURNING id`, s.Name, s.Description,
+		).Scan(&s.ID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionCreate, models.AuditEntitySeries, s.ID, nil, s)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.CreateSeries: insert error name=%q: %v", s.Name, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreateSeries: created series ID=%d name=%q", s.ID, s.Name)
+	return nil
+}
+
+// UpdateSeries обновляет серию и пишет событие аудита в той же транзакции.
+func UpdateSeries(ctx context.Context, s *models.Series, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("UpdateSeries")()
+	logger.Debug.Printf("repo.UpdateSeries: executing UPDATE series id=%d", s.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Series
+		if err := tx.GetContext(ctx, &before, `SELECT `+seriesColumns+` FROM series WHERE id = $1 FOR UPDATE`, s.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE series SET name = $1, description = $2 WHERE id = $3`, s.Name, s.Description, s.ID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntitySeries, s.ID, before, s)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.UpdateSeries: update error id=%d: %v", s.ID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.UpdateSeries: updated series ID=%d", s.ID)
+	return nil
+}
+
+// DeleteSeriesByID удаляет серию; членство книг удаляется каскадно, сами книги не затрагиваются.
+func DeleteSeriesByID(ctx context.Context, seriesID int, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("DeleteSeriesByID")()
+	logger.Debug.Printf("repo.DeleteSeriesByID: executing DELETE FROM series WHERE id=%d", seriesID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Series
+		if err := tx.GetContext(ctx, &before, `SELECT `+seriesColumns+` FROM series WHERE id = $1 FOR UPDATE`, seriesID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM series WHERE id = $1`, seriesID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionDelete, models.AuditEntitySeries, seriesID, before, nil)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.DeleteSeriesByID: delete error id=%d: %v", seriesID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.DeleteSeriesByID: deleted series ID=%d", seriesID)
+	return nil
+}
+
+// SetSeriesBookPosition добавляет книгу в серию или переставляет её на новую позицию.
+// Две книги серии не могут занимать одну позицию.
+func SetSeriesBookPosition(ctx context.Context, seriesID, bookID int, position float64, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("SetSeriesBookPosition")()
+	logger.Debug.Printf("repo.SetSeriesBookPosition: series_id=%d book_id=%d position=%g", seriesID, bookID, position)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var locked int
+		if err := tx.GetContext(ctx, &locked, `SELECT id FROM series WHERE id = $1 FOR UPDATE`, seriesID); err != nil {
+			return err
+		}
+		var exists bool
+		if err := tx.GetContext(ctx, &exists,
+			`SELECT EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)`, bookID,
+		); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: unknown book", errs.ErrNotFound)
+		}
+
+		var taken bool
+		if err := tx.GetContext(ctx, &taken, `
+          SELECT EXISTS (SELECT 1 FROM series_books WHERE series_id = $1 AND position = $2 AND book_id <> $3)`,
+			seriesID, position, bookID,
+		); err != nil {
+			return err
+		}
+		if taken {
+			return errs.ErrSeriesPositionTaken
+		}
+
+		before := seriesMembership{BookID: bookID}
+		var old float64
+		switch err := tx.GetContext(ctx, &old, `SELECT position FROM series_books WHERE series_id = $1 AND book_id = $2`, seriesID, bookID); {
+		case err == nil:
+			before.Position = &old
+		case translateError(err) != errs.ErrNotFound:
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+          INSERT INTO series_books (series_id, book_id, position) VALUES ($1, $2, $3)
+          ON CONFLICT (series_id, book_id) DO UPDATE SET position = EXCLUDED.position`,
+			seriesID, bookID, position,
+		); err != nil {
+			return err
+		}
+		after := seriesMembership{BookID: bookID, Position: &position}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntitySeries, seriesID, before, after)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.SetSeriesBookPosition: error series_id=%d book_id=%d: %v", seriesID, bookID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.SetSeriesBookPosition: book_id=%d at position %g in series_id=%d", bookID, position, seriesID)
+	return nil
+}
+
+// RemoveBookFromSeries исключает книгу из серии.
+func RemoveBookFromSeries(ctx context.Context, seriesID, bookID int, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("RemoveBookFromSeries")()
+	logger.Debug.Printf("repo.RemoveBookFromSeries: series_id=%d book_id=%d", seriesID, bookID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before seriesMembership
+		if err := tx.GetContext(ctx, &before, `
+          DELETE FROM series_books WHERE series_id = $1 AND book_id = $2
+          RETURNING book_id, position`, seriesID, bookID,
+		); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntitySeries, seriesID, before, seriesMembership{BookID: bookID})
+	})
+	if err != nil {
+		logger.Error.Printf("repo.RemoveBookFromSeries: error series_id=%d book_id=%d: %v", seriesID, bookID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.RemoveBookFromSeries: removed book_id=%d from series_id=%d", bookID, seriesID)
+	return nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+
+	"Library/internal/db"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+)
+
+// GetLibraryStats считает книги, читателей и выдачи одним запросом.
+// Просроченная выдача — истёкшая, но ещё не закрытая задачей digital_loan_expiry.
+func GetLibraryStats(ctx context.Context) (models.LibraryStats, error) {
+	defer metrics.ObserveQuery("GetLibraryStats")()
+
+	var s models.LibraryStats
+	err := db.GetReadConn(ctx).GetContext(ctx, &s, `
+      SELECT (SELECT count(*) FROM books WHERE deleted_at IS NULL) AS books,
+             (SELECT count(*) FROM users WHERE deleted_at IS NULL) AS users,
+             count(*) FILTER (WHERE expires_at > now())            AS active_loans,
+             count(*) FILTER (WHERE expires_at <= now())           AS overdue_loans
+        FROM digital_loans
+       WHERE returned_at IS NULL
+    `)
+	if err != nil {
+		logger.Error.Printf("repo.GetLibraryStats: query error: %v", err)
+		return models.LibraryStats{}, translateError(err)
+	}
+	return s, nil
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// subjectTreeSQL обходит рубрикатор от корней рекурсивным CTE,
+// вычисляя глубину и полный путь каждого узла. Массив visited обрывает обход,
+// если в данных всё же окажется цикл.
+const subjectTreeSQL = `
+      WITH RECURSIVE tree AS (
+          SELECT id, name, parent_id, 0 AS depth, name::text AS path, ARRAY[id] AS visited
+            FROM subjects
+           WHERE parent_id IS NULL
+          UNION ALL
+          SELECT s.id, s.name, s.parent_id, t.depth + 1, t.path || ' / ' || s.name, t.visited || s.id
+            FROM subjects s
+            JOIN tree t ON s.parent_id = t.id
+           WHERE s.id <> ALL(t.visited)
+      )
+    `
+
+// subjectDescendantsSQL — узел $1 и все его потомки. UNION (а не UNION ALL) отбрасывает
+// уже найденные узлы, поэтому запрос завершается и на дереве с циклом.
+const subjectDescendantsSQL = `
+      WITH RECURSIVE sub AS (
+          SELECT id FROM subjects WHERE id = $1
+          UNION
+          SELECT s.id FROM subjects s JOIN sub ON s.parent_id = sub.id
+      )
+    `
+
+// subjectTreeLock — ключ advisory-блокировки, под которой рубрики переносятся между родителями.
+const subjectTreeLock int64 = 0x7375626a65637473 // "subjects"
+
+// GetAllSubjects возвращает весь рубрикатор в порядке обхода дерева.
+func GetAllSubjects(ctx context.Context) ([]models.Subject, error) {
+	defer metrics.ObserveQuery("GetAllSubjects")()
+	logger.Debug.Println("repo.GetAllSubjects: executing recursive SELECT FROM subjects")
+	subjects := []models.Subject{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &subjects,
+		subjectTreeSQL+`SELECT id, name, parent_id, depth, path FROM tree ORDER BY path`,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetAllSubjects: query error: %v", err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetAllSubjects: returned %d subjects", len(subjects))
+	return subjects, nil
+}
+
+// GetSubjectByID возвращает рубрику с глубиной и полным путём.
+func GetSubjectByID(ctx context.Context, subjectID int) (models.Subject, error) {
+	defer metrics.ObserveQuery("GetSubjectByID")()
+	logger.Debug.Printf("repo.GetSubjectByID: executing recursive SELECT for id=%d", subjectID)
+	var s models.Subject
+	err := db.GetReadConn(ctx).GetContext(ctx, &s,
+		subjectTreeSQL+`SELECT id, name, parent_id, depth, path FROM tree WHERE id = $1`, subjectID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetSubjectByID: query error id=%d: %v", subjectID, err)
+		return models.Subject{}, translateError(err)
+	}
+	return s, nil
+}
+
+// CreateSubject добавляет рубрику и пишет событие аудита в той же транзакции.
+func CreateSubject(ctx context.Context, s *models.Subject, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("CreateSubject")()
+	logger.Debug.Printf("repo.CreateSubject: executing INSERT INTO subjects name=%q parent=%v", s.Name, s.ParentID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			`INSERT INTO subjects (name, parent_id) VALUES ($1, $2) RETURNING id`, s.Name, s.ParentID,
+		).Scan(&s.ID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionCreate, models.AuditEntitySubject, s.ID, nil, s)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.CreateSubject: insert error name=%q: %v", s.Name, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreateSubject: created subject ID=%d name=%q", s.ID, s.Name)
+	return nil
+}
+
+// UpdateSubject переименовывает или переносит рубрику.
+// Новый родитель не может быть самой рубрикой или её потомком — иначе в дереве появится цикл.
+// Переносы выполняются по одному под общей advisory-блокировкой: блокировки одной своей строки
+// мало, два встречных переноса (A под B и B под A) иначе оба прошли бы проверку.
+func UpdateSubject(ctx context.Context, s *models.Subject, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("UpdateSubject")()
+	logger.Debug.Printf("repo.UpdateSubject: executing UPDATE subjects id=%d", s.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Subject
+		if err := tx.GetContext(ctx, &before, `SELECT id, name, parent_id FROM subjects WHERE id = $1 FOR UPDATE`, s.ID); err != nil {
+			return err
+		}
+		if s.ParentID != nil {
+			if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, subjectTreeLock); err != nil {
+				return err
+			}
+			var cycle bool
+			if err := tx.GetContext(ctx, &cycle,
+				subjectDescendantsSQL+`SELECT EXISTS (SELECT 1 FROM sub WHERE id = $2)`, s.ID, *s.ParentID,
+			); err != nil {
+				return err
+			}
+			if cycle {
+				return errs.ErrSubjectCycle
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE subjects SET name = $1, parent_id = $2 WHERE id = $3`, s.Name, s.ParentID, s.ID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntitySubject, s.ID, before, s)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.UpdateSubject: update error id=%d: %v", s.ID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.UpdateSubject: updated subject ID=%d", s.ID)
+	return nil
+}
+
+// DeleteSubjectByID удаляет рубрику без дочерних узлов; привязки к книгам удаляются каскадно.
+func DeleteSubjectByID(ctx context.Context, subjectID int, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("DeleteSubjectByID")()
+	logger.Debug.Printf("repo.DeleteSubjectByID: executing DELETE FROM subjects WHERE id=%d", subjectID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.Subject
+		if err := tx.GetContext(ctx, &before, `SELECT id, name, parent_id FROM subjects WHERE id = $1 FOR UPDATE`, subjectID); err != nil {
+			return err
+		}
+		var children int
+		if err := tx.GetContext(ctx, &children, `SELECT count(*) FROM subjects WHERE parent_id = $1`, subjectID); err != nil {
+			return err
+		}
+		if children > 0 {
+			return errs.ErrSubjectHasChildren
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM subjects WHERE id = $1`, subjectID); err != nil {
+			return err
+		}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionDelete, models.AuditEntitySubject, subjectID, before, nil)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.DeleteSubjectByID: delete error id=%d: %v", subjectID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.DeleteSubjectByID: deleted subject ID=%d", subjectID)
+	return nil
+}
+
+// GetBooksBySubject возвращает неудалённые книги рубрики и всех её потомков.
+func GetBooksBySubject(ctx context.Context, subjectID int) ([]models.Book, error) {
+	defer metrics.ObserveQuery("GetBooksBySubject")()
+	logger.Debug.Printf("repo.GetBooksBySubject: executing recursive SELECT for subject_id=%d", subjectID)
+	books := []models.Book{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &books, subjectDescendantsSQL+bookSelectSQL+`
+       WHERE b.deleted_at IS NULL
+         AND EXISTS (SELECT 1 FROM book_subjects bs JOIN sub ON sub.id = bs.subject_id WHERE bs.book_id = b.id)
+       ORDER BY b.dewey_class NULLS LAST, b.title`, subjectID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetBooksBySubject: query error subject_id=%d: %v", subjectID, err)
+		return nil, translateError(err)
+	}
+	logger.Info.Printf("repo.GetBooksBySubject: found %d books for subject_id=%d", len(books), subjectID)
+	return books, nil
+}
+
+// GetSubjectsByBookID возвращает рубрики, к которым отнесена книга.
+func GetSubjectsByBookID(ctx context.Context, bookID int) ([]models.Subject, error) {
+	defer metrics.ObserveQuery("GetSubjectsByBookID")()
+	logger.Debug.Printf("repo.GetSubjectsByBookID: executing SELECT for book_id=%d", bookID)
+	subjects := []models.Subject{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &subjects, subjectTreeSQL+`
+      SELECT t.id, t.name, t.parent_id, t.depth, t.path
+        FROM tree t
+        JOIN book_subjects bs ON bs.subject_id = t.id
+       WHERE bs.book_id = $1
+       ORDER BY t.path`, bookID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetSubjectsByBookID: query error book_id=%d: %v", bookID, err)
+		return nil, translateError(err)
+	}
+	return subjects, nil
+}
+
+// bookSubjectsSnapshot — снимок привязок книги к рубрикам для журнала аудита.
+type bookSubjectsSnapshot struct {
+	SubjectIDs pq.Int64Array `db:"subject_ids"`
+}
+
+// SetBookSubjects заменяет набор рубрик книги и пишет событие аудита в той же транзакции.
+func SetBookSubjects(ctx context.Context, bookID int, subjectIDs []int64, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("SetBookSubjects")()
+	logger.Debug.Printf("repo.SetBookSubjects: replacing subjects of book_id=%d with %v", bookID, subjectIDs)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var exists bool
+		if err := tx.GetContext(ctx, &exists,
+			`SELECT EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)`, bookID,
+		); err != nil {
+			return err
+		}
+		if !exists {
+			return errs.ErrNotFound
+		}
+
+		if len(subjectIDs) > 0 {
+			var found int
+			if err := tx.GetContext(ctx, &found,
+				`SELECT count(*) FROM subjects WHERE id = ANY($1)`, pq.Int64Array(subjectIDs),
+			); err != nil {
+				return err
+			}
+			if found != len(subjectIDs) {
+				return fmt.Errorf("%w: unknown subject id", errs.ErrValidationFailed)
+			}
+		}
+
+		var before bookSubjectsSnapshot
+		if err := tx.GetContext(ctx, &before.SubjectIDs,
+			`SELECT COALESCE(array_agg(subject_id ORDER BY subject_id), '{}') FROM book_subjects WHERE book_id = $1`, bookID,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM book_subjects WHERE book_id = $1`, bookID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+          INSERT INTO book_subjects (book_id, subject_id)
+          SELECT $1, unnest($2::int[])
+          ON CONFLICT DO NOTHING`, bookID, pq.Int64Array(subjectIDs),
+		); err != nil {
+			return err
+		}
+		after := bookSubjectsSnapshot{SubjectIDs: subjectIDs}
+		return insertAuditEvent(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityBook, bookID, before, after)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.SetBookSubjects: error book_id=%d: %v", bookID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.SetBookSubjects: book_id=%d now has %d subjects", bookID, len(subjectIDs))
+	return nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+)
+
+const taskColumns = `id, kind, payload, status, attempts, max_attempts, run_at, locked_until,
+       last_error, idempotency_key, created_at, updated_at, finished_at`
+
+// EnqueueTask ставит задачу в очередь. Если задача с тем же ключом идемпотентности уже есть,
+// возвращается она, а created == false.
+func EnqueueTask(ctx context.Context, kind string, payload []byte, maxAttempts int, runAt time.Time, key *string) (task models.Task, created bool, err error) {
+	defer metrics.ObserveQuery("EnqueueTask")()
+	err = db.GetDBConn().GetContext(ctx, &task, `
+      INSERT INTO tasks (kind, payload, max_attempts, run_at, idempotency_key)
+      VALUES ($1, $2, $3, $4, $5)
+      ON CONFLICT (idempotency_key) DO NOTHING
+      RETURNING `+taskColumns, kind, payload, maxAttempts, runAt, key,
+	)
+	if err == nil {
+		logger.Debug.Printf("repo.EnqueueTask: task ID=%d kind=%s", task.ID, kind)
+		return task, true, nil
+	}
+	if translateError(err) != errs.ErrNotFound || key == nil {
+		logger.Error.Printf("repo.EnqueueTask: insert error kind=%s: %v", kind, err)
+		return models.Task{}, false, err
+	}
+
+	if err := db.GetDBConn().GetContext(ctx, &task, `SELECT `+taskColumns+` FROM tasks WHERE idempotency_key = $1`, *key); err != nil {
+		logger.Error.Printf("repo.EnqueueTask: select by key error kind=%s: %v", kind, err)
+		return models.Task{}, false, translateError(err)
+	}
+	logger.Debug.Printf("repo.EnqueueTask: duplicate key, existing task ID=%d", task.ID)
+	return task, false, nil
+}
+
+// ClaimTasks забирает до limit готовых задач и задач, чьи обработчики не уложились в lockTimeout.
+// SKIP LOCKED позволяет нескольким обработчикам и экземплярам разбирать очередь параллельно.
+func ClaimTasks(ctx context.Context, limit int, lockTimeout time.Duration) ([]models.Task, error) {
+	defer metrics.ObserveQuery("ClaimTasks")()
+	tasks := []models.Task{}
+	err := db.GetDBConn().SelectContext(ctx, &tasks, `
+      UPDATE tasks t
+         SET status = 'running', attempts = t.attempts + 1, updated_at = now(),
+             locked_until = now() + $2::float8 * interval '1 second'
+        FROM (SELECT id
+                FROM tasks
+               WHERE (status = 'queued' AND run_at <= now())
+                  OR (status = 'running' AND locked_until < now())
+               ORDER BY run_at, id
+               LIMIT $1
+                 FOR UPDATE SKIP LOCKED) c
+       WHERE t.id = c.id
+      RETURNING t.id, t.kind, t.payload, t.status, t.attempts, t.max_attempts, t.run_at, t.locked_until,
+                t.last_error, t.idempotency_key, t.created_at, t.updated_at, t.finished_at`,
+		limit, int(lockTimeout/time.Second),
+	)
+	if err != nil {
+		logger.Error.Printf("repo.ClaimTasks: error: %v", err)
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// Завершающие операции проверяют номер попытки: если задачу уже забрал другой обработчик
+// после истечения блокировки, устаревший результат не затрёт его состояние.
+
+// CompleteTask отмечает задачу выполненной.
+func CompleteTask(ctx context.Context, id int64, attempt int) error {
+	defer metrics.ObserveQuery("CompleteTask")()
+	_, err := db.GetDBConn().ExecContext(ctx, `
+      UPDATE tasks
+         SET status = 'succeeded', locked_until = NULL, last_error = '', finished_at = now(), updated_at = now()
+       WHERE id = $1 AND status = 'running' AND attempts = $2`, id, attempt,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.CompleteTask: update error ID=%d: %v", id, err)
+	}
+	return err
+}
+
+// RetryTaskLater возвращает задачу в очередь с отложенным запуском.
+func RetryTaskLater(ctx context.Context, id int64, attempt int, errText string, runAt time.Time) error {
+	defer metrics.ObserveQuery("RetryTaskLater")()
+	_, err := db.GetDBConn().ExecContext(ctx, `
+      UPDATE tasks
+         SET status = 'queued', locked_until = NULL, last_error = $3, run_at = $4, updated_at = now()
+       WHERE id = $1 AND status = 'running' AND attempts = $2`, id, attempt, errText, runAt,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.RetryTaskLater: update error ID=%d: %v", id, err)
+	}
+	return err
+}
+
+// KillTask переводит задачу в dead: повторов больше не будет до ручного вмешательства.
+func KillTask(ctx context.Context, id int64, attempt int, errText string) error {
+	defer metrics.ObserveQuery("KillTask")()
+	_, err := db.GetDBConn().ExecContext(ctx, `
+      UPDATE tasks
+         SET status = 'dead', locked_until = NULL, last_error = $3, finished_at = now(), updated_at = now()
+       WHERE id = $1 AND status = 'running' AND attempts = $2`, id, attempt, errText,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.KillTask: update error ID=%d: %v", id, err)
+	}
+	return err
+}
+
+// RequeueDeadTask возвращает задачу из dead в очередь с обнулённым счётчиком попыток.
+func RequeueDeadTask(ctx context.Context, id int64) (models.Task, error) {
+	defer metrics.ObserveQuery("RequeueDeadTask")()
+	var task models.Task
+	err := db.GetDBConn().GetContext(ctx, &task, `
+      UPDATE tasks
+         SET status = 'queued', attempts = 0, run_at = now(), finished_at = NULL, updated_at = now()
+       WHERE id = $1 AND status = 'dead'
+      RETURNING `+taskColumns, id,
+	)
+	if err == nil {
+		logger.Info.Printf("repo.RequeueDeadTask: task ID=%d requeued", id)
+		return task, nil
+	}
+	if translateError(err) != errs.ErrNotFound {
+		logger.Error.Printf("repo.RequeueDeadTask: update error ID=%d: %v", id, err)
+		return models.Task{}, err
+	}
+	if _, err := GetTaskByID(ctx, id); err != nil {
+		return models.Task{}, err
+	}
+	return models.Task{}, errs.ErrTaskNotRetryable
+}
+
+// GetTaskByID возвращает задачу по ID.
+func GetTaskByID(ctx context.Context, id int64) (models.Task, error) {
+	defer metrics.ObserveQuery("GetTaskByID")()
+	var task models.Task
+	if err := db.GetReadConn(ctx).GetContext(ctx, &task, `SELECT `+taskColumns+` FROM tasks WHERE id = $1`, id); err != nil {
+		logger.Error.Printf("repo.GetTaskByID: query error ID=%d: %v", id, err)
+		return models.Task{}, translateError(err)
+	}
+	return task, nil
+}
+
+// GetTasks возвращает задачи по фильтру (новые сверху).
+func GetTasks(ctx context.Context, f models.TaskFilter) ([]models.Task, error) {
+	defer metrics.ObserveQuery("GetTasks")()
+	logger.Debug.Printf("repo.GetTasks: start filter=%+v", f)
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	if f.Status != "" {
+		args = append(args, f.Status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if f.Kind != "" {
+		args = append(args, f.Kind)
+		where = append(where, fmt.Sprintf("kind = $%d", len(args)))
+	}
+
+	query := `SELECT ` + taskColumns + ` FROM tasks`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit, f.Offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	tasks := []models.Task{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &tasks, query, args...); err != nil {
+		logger.Error.Printf("repo.GetTasks: query error: %v", err)
+		return nil, translateError(err)
+	}
+	return tasks, nil
+}
+
+// GetTaskStats считает задачи по видам и статусам.
+func GetTaskStats(ctx context.Context) ([]models.TaskStats, error) {
+	defer metrics.ObserveQuery("GetTaskStats")()
+	stats := []models.TaskStats{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &stats, `
+      SELECT kind, status, count(*) AS count
+        FROM tasks
+       GROUP BY kind, status
+       ORDER BY kind, status`,
+	); err != nil {
+		logger.Error.Printf("repo.GetTaskStats: query error: %v", err)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// PurgeFinishedTasks удаляет выполненные задачи старше olderThan; dead остаются для разбора.
+func PurgeFinishedTasks(ctx context.Context, olderThan time.Time) (int64, error) {
+	defer metrics.ObserveQuery("PurgeFinishedTasks")()
+	res, err := db.GetDBConn().ExecContext(ctx, `DELETE FROM tasks WHERE status = 'succeeded' AND finished_at < $1`, olderThan)
+	if err != nil {
+		logger.Error.Printf("repo.PurgeFinishedTasks: delete error: %v", err)
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -2,17 +2,25 @@ package repository
 
 import (
 	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
 	"Library/internal/models"
 	"Library/logger"
+	"context"
+
+	"github.com/jmoiron/sqlx"
 )
 
-// GetallUsers возвращает всех пользователей.
-func GetallUsers() ([]models.User, error) {
-	logger.Debug.Println("repo.GetallUsers: executing SELECT id, username, email, role FROM users")
+const userColumns = `id, username, email, role, version, deleted_at`
+
+// GetallUsers возвращает всех пользователей; удалённых — только при includeDeleted.
+func GetallUsers(ctx context.Context, includeDeleted bool) ([]models.User, error) {
+	defer metrics.ObserveQuery("GetallUsers")()
+	logger.Debug.Printf("repo.GetallUsers: executing SELECT FROM users include_deleted=%t", includeDeleted)
 
 	var users []models.User
-	err := db.GetDBConn().Select(&users,
-		`SELECT id, username, email, role FROM users`,
+	err := db.GetReadConn(ctx).SelectContext(ctx, &users,
+		`SELECT `+userColumns+` FROM users WHERE ($1 OR deleted_at IS NULL) ORDER BY id`, includeDeleted,
 	)
 	if err != nil {
 		logger.Error.Printf("repo.GetallUsers: query error: %v", err)
@@ -22,13 +30,15 @@ func GetallUsers() ([]models.User, error) {
 	return users, nil
 }
 
-// GetUserByID возвращает пользователя по ID.
-func GetUserByID(userID int) (models.User, error) {
-	logger.Debug.Printf("repo.GetUserByID: executing SELECT id, username, email, role FROM users WHERE id=%d", userID)
+// GetUserByID возвращает пользователя по ID; удалённого — только при includeDeleted.
+// Читает с основной базы: по пользователю проверяются права, отставание реплики тут недопустимо.
+func GetUserByID(ctx context.Context, userID int, includeDeleted bool) (models.User, error) {
+	defer metrics.ObserveQuery("GetUserByID")()
+	logger.Debug.Printf("repo.GetUserByID: executing SELECT FROM users WHERE id=%d include_deleted=%t", userID, includeDeleted)
 
 	var user models.User
-	err := db.GetDBConn().Get(&user,
-		`SELECT id, username, email, role FROM users WHERE id = $1`, userID,
+	err := db.GetDBConn().GetContext(ctx, &user,
+		`SELECT `+userColumns+` FROM users WHERE id = $1 AND ($2 OR deleted_at IS NULL)`, userID, includeDeleted,
 	)
 	if err != nil {
 		logger.Error.Printf("repo.GetUserByID: query error id=%d: %v", userID, err)
@@ -38,16 +48,39 @@ func GetUserByID(userID int) (models.User, error) {
 	return user, nil
 }
 
-// CreateUser сохраняет нового пользователя.
-func CreateUser(user *models.User) error {
+const userByIDSQL = `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+
+// IsActiveUser сообщает, существует ли пользователь и не удалён ли он.
+// Проверяется на каждый запрос с токеном, поэтому читает с основной базы и только флаг.
+func IsActiveUser(ctx context.Context, userID int) (bool, error) {
+	defer metrics.ObserveQuery("IsActiveUser")()
+	var active bool
+	err := db.GetDBConn().GetContext(ctx, &active,
+		`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`, userID,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.IsActiveUser: query error id=%d: %v", userID, err)
+		return false, translateError(err)
+	}
+	return active, nil
+}
+
+// CreateUser сохраняет нового пользователя и пишет событие аудита в той же транзакции.
+func CreateUser(ctx context.Context, user *models.User, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("CreateUser")()
 	logger.Debug.Printf(
 		"repo.CreateUser: executing INSERT INTO users (username, email, password) VALUES (%q, %q, ****)",
 		user.Username, user.Email,
 	)
-	err := db.GetDBConn().QueryRow(
-		`INSERT INTO users (username, email, password) VALUES ($1, $2, $3) RETURNING id`,
-		user.Username, user.Email, user.Password,
-	).Scan(&user.ID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := tx.QueryRowContext(ctx,
+			`INSERT INTO users (username, email, password) VALUES ($1, $2, $3) RETURNING id, role, version`,
+			user.Username, user.Email, user.Password,
+		).Scan(&user.ID, &user.Role, &user.Version); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionCreate, models.AuditEntityUser, user.ID, nil, user)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.CreateUser: insert error username=%q: %v", user.Username, err)
 		return translateError(err)
@@ -56,16 +89,37 @@ func CreateUser(user *models.User) error {
 	return nil
 }
 
-// UpdateUser обновляет данные пользователя.
-func UpdateUser(user *models.User) error {
+// UpdateUser обновляет данные пользователя и пишет событие аудита в той же транзакции.
+// user.Version — ожидаемая версия строки (0 — без проверки).
+func UpdateUser(ctx context.Context, user *models.User, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("UpdateUser")()
 	logger.Debug.Printf(
 		"repo.UpdateUser: executing UPDATE users SET username=%q, email=%q, role=%q WHERE id=%d",
 		user.Username, user.Email, user.Role, user.ID,
 	)
-	_, err := db.GetDBConn().Exec(
-		`UPDATE users SET username = $1, email = $2, role = $3 WHERE id = $4`,
-		user.Username, user.Email, user.Role, user.ID,
-	)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.User
+		if err := tx.GetContext(ctx, &before, userByIDSQL+" AND deleted_at IS NULL FOR UPDATE", user.ID); err != nil {
+			return err
+		}
+		if user.Version != 0 && user.Version != before.Version {
+			return errs.ErrVersionMismatch
+		}
+		res, err := tx.ExecContext(ctx, `
+          UPDATE users
+             SET username = $1, email = $2, role = $3, version = version + 1
+           WHERE id = $4 AND version = $5 AND deleted_at IS NULL`,
+			user.Username, user.Email, user.Role, user.ID, before.Version,
+		)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrNotFound
+		}
+		user.Version = before.Version + 1
+		return recordChange(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityUser, user.ID, before, user)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.UpdateUser: exec error id=%d: %v", user.ID, err)
 		return translateError(err)
@@ -74,12 +128,20 @@ func UpdateUser(user *models.User) error {
 	return nil
 }
 
-// DeleteUserByID удаляет пользователя по ID.
-func DeleteUserByID(userID int) error {
-	logger.Debug.Printf("repo.DeleteUserByID: executing DELETE FROM users WHERE id=%d", userID)
-	_, err := db.GetDBConn().Exec(
-		`DELETE FROM users WHERE id = $1`, userID,
-	)
+// DeleteUserByID помечает пользователя удалённым (soft delete) и пишет событие аудита в той же транзакции.
+func DeleteUserByID(ctx context.Context, userID int, actor models.AuditActor) error {
+	defer metrics.ObserveQuery("DeleteUserByID")()
+	logger.Debug.Printf("repo.DeleteUserByID: executing UPDATE users SET deleted_at=now() WHERE id=%d", userID)
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.User
+		if err := tx.GetContext(ctx, &before, userByIDSQL+" AND deleted_at IS NULL FOR UPDATE", userID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET deleted_at = now(), version = version + 1 WHERE id = $1`, userID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionDelete, models.AuditEntityUser, userID, before, nil)
+	})
 	if err != nil {
 		logger.Error.Printf("repo.DeleteUserByID: delete error id=%d: %v", userID, err)
 		return translateError(err)
@@ -88,15 +150,17 @@ func DeleteUserByID(userID int) error {
 	return nil
 }
 
-// GetUserByUsername возвращает пользователя по username (для аутентификации).
-func GetUserByUsername(username string) (*models.User, error) {
+// GetUserByUsername возвращает неудалённого пользователя по username (для аутентификации).
+func GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	defer metrics.ObserveQuery("GetUserByUsername")()
 	logger.Debug.Printf("repo.GetUserByUsername: executing SELECT id, username, email, password, role FROM users WHERE username=%q", username)
 
 	var u models.User
-	err := db.GetDBConn().Get(&u,
+	err := db.GetDBConn().GetContext(ctx, &u,
 		`SELECT id, username, email, password, role
            FROM users
-          WHERE username = $1`, username,
+          WHERE username = $1
+            AND deleted_at IS NULL`, username,
 	)
 	if err != nil {
 		logger.Error.Printf("repo.GetUserByUsername: query error username=%q: %v", username, err)
@@ -105,3 +169,66 @@ func GetUserByUsername(username string) (*models.User, error) {
 	logger.Info.Printf("repo.GetUserByUsername: found user ID=%d username=%q", u.ID, u.Username)
 	return &u, nil
 }
+
+// RestoreUserByID снимает пометку об удалении и пишет событие аудита в той же транзакции.
+func RestoreUserByID(ctx context.Context, userID int, actor models.AuditActor) (models.User, error) {
+	defer metrics.ObserveQuery("RestoreUserByID")()
+	logger.Debug.Printf("repo.RestoreUserByID: executing UPDATE users SET deleted_at=NULL WHERE id=%d", userID)
+	var user models.User
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.User
+		if err := tx.GetContext(ctx, &before, userByIDSQL+" AND deleted_at IS NOT NULL FOR UPDATE", userID); err != nil {
+			return err
+		}
+		if err := tx.GetContext(ctx, &user,
+			`UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id = $1 RETURNING `+userColumns, userID,
+		); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionRestore, models.AuditEntityUser, userID, before, user)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.RestoreUserByID: restore error id=%d: %v", userID, err)
+		return models.User{}, translateError(err)
+	}
+	logger.Info.Printf("repo.RestoreUserByID: restored user ID=%d", userID)
+	return user, nil
+}
+
+// PatchUser обновляет только переданные колонки пользователя (merge patch) и пишет событие аудита.
+// version — ожидаемая версия строки (0 — без проверки). Пароль должен быть уже захеширован.
+func PatchUser(ctx context.Context, userID, version int, fields map[string]interface{}, actor models.AuditActor) (models.User, error) {
+	defer metrics.ObserveQuery("PatchUser")()
+	logger.Debug.Printf("repo.PatchUser: patching user id=%d fields=%d", userID, len(fields))
+	var user models.User
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		var before models.User
+		if err := tx.GetContext(ctx, &before, userByIDSQL+" AND deleted_at IS NULL FOR UPDATE", userID); err != nil {
+			return err
+		}
+		if version != 0 && version != before.Version {
+			return errs.ErrVersionMismatch
+		}
+		query, args, err := buildPatchUpdate("users", userPatchColumns, fields, userID, before.Version)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errs.ErrNotFound
+		}
+		if err := tx.GetContext(ctx, &user, userByIDSQL, userID); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, actor, models.AuditActionUpdate, models.AuditEntityUser, userID, before, user)
+	})
+	if err != nil {
+		logger.Error.Printf("repo.PatchUser: patch error id=%d: %v", userID, err)
+		return models.User{}, translateError(err)
+	}
+	logger.Info.Printf("repo.PatchUser: patched user ID=%d version=%d", userID, user.Version)
+	return user, nil
+}
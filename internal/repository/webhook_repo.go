@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/metrics"
+	"Library/internal/models"
+	"Library/logger"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const webhookSubscriptionColumns = `id, url, secret, event_types, description, active, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts,
+       response_status, error, duration_ms, created_at, updated_at`
+
+// GetWebhookSubscriptions возвращает все подписки.
+func GetWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	defer metrics.ObserveQuery("GetWebhookSubscriptions")()
+	subs := []models.WebhookSubscription{}
+	if err := db.GetReadConn(ctx).SelectContext(ctx, &subs, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY id`); err != nil {
+		logger.Error.Printf("repo.GetWebhookSubscriptions: query error: %v", err)
+		return nil, translateError(err)
+	}
+	return subs, nil
+}
+
+// GetWebhookSubscriptionByID возвращает подписку по ID. Читает с основной базы: от неё зависят
+// доставка и проверки перед изменениями.
+func GetWebhookSubscriptionByID(ctx context.Context, id int) (models.WebhookSubscription, error) {
+	defer metrics.ObserveQuery("GetWebhookSubscriptionByID")()
+	var s models.WebhookSubscription
+	if err := db.GetDBConn().GetContext(ctx, &s, `SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id); err != nil {
+		logger.Error.Printf("repo.GetWebhookSubscriptionByID: query error id=%d: %v", id, err)
+		return models.WebhookSubscription{}, translateError(err)
+	}
+	return s, nil
+}
+
+// CreateWebhookSubscription добавляет подписку.
+func CreateWebhookSubscription(ctx context.Context, s *models.WebhookSubscription) error {
+	defer metrics.ObserveQuery("CreateWebhookSubscription")()
+	err := db.GetDBConn().GetContext(ctx, s, `
+      INSERT INTO webhook_subscriptions (url, secret, event_types, description, active)
+      VALUES ($1, $2, $3, $4, $5)
+      RETURNING `+webhookSubscriptionColumns,
+		s.URL, s.Secret, pq.StringArray(s.EventTypes), s.Description, s.Active,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.CreateWebhookSubscription: insert error: %v", err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.CreateWebhookSubscription: created subscription ID=%d url=%s", s.ID, s.URL)
+	return nil
+}
+
+// UpdateWebhookSubscription сохраняет изменения подписки.
+func UpdateWebhookSubscription(ctx context.Context, s *models.WebhookSubscription) error {
+	defer metrics.ObserveQuery("UpdateWebhookSubscription")()
+	err := db.GetDBConn().GetContext(ctx, s, `
+      UPDATE webhook_subscriptions
+         SET url = $2, secret = $3, event_types = $4, description = $5, active = $6, updated_at = now()
+       WHERE id = $1
+      RETURNING `+webhookSubscriptionColumns,
+		s.ID, s.URL, s.Secret, pq.StringArray(s.EventTypes), s.Description, s.Active,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.UpdateWebhookSubscription: update error id=%d: %v", s.ID, err)
+		return translateError(err)
+	}
+	logger.Info.Printf("repo.UpdateWebhookSubscription: updated subscription ID=%d", s.ID)
+	return nil
+}
+
+// DeleteWebhookSubscription удаляет подписку вместе с журналом её доставок.
+func DeleteWebhookSubscription(ctx context.Context, id int) error {
+	defer metrics.ObserveQuery("DeleteWebhookSubscription")()
+	res, err := db.GetDBConn().ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		logger.Error.Printf("repo.DeleteWebhookSubscription: delete error id=%d: %v", id, err)
+		return translateError(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrNotFound
+	}
+	logger.Info.Printf("repo.DeleteWebhookSubscription: deleted subscription ID=%d", id)
+	return nil
+}
+
+// CreateWebhookDelivery заводит доставку вне outbox (проверочный ping).
+func CreateWebhookDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	defer metrics.ObserveQuery("CreateWebhookDelivery")()
+	err := db.GetDBConn().GetContext(ctx, d, `
+      INSERT INTO webhook_deliveries (subscription_id, event_type, payload)
+      VALUES ($1, $2, $3)
+      RETURNING `+webhookDeliveryColumns,
+		d.SubscriptionID, d.EventType, d.Payload,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.CreateWebhookDelivery: insert error subscription_id=%d: %v", d.SubscriptionID, err)
+		return translateError(err)
+	}
+	return nil
+}
+
+// GetWebhookDeliveryByID возвращает доставку по ID.
+func GetWebhookDeliveryByID(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("GetWebhookDeliveryByID")()
+	var d models.WebhookDelivery
+	if err := db.GetDBConn().GetContext(ctx, &d, `SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id); err != nil {
+		logger.Error.Printf("repo.GetWebhookDeliveryByID: query error id=%d: %v", id, err)
+		return models.WebhookDelivery{}, translateError(err)
+	}
+	return d, nil
+}
+
+// GetWebhookDeliveries возвращает журнал доставок подписки (новые сверху).
+func GetWebhookDeliveries(ctx context.Context, subscriptionID, limit, offset int) ([]models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("GetWebhookDeliveries")()
+	deliveries := []models.WebhookDelivery{}
+	err := db.GetReadConn(ctx).SelectContext(ctx, &deliveries, `
+      SELECT `+webhookDeliveryColumns+`
+        FROM webhook_deliveries
+       WHERE subscription_id = $1
+       ORDER BY id DESC
+       LIMIT $2 OFFSET $3`, subscriptionID, limit, offset,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.GetWebhookDeliveries: query error subscription_id=%d: %v", subscriptionID, err)
+		return nil, translateError(err)
+	}
+	return deliveries, nil
+}
+
+// RecordWebhookAttempt сохраняет результат очередной попытки доставки.
+func RecordWebhookAttempt(ctx context.Context, id int64, a models.WebhookAttempt) (models.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("RecordWebhookAttempt")()
+	var d models.WebhookDelivery
+	err := db.GetDBConn().GetContext(ctx, &d, `
+      UPDATE webhook_deliveries
+         SET status = $2, attempts = attempts + 1, response_status = $3,
+             error = $4, duration_ms = $5, updated_at = now()
+       WHERE id = $1
+      RETURNING `+webhookDeliveryColumns,
+		id, a.Status, a.ResponseStatus, a.Error, a.DurationMS,
+	)
+	if err != nil {
+		logger.Error.Printf("repo.RecordWebhookAttempt: update error id=%d: %v", id, err)
+		return models.WebhookDelivery{}, translateError(err)
+	}
+	return d, nil
+}
+
+// PurgeWebhookHistory удаляет разосланные события outbox и журнал доставок старше olderThan.
+func PurgeWebhookHistory(ctx context.Context, olderThan time.Time) (int64, error) {
+	defer metrics.ObserveQuery("PurgeWebhookHistory")()
+	var total int64
+	err := db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		for _, q := range []string{
+			`DELETE FROM outbox_events WHERE dispatched_at < $1`,
+			`DELETE FROM webhook_deliveries WHERE updated_at < $1 AND status <> 'pending'`,
+		} {
+			res, err := tx.ExecContext(ctx, q, olderThan)
+			if err != nil {
+				logger.Error.Printf("repo.PurgeWebhookHistory: delete error: %v", err)
+				return err
+			}
+			n, _ := res.RowsAffected()
+			total += n
+		}
+		return nil
+	})
+	return total, err
+}
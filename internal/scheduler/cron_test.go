@@ -0,0 +1,188 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+		wantErr  bool
+	}{
+		{field: "*", min: 0, max: 5, want: []int{0, 1, 2, 3, 4, 5}},
+		{field: "3", min: 0, max: 59, want: []int{3}},
+		{field: "0", min: 0, max: 59, want: []int{0}},
+		{field: "59", min: 0, max: 59, want: []int{59}},
+		{field: "1,5,9", min: 0, max: 59, want: []int{1, 5, 9}},
+		{field: "10-13", min: 0, max: 59, want: []int{10, 11, 12, 13}},
+		{field: "*/15", min: 0, max: 59, want: []int{0, 15, 30, 45}},
+		{field: "5/20", min: 0, max: 59, want: []int{5, 25, 45}},
+		{field: "10-20/5", min: 0, max: 59, want: []int{10, 15, 20}},
+		{field: "1-3,20-22/2", min: 0, max: 23, want: []int{1, 2, 3, 20, 22}},
+		{field: "*/10", min: 1, max: 31, want: []int{1, 11, 21, 31}},
+		{field: "1,1,1", min: 1, max: 12, want: []int{1}},
+		{field: "7", min: 0, max: 7, want: []int{7}},
+
+		{field: "60", min: 0, max: 59, wantErr: true},
+		{field: "0", min: 1, max: 31, wantErr: true},
+		{field: "13", min: 1, max: 12, wantErr: true},
+		{field: "-1", min: 0, max: 59, wantErr: true},
+		{field: "5-3", min: 0, max: 59, wantErr: true},
+		{field: "1-60", min: 0, max: 59, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "*/x", min: 0, max: 59, wantErr: true},
+		{field: "a", min: 0, max: 59, wantErr: true},
+		{field: "1-b", min: 0, max: 59, wantErr: true},
+		{field: "", min: 0, max: 59, wantErr: true},
+		{field: "1,", min: 0, max: 59, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCronField(%q, %d, %d) = %b, want error", tt.field, tt.min, tt.max, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCronField(%q, %d, %d): unexpected error %v", tt.field, tt.min, tt.max, err)
+			continue
+		}
+		var want uint64
+		for _, v := range tt.want {
+			want |= 1 << uint(v)
+		}
+		if got != want {
+			t.Errorf("parseCronField(%q, %d, %d) = %b, want %b", tt.field, tt.min, tt.max, got, want)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * * 8",
+		"@every",
+		"@every 0s",
+		"@every 500ms",
+		"@every soon",
+		"@fortnightly",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q): expected error", spec)
+		}
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+func TestScheduleNext(t *testing.T) {
+	utc := time.UTC
+	ny := mustLoadLocation(t, "America/New_York")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *",
+			time.Date(2024, 5, 1, 10, 0, 30, 0, utc), time.Date(2024, 5, 1, 10, 1, 0, 0, utc)},
+		{"strictly after exact match", "0 * * * *",
+			time.Date(2024, 5, 1, 10, 0, 0, 0, utc), time.Date(2024, 5, 1, 11, 0, 0, 0, utc)},
+		{"minute step", "*/15 * * * *",
+			time.Date(2024, 5, 1, 10, 16, 0, 0, utc), time.Date(2024, 5, 1, 10, 30, 0, 0, utc)},
+		{"minute step wraps hour", "*/15 * * * *",
+			time.Date(2024, 5, 1, 10, 45, 0, 0, utc), time.Date(2024, 5, 1, 11, 0, 0, 0, utc)},
+		{"hour list", "30 3,15 * * *",
+			time.Date(2024, 5, 1, 4, 0, 0, 0, utc), time.Date(2024, 5, 1, 15, 30, 0, 0, utc)},
+		{"hour range wraps day", "0 9-17 * * *",
+			time.Date(2024, 5, 1, 17, 0, 0, 0, utc), time.Date(2024, 5, 2, 9, 0, 0, 0, utc)},
+		{"daily macro", "@daily",
+			time.Date(2024, 12, 31, 23, 59, 0, 0, utc), time.Date(2025, 1, 1, 0, 0, 0, 0, utc)},
+		{"hourly macro", "@hourly",
+			time.Date(2024, 5, 1, 10, 5, 0, 0, utc), time.Date(2024, 5, 1, 11, 0, 0, 0, utc)},
+		{"weekly macro is Sunday", "@weekly",
+			time.Date(2024, 5, 1, 0, 0, 0, 0, utc), time.Date(2024, 5, 5, 0, 0, 0, 0, utc)},
+		{"monthly macro", "@monthly",
+			time.Date(2024, 1, 31, 12, 0, 0, 0, utc), time.Date(2024, 2, 1, 0, 0, 0, 0, utc)},
+		{"yearly macro", "@yearly",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, utc), time.Date(2025, 1, 1, 0, 0, 0, 0, utc)},
+
+		{"day 31 skips short months", "0 0 31 * *",
+			time.Date(2024, 4, 1, 0, 0, 0, 0, utc), time.Date(2024, 5, 31, 0, 0, 0, 0, utc)},
+		{"Feb 29 in leap year", "0 0 29 2 *",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, utc), time.Date(2024, 2, 29, 0, 0, 0, 0, utc)},
+		{"Feb 29 waits for next leap year", "0 0 29 2 *",
+			time.Date(2024, 3, 1, 0, 0, 0, 0, utc), time.Date(2028, 2, 29, 0, 0, 0, 0, utc)},
+		{"month list wraps year", "0 0 1 1,7 *",
+			time.Date(2024, 7, 1, 0, 0, 0, 0, utc), time.Date(2025, 1, 1, 0, 0, 0, 0, utc)},
+		{"month step", "0 0 1 */3 *",
+			time.Date(2024, 2, 15, 0, 0, 0, 0, utc), time.Date(2024, 4, 1, 0, 0, 0, 0, utc)},
+		{"impossible date gives zero time", "0 0 30 2 *",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, utc), time.Time{}},
+
+		{"weekday range", "0 8 * * 1-5",
+			time.Date(2024, 5, 3, 9, 0, 0, 0, utc), time.Date(2024, 5, 6, 8, 0, 0, 0, utc)},
+		{"7 is Sunday", "0 0 * * 7",
+			time.Date(2024, 5, 1, 0, 0, 0, 0, utc), time.Date(2024, 5, 5, 0, 0, 0, 0, utc)},
+		{"day of month or day of week", "0 0 13 * 5",
+			time.Date(2024, 9, 1, 0, 0, 0, 0, utc), time.Date(2024, 9, 6, 0, 0, 0, 0, utc)},
+		{"day of month when weekday is later", "0 0 2 * 5",
+			time.Date(2024, 9, 1, 0, 0, 0, 0, utc), time.Date(2024, 9, 2, 0, 0, 0, 0, utc)},
+		{"star day of month requires weekday", "0 0 * * 1",
+			time.Date(2024, 9, 1, 0, 0, 0, 0, utc), time.Date(2024, 9, 2, 0, 0, 0, 0, utc)},
+		{"stepped star day of month requires weekday", "0 0 */2 * 1",
+			time.Date(2024, 9, 1, 0, 0, 0, 0, utc), time.Date(2024, 9, 9, 0, 0, 0, 0, utc)},
+
+		{"keeps location", "0 3 * * *",
+			time.Date(2024, 5, 1, 12, 0, 0, 0, berlin), time.Date(2024, 5, 2, 3, 0, 0, 0, berlin)},
+		{"spring forward skips missing local time", "30 2 * * *",
+			time.Date(2024, 3, 10, 0, 0, 0, 0, ny), time.Date(2024, 3, 11, 2, 30, 0, 0, ny)},
+		{"spring forward hourly", "0 * * * *",
+			time.Date(2024, 3, 10, 1, 30, 0, 0, ny), time.Date(2024, 3, 10, 3, 0, 0, 0, ny)},
+		{"spring forward Berlin", "0 2 * * *",
+			time.Date(2024, 3, 31, 1, 0, 0, 0, berlin), time.Date(2024, 4, 1, 2, 0, 0, 0, berlin)},
+		{"fall back runs repeated hour once", "30 1 * * *",
+			time.Date(2024, 11, 3, 0, 0, 0, 0, ny), time.Date(2024, 11, 3, 1, 30, 0, 0, ny)},
+		{"fall back does not repeat daily job", "30 1 * * *",
+			time.Date(2024, 11, 3, 1, 30, 0, 0, ny), time.Date(2024, 11, 4, 1, 30, 0, 0, ny)},
+		{"fall back hourly continues after repeated hour", "0 * * * *",
+			time.Date(2024, 11, 3, 1, 0, 0, 0, ny), time.Date(2024, 11, 3, 2, 0, 0, 0, ny)},
+
+		{"every aligned to epoch", "@every 15m",
+			time.Date(2024, 5, 1, 10, 7, 0, 0, utc), time.Date(2024, 5, 1, 10, 15, 0, 0, utc)},
+		{"every strictly after slot", "@every 1h",
+			time.Date(2024, 5, 1, 10, 0, 0, 0, utc), time.Date(2024, 5, 1, 11, 0, 0, 0, utc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q): %v", tt.spec, err)
+			}
+			got := s.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%s) for %q = %s, want %s", tt.from, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
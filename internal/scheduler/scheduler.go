@@ -0,0 +1,251 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// JobFunc — тело фоновой задачи.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name        string
+	description string
+	spec        string
+	schedule    Schedule
+	enabled     bool
+	run         JobFunc
+
+	mu      sync.Mutex
+	running bool
+	next    time.Time
+}
+
+var (
+	mu      sync.RWMutex
+	jobs    = map[string]*job{}
+	baseCtx = context.Background()
+	// wg учитывает циклы задач и выполняющиеся запуски — их ждёт Wait при остановке.
+	wg      sync.WaitGroup
+	running atomic.Bool
+	// stopping выставляет Wait под mu: после этого Trigger не добавляет запуски в wg.
+	stopping bool
+
+	// instance попадает в историю запусков, чтобы было видно, какой экземпляр выполнил задачу.
+	instance = func() string {
+		host, _ := os.Hostname()
+		return fmt.Sprintf("%s:%d", host, os.Getpid())
+	}()
+)
+
+// Register добавляет задачу. Выключенная задача видна в списке и запускается только вручную.
+// Вызывать до Start.
+func Register(name, description, spec string, enabled bool, fn JobFunc) error {
+	sched, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := jobs[name]; ok {
+		return fmt.Errorf("job %s: already registered", name)
+	}
+	jobs[name] = &job{name: name, description: description, spec: spec, schedule: sched, enabled: enabled, run: fn}
+	return nil
+}
+
+// Start запускает по горутине на каждую включённую задачу; они останавливаются при отмене ctx.
+func Start(ctx context.Context) {
+	mu.Lock()
+	baseCtx = ctx
+	mu.Unlock()
+
+	running.Store(true)
+	go func() {
+		<-ctx.Done()
+		running.Store(false)
+	}()
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, j := range jobs {
+		if !j.enabled {
+			logger.Info.Printf("scheduler.Start: job %s disabled", j.name)
+			continue
+		}
+		logger.Info.Printf("scheduler.Start: job %s scheduled %q", j.name, j.spec)
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			loop(ctx, j)
+		}(j)
+	}
+}
+
+func loop(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			logger.Warn.Printf("scheduler: job %s has no upcoming runs for %q", j.name, j.spec)
+			return
+		}
+		j.mu.Lock()
+		j.next = next
+		j.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		slot := next.UTC()
+		execute(ctx, j, models.JobTriggerSchedule, &slot)
+	}
+}
+
+// execute выполняет задачу, если этот экземпляр смог захватить её advisory-блокировку
+// и (для планового запуска) слот расписания ещё никто не занял.
+func execute(ctx context.Context, j *job, trigger string, slot *time.Time) {
+	release, ok, err := repository.TryJobLock(ctx, j.name)
+	if err != nil {
+		return
+	}
+	if !ok {
+		logger.Debug.Printf("scheduler: job %s is already running, skipping", j.name)
+		// Ручной запуск оставляет след в истории, иначе администратор не поймёт, почему ничего не произошло
+		if trigger == models.JobTriggerManual {
+			if runID, started, err := repository.StartJobRun(ctx, j.name, trigger, nil, instance); err == nil && started {
+				repository.FinishJobRun(ctx, runID, models.JobStatusSkipped, "job is already running")
+			}
+		}
+		return
+	}
+	defer release()
+
+	runID, started, err := repository.StartJobRun(ctx, j.name, trigger, slot, instance)
+	if err != nil || !started {
+		return
+	}
+
+	j.mu.Lock()
+	j.running = true
+	j.mu.Unlock()
+	defer func() {
+		j.mu.Lock()
+		j.running = false
+		j.mu.Unlock()
+	}()
+
+	logger.Info.Printf("scheduler: job %s started run=%d trigger=%s", j.name, runID, trigger)
+	ctx, span := tracing.Start(ctx, "job "+j.name, attribute.String("job.trigger", trigger), attribute.Int64("job.run_id", runID))
+	defer span.End()
+	begin := time.Now()
+	runErr := safeRun(ctx, j)
+
+	status, errText := models.JobStatusSucceeded, ""
+	if runErr != nil {
+		status, errText = models.JobStatusFailed, runErr.Error()
+		span.RecordError(runErr)
+		span.SetStatus(codes.Error, errText)
+		logger.Error.Printf("scheduler: job %s run=%d failed after %s: %v", j.name, runID, time.Since(begin), runErr)
+	} else {
+		logger.Info.Printf("scheduler: job %s run=%d finished in %s", j.name, runID, time.Since(begin))
+	}
+	// Итог записывается и после отмены ctx при остановке сервиса
+	repository.FinishJobRun(context.WithoutCancel(ctx), runID, status, errText)
+}
+
+// safeRun не даёт панике в задаче уронить процесс.
+func safeRun(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.run(ctx)
+}
+
+// Trigger запускает задачу вне расписания в фоне.
+// Если задача уже выполняется на этом или другом экземпляре, запуск пропускается.
+func Trigger(name string) error {
+	mu.RLock()
+	defer mu.RUnlock()
+	j, ok := jobs[name]
+	if !ok {
+		return fmt.Errorf("job %s: %w", name, errs.ErrNotFound)
+	}
+	ctx := baseCtx
+	// wg.Add под mu: Wait выставляет stopping под той же блокировкой до wg.Wait
+	if stopping || ctx.Err() != nil {
+		return fmt.Errorf("job %s: %w", name, errs.ErrShuttingDown)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		execute(ctx, j, models.JobTriggerManual, nil)
+	}()
+	return nil
+}
+
+// Running сообщает, запущен ли планировщик и не остановлен ли он.
+func Running() bool {
+	return running.Load()
+}
+
+// Wait ждёт, пока после отмены контекста Start завершатся циклы и выполняющиеся запуски.
+// Новые ручные запуски после вызова Wait отклоняются.
+func Wait() {
+	mu.Lock()
+	stopping = true
+	mu.Unlock()
+	wg.Wait()
+}
+
+// Exists сообщает, зарегистрирована ли задача.
+func Exists(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := jobs[name]
+	return ok
+}
+
+// List возвращает зарегистрированные задачи по алфавиту с состоянием на этом экземпляре.
+func List() []models.JobInfo {
+	mu.RLock()
+	defer mu.RUnlock()
+	out := make([]models.JobInfo, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		info := models.JobInfo{
+			Name:        j.name,
+			Description: j.description,
+			Schedule:    j.spec,
+			Enabled:     j.enabled,
+			Running:     j.running,
+		}
+		if j.enabled && !j.next.IsZero() {
+			next := j.next
+			info.NextRun = &next
+		}
+		j.mu.Unlock()
+		out = append(out, info)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Name < out[b].Name })
+	return out
+}
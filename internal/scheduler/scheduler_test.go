@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"Library/internal/errs"
+)
+
+func TestTriggerAfterWait(t *testing.T) {
+	if err := Register("trigger-after-wait", "test job", "@hourly", false, func(context.Context) error {
+		t.Error("job must not run after Wait")
+		return nil
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	Wait()
+
+	if err := Trigger("trigger-after-wait"); !errors.Is(err, errs.ErrShuttingDown) {
+		t.Fatalf("Trigger() error = %v, want %v", err, errs.ErrShuttingDown)
+	}
+	if err := Trigger("missing"); !errors.Is(err, errs.ErrNotFound) {
+		t.Fatalf("Trigger(missing) error = %v, want %v", err, errs.ErrNotFound)
+	}
+}
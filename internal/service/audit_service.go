@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+var auditActions = map[string]bool{
+	models.AuditActionCreate:  true,
+	models.AuditActionUpdate:  true,
+	models.AuditActionDelete:  true,
+	models.AuditActionRestore: true,
+	models.AuditActionPurge:   true,
+	models.AuditActionRevert:  true,
+	models.AuditActionMerge:   true,
+}
+
+// GetAuditEvents возвращает журнал аудита по фильтру с логированием.
+func GetAuditEvents(ctx context.Context, f models.AuditFilter) ([]models.AuditEvent, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAuditEvents")
+	defer span.End()
+	logger.Debug.Printf("service.GetAuditEvents: start filter=%+v", f)
+	if f.Action != "" && !auditActions[f.Action] {
+		return nil, fmt.Errorf("%w: unknown action %q", errs.ErrValidationFailed, f.Action)
+	}
+	if f.Limit <= 0 {
+		f.Limit = defaultAuditLimit
+	}
+	if f.Limit > maxAuditLimit {
+		f.Limit = maxAuditLimit
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	events, err := repository.GetAuditEvents(ctx, f)
+	if err != nil {
+		logger.Error.Printf("service.GetAuditEvents: error fetching events: %v", err)
+		return nil, err
+	}
+	logger.Info.Printf("service.GetAuditEvents: returned %d events", len(events))
+	return events, nil
+}
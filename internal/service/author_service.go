@@ -1,15 +1,119 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Library/internal/errs"
 	"Library/internal/models"
 	"Library/internal/repository"
+	"Library/internal/tracing"
 	"Library/logger"
 )
 
+const (
+	authorDateLayout   = "2006-01-02"
+	maxBiographyLength = 20000
+	maxAliasLength     = 200
+)
+
+var authorAliasKinds = map[string]bool{
+	models.AuthorAliasPseudonym:       true,
+	models.AuthorAliasTransliteration: true,
+	models.AuthorAliasAlternate:       true,
+}
+
+// normalizeAuthorDate проверяет дату в формате YYYY-MM-DD, не позже сегодняшней.
+func normalizeAuthorDate(field, date string) (string, error) {
+	t, err := time.Parse(authorDateLayout, strings.TrimSpace(date))
+	if err != nil {
+		return "", patchError(field, "must be a date in YYYY-MM-DD format")
+	}
+	if t.After(time.Now()) {
+		return "", patchError(field, "cannot be in the future")
+	}
+	return t.Format(authorDateLayout), nil
+}
+
+// checkLifeDates проверяет, что дата смерти не раньше даты рождения.
+// Даты уже нормализованы, поэтому их можно сравнивать как строки.
+func checkLifeDates(birth, death *string) error {
+	if birth != nil && death != nil && *death < *birth {
+		return patchError("death_date", "cannot be earlier than birth_date")
+	}
+	return nil
+}
+
+func normalizeBiography(bio string) (string, error) {
+	bio = strings.TrimSpace(bio)
+	if utf8.RuneCountInString(bio) > maxBiographyLength {
+		return "", patchError("biography", fmt.Sprintf("must be at most %d characters", maxBiographyLength))
+	}
+	return bio, nil
+}
+
+// validateAuthor проверяет и нормализует профиль автора при создании и полной замене.
+func validateAuthor(a *models.Author) error {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return fmt.Errorf("%w: author name is required", errs.ErrValidationFailed)
+	}
+	for field, date := range map[string]**string{"birth_date": &a.BirthDate, "death_date": &a.DeathDate} {
+		if *date == nil {
+			continue
+		}
+		v, err := normalizeAuthorDate(field, **date)
+		if err != nil {
+			return err
+		}
+		*date = &v
+	}
+	if err := checkLifeDates(a.BirthDate, a.DeathDate); err != nil {
+		return err
+	}
+	bio, err := normalizeBiography(a.Biography)
+	if err != nil {
+		return err
+	}
+	a.Biography = bio
+	a.Nationality = strings.TrimSpace(a.Nationality)
+	return nil
+}
+
+// attachAliases подгружает альтернативные имена авторов одним запросом.
+func attachAliases(ctx context.Context, authors []models.Author) error {
+	if len(authors) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(authors))
+	byID := make(map[int]*models.Author, len(authors))
+	for i := range authors {
+		ids[i] = int64(authors[i].ID)
+		byID[authors[i].ID] = &authors[i]
+	}
+	aliases, err := repository.GetAuthorAliases(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, al := range aliases {
+		if a := byID[al.AuthorID]; a != nil {
+			a.Aliases = append(a.Aliases, al)
+		}
+	}
+	return nil
+}
+
 // GetAllAuthors возвращает всех авторов с логированием.
-func GetAllAuthors() ([]models.Author, error) {
-	logger.Debug.Println("service.GetAllAuthors: start")
-	authors, err := repository.GetAllAuthors()
+func GetAllAuthors(ctx context.Context, includeDeleted bool) ([]models.Author, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllAuthors")
+	defer span.End()
+	logger.Debug.Printf("service.GetAllAuthors: start include_deleted=%t", includeDeleted)
+	authors, err := repository.GetAllAuthors(ctx, includeDeleted)
 	if err != nil {
 		logger.Error.Printf("service.GetAllAuthors: error fetching authors: %v", err)
 		return nil, err
@@ -19,21 +123,33 @@ func GetAllAuthors() ([]models.Author, error) {
 }
 
 // GetAuthorByID возвращает автора по ID с логированием.
-func GetAuthorByID(authorID int) (models.Author, error) {
+func GetAuthorByID(ctx context.Context, authorID int, includeDeleted bool) (models.Author, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAuthorByID")
+	defer span.End()
 	logger.Debug.Printf("service.GetAuthorByID: start id=%d", authorID)
-	author, err := repository.GetAuthorByID(authorID)
+	author, err := repository.GetAuthorByID(ctx, authorID, includeDeleted)
 	if err != nil {
 		logger.Error.Printf("service.GetAuthorByID: error fetching author id=%d: %v", authorID, err)
 		return models.Author{}, err
 	}
+	if author.Aliases, err = repository.GetAuthorAliases(ctx, []int64{int64(author.ID)}); err != nil {
+		logger.Error.Printf("service.GetAuthorByID: error fetching aliases id=%d: %v", authorID, err)
+		return models.Author{}, err
+	}
 	logger.Info.Printf("service.GetAuthorByID: returned author ID=%d name=%q", author.ID, author.Name)
 	return author, nil
 }
 
 // CreateAuthor создаёт нового автора с логированием.
-func CreateAuthor(author *models.Author) error {
+func CreateAuthor(ctx context.Context, author *models.Author, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.CreateAuthor")
+	defer span.End()
 	logger.Debug.Printf("service.CreateAuthor: start name=%q", author.Name)
-	err := repository.CreateAuthor(author)
+	if err := validateAuthor(author); err != nil {
+		logger.Warn.Printf("service.CreateAuthor: invalid author: %v", err)
+		return err
+	}
+	err := repository.CreateAuthor(ctx, author, actor)
 	if err != nil {
 		logger.Error.Printf("service.CreateAuthor: error creating author name=%q: %v", author.Name, err)
 		return err
@@ -43,9 +159,15 @@ func CreateAuthor(author *models.Author) error {
 }
 
 // UpdateAuthor обновляет автора с логированием.
-func UpdateAuthor(author *models.Author) error {
+func UpdateAuthor(ctx context.Context, author *models.Author, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.UpdateAuthor")
+	defer span.End()
 	logger.Debug.Printf("service.UpdateAuthor: start ID=%d name=%q", author.ID, author.Name)
-	err := repository.UpdateAuthor(author)
+	if err := validateAuthor(author); err != nil {
+		logger.Warn.Printf("service.UpdateAuthor: invalid author ID=%d: %v", author.ID, err)
+		return err
+	}
+	err := repository.UpdateAuthor(ctx, author, actor)
 	if err != nil {
 		logger.Error.Printf("service.UpdateAuthor: error updating author ID=%d: %v", author.ID, err)
 		return err
@@ -55,9 +177,11 @@ func UpdateAuthor(author *models.Author) error {
 }
 
 // DeleteAuthorByID удаляет автора по ID с логированием.
-func DeleteAuthorByID(authorID int) error {
+func DeleteAuthorByID(ctx context.Context, authorID int, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteAuthorByID")
+	defer span.End()
 	logger.Debug.Printf("service.DeleteAuthorByID: start id=%d", authorID)
-	err := repository.DeleteAuthorByID(authorID)
+	err := repository.DeleteAuthorByID(ctx, authorID, actor)
 	if err != nil {
 		logger.Error.Printf("service.DeleteAuthorByID: error deleting author id=%d: %v", authorID, err)
 		return err
@@ -66,13 +190,179 @@ func DeleteAuthorByID(authorID int) error {
 	return nil
 }
 
-func SearchAuthorsByName(fragment string) ([]models.Author, error) {
+func SearchAuthorsByName(ctx context.Context, fragment string) ([]models.Author, error) {
+	ctx, span := tracing.Start(ctx, "service.SearchAuthorsByName")
+	defer span.End()
 	logger.Debug.Printf("service.SearchAuthorsByName: start fragment=%q", fragment)
-	authors, err := repository.SearchAuthorsByName(fragment)
+	authors, err := repository.SearchAuthorsByName(ctx, fragment)
 	if err != nil {
 		logger.Error.Printf("service.SearchAuthorsByName: error searching authors fragment=%q: %v", fragment, err)
 		return nil, err
 	}
+	if err := attachAliases(ctx, authors); err != nil {
+		logger.Error.Printf("service.SearchAuthorsByName: error fetching aliases: %v", err)
+		return nil, err
+	}
 	logger.Info.Printf("service.SearchAuthorsByName: returned %d authors matching %q", len(authors), fragment)
 	return authors, nil
 }
+
+// RestoreAuthorByID восстанавливает удалённого автора с логированием.
+func RestoreAuthorByID(ctx context.Context, authorID int, actor models.AuditActor) (models.Author, error) {
+	ctx, span := tracing.Start(ctx, "service.RestoreAuthorByID")
+	defer span.End()
+	logger.Debug.Printf("service.RestoreAuthorByID: start id=%d", authorID)
+	author, err := repository.RestoreAuthorByID(ctx, authorID, actor)
+	if err != nil {
+		logger.Error.Printf("service.RestoreAuthorByID: error restoring author id=%d: %v", authorID, err)
+		return models.Author{}, err
+	}
+	logger.Info.Printf("service.RestoreAuthorByID: restored author ID=%d", authorID)
+	return author, nil
+}
+
+// PatchAuthor применяет JSON Merge Patch к автору.
+// Для дат null означает «не указана», для биографии и национальности — пустое значение.
+func PatchAuthor(ctx context.Context, authorID, version int, patch map[string]json.RawMessage, actor models.AuditActor) (models.Author, error) {
+	ctx, span := tracing.Start(ctx, "service.PatchAuthor")
+	defer span.End()
+	logger.Debug.Printf("service.PatchAuthor: start id=%d fields=%d", authorID, len(patch))
+	fields := make(map[string]interface{}, len(patch))
+	for field, raw := range patch {
+		var (
+			v   interface{}
+			err error
+		)
+		switch field {
+		case "name":
+			v, err = patchString(field, raw)
+		case "birth_date", "death_date":
+			if string(raw) == "null" {
+				break
+			}
+			var date string
+			if date, err = patchString(field, raw); err == nil {
+				v, err = normalizeAuthorDate(field, date)
+			}
+		case "biography", "nationality":
+			var text string
+			if string(raw) != "null" {
+				if err = json.Unmarshal(raw, &text); err != nil {
+					err = patchError(field, "must be a string")
+					break
+				}
+			}
+			if field == "biography" {
+				v, err = normalizeBiography(text)
+			} else {
+				v = strings.TrimSpace(text)
+			}
+		default:
+			err = unknownPatchField(field)
+		}
+		if err != nil {
+			logger.Warn.Printf("service.PatchAuthor: invalid patch id=%d: %v", authorID, err)
+			return models.Author{}, err
+		}
+		fields[field] = v
+	}
+
+	_, birthPatched := fields["birth_date"]
+	_, deathPatched := fields["death_date"]
+	if birthPatched || deathPatched {
+		current, err := repository.GetAuthorByID(ctx, authorID, false)
+		if err != nil {
+			return models.Author{}, err
+		}
+		birth, death := current.BirthDate, current.DeathDate
+		if birthPatched {
+			birth = patchedDate(fields["birth_date"])
+		}
+		if deathPatched {
+			death = patchedDate(fields["death_date"])
+		}
+		if err := checkLifeDates(birth, death); err != nil {
+			return models.Author{}, err
+		}
+	}
+
+	author, err := repository.PatchAuthor(ctx, authorID, version, fields, actor)
+	if err != nil {
+		logger.Error.Printf("service.PatchAuthor: error patching author id=%d: %v", authorID, err)
+		return models.Author{}, err
+	}
+	logger.Info.Printf("service.PatchAuthor: patched author ID=%d", authorID)
+	return author, nil
+}
+
+func patchedDate(v interface{}) *string {
+	if s, ok := v.(string); ok {
+		return &s
+	}
+	return nil
+}
+
+// SetAuthorAliases заменяет альтернативные имена автора. Повторы (без учёта регистра) отбрасываются.
+func SetAuthorAliases(ctx context.Context, authorID int, aliases []models.AuthorAlias, actor models.AuditActor) ([]models.AuthorAlias, error) {
+	ctx, span := tracing.Start(ctx, "service.SetAuthorAliases")
+	defer span.End()
+	logger.Debug.Printf("service.SetAuthorAliases: start author_id=%d aliases=%d", authorID, len(aliases))
+	seen := make(map[string]bool, len(aliases))
+	clean := make([]models.AuthorAlias, 0, len(aliases))
+	for _, al := range aliases {
+		al.Name = strings.TrimSpace(al.Name)
+		if al.Name == "" || utf8.RuneCountInString(al.Name) > maxAliasLength {
+			return nil, fmt.Errorf("%w: alias name must be 1..%d characters", errs.ErrValidationFailed, maxAliasLength)
+		}
+		if al.Kind == "" {
+			al.Kind = models.AuthorAliasAlternate
+		}
+		if !authorAliasKinds[al.Kind] {
+			return nil, fmt.Errorf("%w: alias kind must be one of pseudonym, transliteration, alternate", errs.ErrValidationFailed)
+		}
+		if key := strings.ToLower(al.Name); !seen[key] {
+			seen[key] = true
+			al.AuthorID = authorID
+			clean = append(clean, al)
+		}
+	}
+
+	if err := repository.SetAuthorAliases(ctx, authorID, clean, actor); err != nil {
+		logger.Error.Printf("service.SetAuthorAliases: error author_id=%d: %v", authorID, err)
+		return nil, err
+	}
+	return repository.GetAuthorAliases(ctx, []int64{int64(authorID)})
+}
+
+// MergeAuthors сливает дубли в одного автора с логированием.
+func MergeAuthors(ctx context.Context, targetID int, sourceIDs []int, actor models.AuditActor) (models.Author, error) {
+	ctx, span := tracing.Start(ctx, "service.MergeAuthors")
+	defer span.End()
+	logger.Debug.Printf("service.MergeAuthors: start target_id=%d sources=%v", targetID, sourceIDs)
+	if len(sourceIDs) == 0 {
+		return models.Author{}, fmt.Errorf("%w: source_ids must not be empty", errs.ErrValidationFailed)
+	}
+	seen := make(map[int]bool, len(sourceIDs))
+	ids := make([]int64, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id <= 0 || id == targetID {
+			return models.Author{}, fmt.Errorf("%w: source ids must be positive and differ from the target", errs.ErrValidationFailed)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, int64(id))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	author, err := repository.MergeAuthors(ctx, targetID, ids, actor)
+	if err != nil {
+		logger.Error.Printf("service.MergeAuthors: error merging into author_id=%d: %v", targetID, err)
+		return models.Author{}, err
+	}
+	if author.Aliases, err = repository.GetAuthorAliases(ctx, []int64{int64(targetID)}); err != nil {
+		return models.Author{}, err
+	}
+	logger.Info.Printf("service.MergeAuthors: merged %d authors into author ID=%d", len(ids), targetID)
+	return author, nil
+}
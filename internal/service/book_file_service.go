@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"Library/internal/config"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/storage"
+	"Library/internal/tracing"
+	"Library/logger"
+
+	"golang.org/x/image/draw"
+)
+
+// Значения по умолчанию, если в configs.json лимиты не заданы.
+const (
+	defaultMaxCoverSizeMB = 5
+	defaultMaxFileSizeMB  = 50
+	defaultThumbnailWidth = 200
+	// 40 Мп в RGBA — около 160 МБ при декодировании
+	defaultMaxCoverMegapixels = 40
+)
+
+var allowedCoverTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+var allowedFileTypes = map[string]bool{
+	"application/pdf":      true,
+	"application/epub+zip": true,
+}
+
+func limitOrDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
+// MaxCoverUploadBytes — предельный размер загружаемой обложки в байтах (max_cover_size_mb).
+func MaxCoverUploadBytes() int64 {
+	return int64(limitOrDefault(config.AppSettings.StorageParams.MaxCoverSizeMB, defaultMaxCoverSizeMB)) << 20
+}
+
+// MaxFileUploadBytes — предельный размер загружаемого файла книги в байтах (max_file_size_mb).
+func MaxFileUploadBytes() int64 {
+	return int64(limitOrDefault(config.AppSettings.StorageParams.MaxFileSizeMB, defaultMaxFileSizeMB)) << 20
+}
+
+// readLimited читает не более max байт; если данных больше — ErrFileTooLarge.
+func readLimited(r io.Reader, max int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, errs.ErrFileTooLarge
+	}
+	return data, nil
+}
+
+// sniffContentType определяет тип по содержимому, а не по заголовкам клиента.
+// EPUB — это zip-архив, поэтому дополнительно проверяем его сигнатуру mimetype.
+func sniffContentType(data []byte) string {
+	ct, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if ct == "application/zip" {
+		head := data
+		if len(head) > 64 {
+			head = head[:64]
+		}
+		if bytes.Contains(head, []byte("mimetypeapplication/epub+zip")) {
+			return "application/epub+zip"
+		}
+	}
+	return ct
+}
+
+// blobHash — ключ объекта в BlobStore: SHA-256 содержимого.
+func blobHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// storeBlob кладёт содержимое в BlobStore под его хешем, пропуская дубликаты.
+// Вызывается из репозитория под блокировкой хеша.
+func storeBlob(hash string, data []byte) error {
+	bs := storage.GetBlobStore()
+	exists, err := bs.Exists(hash)
+	if err != nil {
+		return err
+	}
+	if exists {
+		logger.Debug.Printf("service.storeBlob: blob %s already stored, deduplicated", hash)
+		return nil
+	}
+	return bs.Put(hash, bytes.NewReader(data))
+}
+
+// releaseBlob удаляет объект из хранилища, если на него больше никто не ссылается.
+// Выполняется и после отмены запроса, чтобы не оставлять осиротевших объектов.
+func releaseBlob(ctx context.Context, hash string) {
+	ctx = context.WithoutCancel(ctx)
+	removed, err := repository.ReleaseBlob(ctx, hash, func() error {
+		return storage.GetBlobStore().Delete(hash)
+	})
+	if err != nil {
+		logger.Error.Printf("service.releaseBlob: cannot release blob %s: %v", hash, err)
+		return
+	}
+	if removed {
+		logger.Debug.Printf("service.releaseBlob: blob %s removed", hash)
+	}
+}
+
+// makeThumbnail уменьшает изображение до заданной ширины и кодирует в JPEG.
+// Размеры сначала читаются из заголовка: маленький файл может объявить гигантскую картинку,
+// и её декодирование заняло бы гигабайты памяти.
+func makeThumbnail(data []byte, width int, maxPixels int64) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxPixels {
+		return nil, fmt.Errorf("%w: image is %dx%d pixels", errs.ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	if b.Dx() > width {
+		height := b.Dy() * width / b.Dx()
+		if height < 1 {
+			height = 1
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+		src = dst
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UploadBookCover сохраняет (или заменяет) обложку книги и генерирует миниатюру.
+func UploadBookCover(ctx context.Context, bookID int, fileName string, r io.Reader) (models.BookFile, error) {
+	ctx, span := tracing.Start(ctx, "service.UploadBookCover")
+	defer span.End()
+	logger.Debug.Printf("service.UploadBookCover: start book_id=%d file=%q", bookID, fileName)
+	if _, err := repository.GetBookByID(ctx, bookID, false); err != nil {
+		return models.BookFile{}, err
+	}
+
+	p := config.AppSettings.StorageParams
+	data, err := readLimited(r, MaxCoverUploadBytes())
+	if err != nil {
+		logger.Warn.Printf("service.UploadBookCover: read error book_id=%d: %v", bookID, err)
+		return models.BookFile{}, err
+	}
+	ct := sniffContentType(data)
+	if !allowedCoverTypes[ct] {
+		logger.Warn.Printf("service.UploadBookCover: rejected content type %q", ct)
+		return models.BookFile{}, errs.ErrUnsupportedMediaType
+	}
+
+	maxPixels := int64(limitOrDefault(p.MaxCoverMegapixels, defaultMaxCoverMegapixels)) * 1_000_000
+	thumb, err := makeThumbnail(data, limitOrDefault(p.ThumbnailWidth, defaultThumbnailWidth), maxPixels)
+	if errors.Is(err, errs.ErrImageTooLarge) {
+		logger.Warn.Printf("service.UploadBookCover: rejected image book_id=%d: %v", bookID, err)
+		return models.BookFile{}, err
+	}
+	if err != nil {
+		logger.Warn.Printf("service.UploadBookCover: cannot decode image book_id=%d: %v", bookID, err)
+		return models.BookFile{}, errs.ErrUnsupportedMediaType
+	}
+
+	hash, thumbHash := blobHash(data), blobHash(thumb)
+	cover := models.BookFile{
+		BookID:        bookID,
+		Kind:          models.BookFileKindCover,
+		FileName:      filepath.Base(fileName),
+		ContentType:   ct,
+		Size:          int64(len(data)),
+		SHA256:        hash,
+		ThumbnailHash: &thumbHash,
+	}
+	stored := false
+	old, err := repository.ReplaceBookCover(ctx, &cover, func() error {
+		stored = true
+		if err := storeBlob(hash, data); err != nil {
+			return err
+		}
+		return storeBlob(thumbHash, thumb)
+	})
+	if err != nil {
+		logger.Error.Printf("service.UploadBookCover: error book_id=%d: %v", bookID, err)
+		// Уже сохранённые объекты без ссылок на них убираем
+		if stored {
+			releaseBlob(ctx, hash)
+			releaseBlob(ctx, thumbHash)
+		}
+		return models.BookFile{}, err
+	}
+
+	if old != nil {
+		releaseBlob(ctx, old.SHA256)
+		if old.ThumbnailHash != nil {
+			releaseBlob(ctx, *old.ThumbnailHash)
+		}
+	}
+	logger.Info.Printf("service.UploadBookCover: stored cover ID=%d for book_id=%d", cover.ID, bookID)
+	return cover, nil
+}
+
+// OpenBookCover открывает обложку книги (или её миниатюру) на чтение.
+// SHA256 в ответе — хеш именно отдаваемого объекта, его контроллер использует как ETag.
+func OpenBookCover(ctx context.Context, bookID int, thumbnail bool) (models.BookFile, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "service.OpenBookCover")
+	defer span.End()
+	logger.Debug.Printf("service.OpenBookCover: start book_id=%d thumbnail=%t", bookID, thumbnail)
+	cover, err := repository.GetBookCover(ctx, bookID)
+	if err != nil {
+		return models.BookFile{}, nil, err
+	}
+
+	key := cover.SHA256
+	if thumbnail && cover.ThumbnailHash != nil {
+		key = *cover.ThumbnailHash
+		cover.SHA256 = key
+		cover.ContentType = "image/jpeg"
+		cover.Size = -1
+	}
+	rc, err := storage.GetBlobStore().Get(key)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		logger.Error.Printf("service.OpenBookCover: blob %s missing for book_id=%d", key, bookID)
+		return models.BookFile{}, nil, errs.ErrNotFound
+	}
+	if err != nil {
+		return models.BookFile{}, nil, err
+	}
+	return cover, rc, nil
+}
+
+// DeleteBookCover удаляет обложку книги.
+func DeleteBookCover(ctx context.Context, bookID int) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteBookCover")
+	defer span.End()
+	logger.Debug.Printf("service.DeleteBookCover: start book_id=%d", bookID)
+	cover, err := repository.GetBookCover(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if err := repository.DeleteBookFileByID(ctx, cover.ID); err != nil {
+		return err
+	}
+	releaseBlob(ctx, cover.SHA256)
+	if cover.ThumbnailHash != nil {
+		releaseBlob(ctx, *cover.ThumbnailHash)
+	}
+	logger.Info.Printf("service.DeleteBookCover: deleted cover for book_id=%d", bookID)
+	return nil
+}
+
+// UploadBookFile прикрепляет к книге файл (PDF/EPUB).
+func UploadBookFile(ctx context.Context, bookID int, fileName string, r io.Reader) (models.BookFile, error) {
+	ctx, span := tracing.Start(ctx, "service.UploadBookFile")
+	defer span.End()
+	logger.Debug.Printf("service.UploadBookFile: start book_id=%d file=%q", bookID, fileName)
+	if _, err := repository.GetBookByID(ctx, bookID, false); err != nil {
+		return models.BookFile{}, err
+	}
+
+	data, err := readLimited(r, MaxFileUploadBytes())
+	if err != nil {
+		logger.Warn.Printf("service.UploadBookFile: read error book_id=%d: %v", bookID, err)
+		return models.BookFile{}, err
+	}
+	ct := sniffContentType(data)
+	if !allowedFileTypes[ct] {
+		logger.Warn.Printf("service.UploadBookFile: rejected content type %q", ct)
+		return models.BookFile{}, errs.ErrUnsupportedMediaType
+	}
+
+	hash := blobHash(data)
+	f := models.BookFile{
+		BookID:      bookID,
+		Kind:        models.BookFileKindFile,
+		FileName:    strings.TrimSpace(filepath.Base(fileName)),
+		ContentType: ct,
+		Size:        int64(len(data)),
+		SHA256:      hash,
+	}
+	stored := false
+	err = repository.CreateBookFile(ctx, &f, func() error {
+		stored = true
+		return storeBlob(hash, data)
+	})
+	if err != nil {
+		logger.Error.Printf("service.UploadBookFile: repository error book_id=%d: %v", bookID, err)
+		if stored {
+			releaseBlob(ctx, hash)
+		}
+		return models.BookFile{}, err
+	}
+	logger.Info.Printf("service.UploadBookFile: stored file ID=%d for book_id=%d", f.ID, bookID)
+	return f, nil
+}
+
+// GetBookFiles возвращает список файлов книги.
+func GetBookFiles(ctx context.Context, bookID int) ([]models.BookFile, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBookFiles")
+	defer span.End()
+	logger.Debug.Printf("service.GetBookFiles: start book_id=%d", bookID)
+	if _, err := repository.GetBookByID(ctx, bookID, false); err != nil {
+		return nil, err
+	}
+	files, err := repository.GetBookFilesByBookID(ctx, bookID)
+	if err != nil {
+		logger.Error.Printf("service.GetBookFiles: error fetching files book_id=%d: %v", bookID, err)
+		return nil, err
+	}
+	return files, nil
+}
+
+// OpenBookFile открывает файл книги на чтение.
+func OpenBookFile(ctx context.Context, bookID, fileID int) (models.BookFile, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "service.OpenBookFile")
+	defer span.End()
+	logger.Debug.Printf("service.OpenBookFile: start book_id=%d id=%d", bookID, fileID)
+	f, err := repository.GetBookFileByID(ctx, bookID, fileID)
+	if err != nil {
+		return models.BookFile{}, nil, err
+	}
+	rc, err := storage.GetBlobStore().Get(f.SHA256)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		logger.Error.Printf("service.OpenBookFile: blob %s missing for file ID=%d", f.SHA256, f.ID)
+		return models.BookFile{}, nil, errs.ErrNotFound
+	}
+	if err != nil {
+		return models.BookFile{}, nil, err
+	}
+	return f, rc, nil
+}
+
+// DeleteBookFile удаляет файл книги.
+func DeleteBookFile(ctx context.Context, bookID, fileID int) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteBookFile")
+	defer span.End()
+	logger.Debug.Printf("service.DeleteBookFile: start book_id=%d id=%d", bookID, fileID)
+	f, err := repository.GetBookFileByID(ctx, bookID, fileID)
+	if err != nil {
+		return err
+	}
+	if err := repository.DeleteBookFileByID(ctx, f.ID); err != nil {
+		return err
+	}
+	releaseBlob(ctx, f.SHA256)
+	logger.Info.Printf("service.DeleteBookFile: deleted file ID=%d book_id=%d", fileID, bookID)
+	return nil
+}
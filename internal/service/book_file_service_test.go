@@ -0,0 +1,68 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"Library/internal/errs"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	// GIF, заголовок которого объявляет 50000×50000 пикселей, при самом файле в 13 байт
+	gifBomb := []byte{'G', 'I', 'F', '8', '9', 'a', 0x50, 0xC3, 0x50, 0xC3, 0, 0, 0}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		maxPixels int64
+		wantW     int
+		wantH     int
+		wantErr   error
+	}{
+		{name: "downscaled", data: encodePNG(t, 400, 100), maxPixels: 1_000_000, wantW: 200, wantH: 50},
+		{name: "small image kept", data: encodePNG(t, 120, 80), maxPixels: 1_000_000, wantW: 120, wantH: 80},
+		{name: "at pixel limit", data: encodePNG(t, 100, 100), maxPixels: 10_000, wantW: 100, wantH: 100},
+		{name: "over pixel limit", data: encodePNG(t, 101, 100), maxPixels: 10_000, wantErr: errs.ErrImageTooLarge},
+		{name: "decompression bomb", data: gifBomb, maxPixels: 40_000_000, wantErr: errs.ErrImageTooLarge},
+		{name: "not an image", data: []byte("%PDF-1.7"), maxPixels: 1_000_000, wantErr: image.ErrFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thumb, err := makeThumbnail(tt.data, 200, tt.maxPixels)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("makeThumbnail() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("makeThumbnail(): %v", err)
+			}
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+			if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+				t.Errorf("thumbnail is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
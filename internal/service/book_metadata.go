@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+)
+
+const (
+	minPublishedYear = 1450 // печатный станок Гутенберга
+	maxPageCount     = 100000
+	maxEditionLength = 100
+)
+
+// languageRe — код языка ISO 639-1/639-2 в нижнем регистре.
+var languageRe = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// deweyRe — индекс Дьюи: три цифры основного класса и необязательная дробная часть.
+var deweyRe = regexp.MustCompile(`^[0-9]{3}(\.[0-9]+)?$`)
+
+// udcRe — индекс УДК: начинается с цифры, дальше цифры и знаки связи/определители.
+var udcRe = regexp.MustCompile(`^[0-9][0-9.:+/()\-="' A-Za-z]{0,63}$`)
+
+var bookFormats = map[string]bool{
+	models.BookFormatHardcover: true,
+	models.BookFormatPaperback: true,
+	models.BookFormatEbook:     true,
+	models.BookFormatAudiobook: true,
+}
+
+func validatePublishedYear(year int) error {
+	if year < minPublishedYear || year > time.Now().Year()+1 {
+		return patchError("published_year", fmt.Sprintf("must be between %d and %d", minPublishedYear, time.Now().Year()+1))
+	}
+	return nil
+}
+
+func validatePageCount(n int) error {
+	if n <= 0 || n > maxPageCount {
+		return patchError("page_count", fmt.Sprintf("must be between 1 and %d", maxPageCount))
+	}
+	return nil
+}
+
+func normalizeLanguage(lang string) (string, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if !languageRe.MatchString(lang) {
+		return "", patchError("language", "must be an ISO 639 language code")
+	}
+	return lang, nil
+}
+
+func validateFormat(format string) error {
+	if !bookFormats[format] {
+		return patchError("format", "must be one of hardcover, paperback, ebook, audiobook")
+	}
+	return nil
+}
+
+func normalizeEdition(edition string) (string, error) {
+	edition = strings.TrimSpace(edition)
+	if edition == "" || len(edition) > maxEditionLength {
+		return "", patchError("edition", fmt.Sprintf("must be 1..%d characters", maxEditionLength))
+	}
+	return edition, nil
+}
+
+func normalizeClass(field, class string) (string, error) {
+	class = strings.TrimSpace(class)
+	re := deweyRe
+	if field == "udc_class" {
+		re = udcRe
+	}
+	if !re.MatchString(class) {
+		return "", patchError(field, "is not a valid class number")
+	}
+	return class, nil
+}
+
+func validatePublisherRef(ctx context.Context, publisherID int) error {
+	if _, err := repository.GetPublisherByID(ctx, publisherID); err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return patchError("publisher_id", "references unknown publisher")
+		}
+		return err
+	}
+	return nil
+}
+
+// validateBookMetadata проверяет и нормализует выходные данные издания
+// при создании и полной замене книги. Незаполненные поля остаются NULL.
+func validateBookMetadata(ctx context.Context, b *models.Book) error {
+	if b.PublisherID != nil {
+		if err := validatePublisherRef(ctx, *b.PublisherID); err != nil {
+			return err
+		}
+	}
+	if b.PublishedYear != nil {
+		if err := validatePublishedYear(*b.PublishedYear); err != nil {
+			return err
+		}
+	}
+	if b.PageCount != nil {
+		if err := validatePageCount(*b.PageCount); err != nil {
+			return err
+		}
+	}
+	if b.Language != nil {
+		lang, err := normalizeLanguage(*b.Language)
+		if err != nil {
+			return err
+		}
+		b.Language = &lang
+	}
+	if b.Format != nil {
+		if err := validateFormat(*b.Format); err != nil {
+			return err
+		}
+	}
+	if b.Edition != nil {
+		edition, err := normalizeEdition(*b.Edition)
+		if err != nil {
+			return err
+		}
+		b.Edition = &edition
+	}
+	for field, class := range map[string]**string{"dewey_class": &b.DeweyClass, "udc_class": &b.UDCClass} {
+		if *class == nil {
+			continue
+		}
+		v, err := normalizeClass(field, **class)
+		if err != nil {
+			return err
+		}
+		*class = &v
+	}
+	return nil
+}
+
+// patchBookMetadata разбирает поле выходных данных из merge patch.
+// Эти колонки необязательны, поэтому null означает «очистить».
+func patchBookMetadata(ctx context.Context, field string, raw json.RawMessage) (interface{}, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	switch field {
+	case "publisher_id":
+		id, err := patchPositiveInt(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		return id, validatePublisherRef(ctx, id)
+	case "published_year":
+		year, err := patchPositiveInt(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		return year, validatePublishedYear(year)
+	case "page_count":
+		n, err := patchPositiveInt(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		return n, validatePageCount(n)
+	case "language":
+		s, err := patchString(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeLanguage(s)
+	case "format":
+		s, err := patchString(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		return s, validateFormat(s)
+	case "edition":
+		s, err := patchString(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeEdition(s)
+	case "dewey_class", "udc_class":
+		s, err := patchString(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeClass(field, s)
+	}
+	return nil, unknownPatchField(field)
+}
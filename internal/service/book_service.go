@@ -1,15 +1,38 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"Library/internal/errs"
 	"Library/internal/models"
 	"Library/internal/repository"
+	"Library/internal/tracing"
 	"Library/logger"
 )
 
-// GetAllBooks возвращает все книги с логированием.
-func GetAllBooks() ([]models.Book, error) {
-	logger.Debug.Println("service.GetAllBooks: start")
-	books, err := repository.GetAllBooks()
+// GetAllBooks возвращает книги по фильтру с логированием.
+func GetAllBooks(ctx context.Context, f models.BookFilter) ([]models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllBooks")
+	defer span.End()
+	logger.Debug.Printf("service.GetAllBooks: start filter=%+v", f)
+	if f.Language != "" {
+		lang, err := normalizeLanguage(f.Language)
+		if err != nil {
+			return nil, err
+		}
+		f.Language = lang
+	}
+	if f.Sort != "" && f.Sort != models.BookSortID && f.Sort != models.BookSortShelf {
+		return nil, fmt.Errorf("%w: unknown sort %q", errs.ErrValidationFailed, f.Sort)
+	}
+	if f.YearFrom != nil && f.YearTo != nil && *f.YearFrom > *f.YearTo {
+		return nil, fmt.Errorf("%w: year_from must not exceed year_to", errs.ErrValidationFailed)
+	}
+	books, err := repository.GetAllBooks(ctx, f)
 	if err != nil {
 		logger.Error.Printf("service.GetAllBooks: error fetching books: %v", err)
 		return nil, err
@@ -19,21 +42,36 @@ func GetAllBooks() ([]models.Book, error) {
 }
 
 // GetBookByID возвращает книгу по ID с логированием.
-func GetBookByID(bookID int) (models.Book, error) {
+func GetBookByID(ctx context.Context, bookID int, includeDeleted bool) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBookByID")
+	defer span.End()
 	logger.Debug.Printf("service.GetBookByID: start id=%d", bookID)
-	book, err := repository.GetBookByID(bookID)
+	book, err := repository.GetBookByID(ctx, bookID, includeDeleted)
 	if err != nil {
 		logger.Error.Printf("service.GetBookByID: error fetching book id=%d: %v", bookID, err)
 		return models.Book{}, err
 	}
+	if book.NextInSeries, err = repository.GetNextInSeries(ctx, book.ID); err != nil {
+		logger.Error.Printf("service.GetBookByID: error fetching next in series id=%d: %v", bookID, err)
+		return models.Book{}, err
+	}
+	for i := range book.NextInSeries {
+		book.NextInSeries[i].Href = fmt.Sprintf("/books/%d", book.NextInSeries[i].BookID)
+	}
 	logger.Info.Printf("service.GetBookByID: returned book ID=%d title=%q", book.ID, book.Title)
 	return book, nil
 }
 
 // CreateBook создаёт новую книгу с логированием.
-func CreateBook(book *models.Book) error {
+func CreateBook(ctx context.Context, book *models.Book, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.CreateBook")
+	defer span.End()
 	logger.Debug.Printf("service.CreateBook: start name=%q title=%q", book.Name, book.Title)
-	err := repository.CreateBook(book)
+	if err := validateBookMetadata(ctx, book); err != nil {
+		logger.Warn.Printf("service.CreateBook: invalid metadata: %v", err)
+		return err
+	}
+	err := repository.CreateBook(ctx, book, actor)
 	if err != nil {
 		logger.Error.Printf("service.CreateBook: error creating book name=%q: %v", book.Name, err)
 		return err
@@ -43,9 +81,15 @@ func CreateBook(book *models.Book) error {
 }
 
 // UpdateBook обновляет книгу с логированием.
-func UpdateBook(book *models.Book) error {
+func UpdateBook(ctx context.Context, book *models.Book, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.UpdateBook")
+	defer span.End()
 	logger.Debug.Printf("service.UpdateBook: start ID=%d name=%q title=%q", book.ID, book.Name, book.Title)
-	err := repository.UpdateBook(book)
+	if err := validateBookMetadata(ctx, book); err != nil {
+		logger.Warn.Printf("service.UpdateBook: invalid metadata ID=%d: %v", book.ID, err)
+		return err
+	}
+	err := repository.UpdateBook(ctx, book, actor)
 	if err != nil {
 		logger.Error.Printf("service.UpdateBook: error updating book ID=%d: %v", book.ID, err)
 		return err
@@ -55,9 +99,11 @@ func UpdateBook(book *models.Book) error {
 }
 
 // DeleteBookByID удаляет книгу по ID с логированием.
-func DeleteBookByID(bookID int) error {
+func DeleteBookByID(ctx context.Context, bookID int, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteBookByID")
+	defer span.End()
 	logger.Debug.Printf("service.DeleteBookByID: start id=%d", bookID)
-	err := repository.DeleteBookByID(bookID)
+	err := repository.DeleteBookByID(ctx, bookID, actor)
 	if err != nil {
 		logger.Error.Printf("service.DeleteBookByID: error deleting book id=%d: %v", bookID, err)
 		return err
@@ -66,9 +112,11 @@ func DeleteBookByID(bookID int) error {
 	return nil
 }
 
-func SearchBooksByName(fragment string) ([]models.Book, error) {
+func SearchBooksByName(ctx context.Context, fragment string) ([]models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.SearchBooksByName")
+	defer span.End()
 	logger.Debug.Printf("service.SearchBooksByName: start fragment=%q", fragment)
-	books, err := repository.SearchBooksByName(fragment)
+	books, err := repository.SearchBooksByName(ctx, fragment)
 	if err != nil {
 		logger.Error.Printf("service.SearchBooksByName: error searching books fragment=%q: %v", fragment, err)
 		return nil, err
@@ -76,3 +124,133 @@ func SearchBooksByName(fragment string) ([]models.Book, error) {
 	logger.Info.Printf("service.SearchBooksByName: found %d books matching %q", len(books), fragment)
 	return books, nil
 }
+
+// GetBooksByAuthorID возвращает книги автора с логированием.
+func GetBooksByAuthorID(ctx context.Context, authorID int) ([]models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBooksByAuthorID")
+	defer span.End()
+	logger.Debug.Printf("service.GetBooksByAuthorID: start author_id=%d", authorID)
+	books, err := repository.GetBooksByAuthorID(ctx, authorID)
+	if err != nil {
+		logger.Error.Printf("service.GetBooksByAuthorID: error fetching books author_id=%d: %v", authorID, err)
+		return nil, err
+	}
+	logger.Info.Printf("service.GetBooksByAuthorID: returned %d books", len(books))
+	return books, nil
+}
+
+// GetNewestBooks возвращает последние поступления с логированием.
+func GetNewestBooks(ctx context.Context, limit int) ([]models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.GetNewestBooks")
+	defer span.End()
+	logger.Debug.Printf("service.GetNewestBooks: start limit=%d", limit)
+	books, err := repository.GetNewestBooks(ctx, limit)
+	if err != nil {
+		logger.Error.Printf("service.GetNewestBooks: error fetching books: %v", err)
+		return nil, err
+	}
+	logger.Info.Printf("service.GetNewestBooks: returned %d books", len(books))
+	return books, nil
+}
+
+// RestoreBookByID восстанавливает удалённую книгу с логированием.
+func RestoreBookByID(ctx context.Context, bookID int, actor models.AuditActor) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.RestoreBookByID")
+	defer span.End()
+	logger.Debug.Printf("service.RestoreBookByID: start id=%d", bookID)
+	book, err := repository.RestoreBookByID(ctx, bookID, actor)
+	if err != nil {
+		logger.Error.Printf("service.RestoreBookByID: error restoring book id=%d: %v", bookID, err)
+		return models.Book{}, err
+	}
+	logger.Info.Printf("service.RestoreBookByID: restored book ID=%d", bookID)
+	return book, nil
+}
+
+// PatchBook применяет JSON Merge Patch к книге: проверяет каждое поле
+// и обновляет только переданные колонки.
+func PatchBook(ctx context.Context, bookID, version int, patch map[string]json.RawMessage, actor models.AuditActor) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.PatchBook")
+	defer span.End()
+	logger.Debug.Printf("service.PatchBook: start id=%d fields=%d", bookID, len(patch))
+	fields := make(map[string]interface{}, len(patch))
+	for field, raw := range patch {
+		var (
+			v   interface{}
+			err error
+		)
+		switch field {
+		case "name", "title":
+			v, err = patchString(field, raw)
+		case "author_id":
+			var authorID int
+			if authorID, err = patchPositiveInt(field, raw); err == nil {
+				if _, err = repository.GetAuthorByID(ctx, authorID, false); errors.Is(err, errs.ErrNotFound) {
+					err = patchError(field, "references unknown author")
+				}
+			}
+			v = authorID
+		case "publisher_id", "published_year", "edition", "language", "page_count", "format",
+			"dewey_class", "udc_class":
+			v, err = patchBookMetadata(ctx, field, raw)
+		default:
+			err = unknownPatchField(field)
+		}
+		if err != nil {
+			logger.Warn.Printf("service.PatchBook: invalid patch id=%d: %v", bookID, err)
+			return models.Book{}, err
+		}
+		fields[field] = v
+	}
+
+	book, err := repository.PatchBook(ctx, bookID, version, fields, actor)
+	if err != nil {
+		logger.Error.Printf("service.PatchBook: error patching book id=%d: %v", bookID, err)
+		return models.Book{}, err
+	}
+	logger.Info.Printf("service.PatchBook: patched book ID=%d", bookID)
+	return book, nil
+}
+
+// GetBookHistory возвращает все версии книги.
+func GetBookHistory(ctx context.Context, bookID int) ([]models.BookHistory, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBookHistory")
+	defer span.End()
+	logger.Debug.Printf("service.GetBookHistory: start id=%d", bookID)
+	history, err := repository.GetBookHistory(ctx, bookID)
+	if err != nil {
+		logger.Error.Printf("service.GetBookHistory: error fetching history id=%d: %v", bookID, err)
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetBookAsOf возвращает книгу в том виде, в каком она была в момент asOf.
+func GetBookAsOf(ctx context.Context, bookID int, asOf time.Time) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBookAsOf")
+	defer span.End()
+	logger.Debug.Printf("service.GetBookAsOf: start id=%d as_of=%s", bookID, asOf.Format(time.RFC3339))
+	book, err := repository.GetBookAsOf(ctx, bookID, asOf)
+	if err != nil {
+		logger.Error.Printf("service.GetBookAsOf: error fetching book id=%d: %v", bookID, err)
+		return models.Book{}, err
+	}
+	return book, nil
+}
+
+// RevertBook откатывает книгу к указанной версии с логированием.
+func RevertBook(ctx context.Context, bookID, toVersion, version int, actor models.AuditActor) (models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.RevertBook")
+	defer span.End()
+	logger.Debug.Printf("service.RevertBook: start id=%d to_version=%d", bookID, toVersion)
+	if toVersion <= 0 {
+		return models.Book{}, fmt.Errorf("%w: version must be positive", errs.ErrValidationFailed)
+	}
+	book, err := repository.RevertBook(ctx, bookID, toVersion, version, actor)
+	if err != nil {
+		logger.Error.Printf("service.RevertBook: error reverting book id=%d: %v", bookID, err)
+		return models.Book{}, err
+	}
+	logger.Info.Printf("service.RevertBook: reverted book ID=%d to version=%d", bookID, toVersion)
+	return book, nil
+}
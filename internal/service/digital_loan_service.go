@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"Library/internal/config"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+	"Library/utils"
+)
+
+// Значения по умолчанию для цифровой выдачи.
+const (
+	defaultLoanDays               = 14
+	defaultDownloadLinkTTLMinutes = 60
+	defaultLicenseCount           = 1
+	defaultExpiryCheckMinutes     = 5
+)
+
+func lendingParams() (loanDays, linkTTL, licenses int) {
+	p := config.AppSettings.LendingParams
+	return limitOrDefault(p.LoanDays, defaultLoanDays),
+		limitOrDefault(p.DownloadLinkTTLMinutes, defaultDownloadLinkTTLMinutes),
+		limitOrDefault(p.DefaultLicenseCount, defaultLicenseCount)
+}
+
+// buildDownloadLinks подписывает ссылки на все файлы книги.
+func buildDownloadLinks(ctx context.Context, loan models.DigitalLoan) ([]models.DownloadLink, error) {
+	files, err := repository.GetBookFilesByBookID(ctx, loan.BookID)
+	if err != nil {
+		return nil, err
+	}
+	return signDownloadLinks(loan, files), nil
+}
+
+// signDownloadLinks подписывает ссылки на уже загруженные файлы книги по выдаче.
+// Ссылка живёт не дольше самой выдачи.
+func signDownloadLinks(loan models.DigitalLoan, files []models.BookFile) []models.DownloadLink {
+	_, linkTTL, _ := lendingParams()
+	expires := time.Now().Add(time.Duration(linkTTL) * time.Minute)
+	if loan.ExpiresAt.Before(expires) {
+		expires = loan.ExpiresAt
+	}
+
+	links := make([]models.DownloadLink, 0, len(files))
+	for _, f := range files {
+		links = append(links, models.DownloadLink{
+			FileID:      f.ID,
+			FileName:    f.FileName,
+			ContentType: f.ContentType,
+			URL: fmt.Sprintf("%s/digital/download?loan=%d&file=%d&expires=%d&sig=%s",
+				opdsBaseURL(), loan.ID, f.ID, expires.Unix(), utils.SignDownload(loan.ID, f.ID, expires)),
+			ExpiresAt: expires,
+		})
+	}
+	return links
+}
+
+// CheckoutDigitalBook выдаёт читателю электронную книгу, если есть свободная лицензия.
+func CheckoutDigitalBook(ctx context.Context, bookID, userID int) (models.DigitalLoanWithLinks, error) {
+	ctx, span := tracing.Start(ctx, "service.CheckoutDigitalBook")
+	defer span.End()
+	logger.Debug.Printf("service.CheckoutDigitalBook: start book_id=%d user_id=%d", bookID, userID)
+	files, err := GetBookFiles(ctx, bookID)
+	if err != nil {
+		return models.DigitalLoanWithLinks{}, err
+	}
+	if len(files) == 0 {
+		logger.Warn.Printf("service.CheckoutDigitalBook: book_id=%d has no digital files", bookID)
+		return models.DigitalLoanWithLinks{}, fmt.Errorf("%w: book has no digital files", errs.ErrValidationFailed)
+	}
+
+	loanDays, _, licenses := lendingParams()
+	loan := models.DigitalLoan{
+		BookID:    bookID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Duration(loanDays) * 24 * time.Hour),
+	}
+	if err := repository.CreateDigitalLoan(ctx, &loan, licenses); err != nil {
+		logger.Error.Printf("service.CheckoutDigitalBook: repository error book_id=%d: %v", bookID, err)
+		return models.DigitalLoanWithLinks{}, err
+	}
+
+	links, err := buildDownloadLinks(ctx, loan)
+	if err != nil {
+		return models.DigitalLoanWithLinks{}, err
+	}
+	logger.Info.Printf("service.CheckoutDigitalBook: loan ID=%d until %s", loan.ID, loan.ExpiresAt.Format(time.RFC3339))
+	return models.DigitalLoanWithLinks{DigitalLoan: loan, Links: links}, nil
+}
+
+// GetMyDigitalLoans возвращает действующие выдачи читателя со свежими ссылками.
+func GetMyDigitalLoans(ctx context.Context, userID int) ([]models.DigitalLoanWithLinks, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMyDigitalLoans")
+	defer span.End()
+	logger.Debug.Printf("service.GetMyDigitalLoans: start user_id=%d", userID)
+	loans, err := repository.GetActiveDigitalLoansByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.DigitalLoanWithLinks, 0, len(loans))
+	for _, l := range loans {
+		links, err := buildDownloadLinks(ctx, l)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, models.DigitalLoanWithLinks{DigitalLoan: l, Links: links})
+	}
+	logger.Info.Printf("service.GetMyDigitalLoans: returned %d loans for user_id=%d", len(out), userID)
+	return out, nil
+}
+
+// ReturnDigitalBook досрочно возвращает выдачу читателя.
+func ReturnDigitalBook(ctx context.Context, loanID, userID int) error {
+	ctx, span := tracing.Start(ctx, "service.ReturnDigitalBook")
+	defer span.End()
+	logger.Debug.Printf("service.ReturnDigitalBook: start id=%d user_id=%d", loanID, userID)
+	if err := repository.ReturnDigitalLoan(ctx, loanID, userID); err != nil {
+		logger.Error.Printf("service.ReturnDigitalBook: error returning loan id=%d: %v", loanID, err)
+		return err
+	}
+	logger.Info.Printf("service.ReturnDigitalBook: returned loan ID=%d", loanID)
+	return nil
+}
+
+// SetDigitalLicenseCount задаёт число одновременных лицензий на издание.
+func SetDigitalLicenseCount(ctx context.Context, bookID, count int) error {
+	ctx, span := tracing.Start(ctx, "service.SetDigitalLicenseCount")
+	defer span.End()
+	logger.Debug.Printf("service.SetDigitalLicenseCount: start book_id=%d count=%d", bookID, count)
+	if count < 0 {
+		return fmt.Errorf("%w: license count must be >= 0", errs.ErrValidationFailed)
+	}
+	if _, err := repository.GetBookByID(ctx, bookID, false); err != nil {
+		return err
+	}
+	return repository.SetDigitalLicenseCount(ctx, bookID, count)
+}
+
+// GetDigitalAvailability возвращает состояние пула лицензий издания.
+func GetDigitalAvailability(ctx context.Context, bookID int) (models.DigitalAvailability, error) {
+	ctx, span := tracing.Start(ctx, "service.GetDigitalAvailability")
+	defer span.End()
+	_, _, licenses := lendingParams()
+	return repository.GetDigitalAvailability(ctx, bookID, licenses)
+}
+
+// OpenDigitalDownload проверяет подписанную ссылку и открывает файл на чтение.
+func OpenDigitalDownload(ctx context.Context, loanID, fileID int, expires int64, signature string) (models.BookFile, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "service.OpenDigitalDownload")
+	defer span.End()
+	logger.Debug.Printf("service.OpenDigitalDownload: start loan=%d file=%d", loanID, fileID)
+	if !utils.VerifyDownload(loanID, fileID, expires, signature) {
+		return models.BookFile{}, nil, errs.ErrInvalidSignature
+	}
+
+	loan, err := repository.GetDigitalLoanByID(ctx, loanID)
+	if err != nil {
+		return models.BookFile{}, nil, err
+	}
+	if loan.ReturnedAt != nil || !time.Now().Before(loan.ExpiresAt) {
+		logger.Warn.Printf("service.OpenDigitalDownload: loan ID=%d is no longer active", loanID)
+		return models.BookFile{}, nil, errs.ErrLoanExpired
+	}
+	return OpenBookFile(ctx, loan.BookID, fileID)
+}
+
+// ExpireDigitalLoans закрывает просроченные выдачи.
+func ExpireDigitalLoans(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.ExpireDigitalLoans")
+	defer span.End()
+	n, err := repository.ExpireDigitalLoans(ctx, time.Now())
+	if err != nil {
+		logger.Error.Printf("service.ExpireDigitalLoans: error: %v", err)
+		return 0, err
+	}
+	if n > 0 {
+		logger.Info.Printf("service.ExpireDigitalLoans: %d licenses returned to pool", n)
+	}
+	return n, nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+const (
+	// eventStreamBuffer — сколько событий может накопить медленный клиент, прежде чем его отключат.
+	// Отключённый клиент переподключается с Last-Event-ID и ничего не теряет.
+	eventStreamBuffer     = 256
+	eventStreamReplayPage = 500
+	eventListenerPing     = 90 * time.Second
+	// eventStreamRetryMax — наибольшая пауза между попытками запустить прослушивание.
+	eventStreamRetryMax = time.Minute
+)
+
+// Сущности, изменения которых транслируются в поток.
+var streamEntities = map[string]bool{
+	models.AuditEntityBook:   true,
+	models.AuditEntityAuthor: true,
+	models.AuditEntityUser:   true,
+}
+
+var eventStreamRunning atomic.Bool
+
+var defaultStreamTypes = []string{"book.*", "author.*", "user.*"}
+
+// EventSubscription — подписка одного клиента на поток событий.
+type EventSubscription struct {
+	C chan models.StreamEvent
+}
+
+var eventHub = struct {
+	sync.Mutex
+	subs map[*EventSubscription]struct{}
+}{subs: map[*EventSubscription]struct{}{}}
+
+// SubscribeEvents регистрирует клиента потока. Канал C закрывается, если клиент не успевает читать.
+func SubscribeEvents() *EventSubscription {
+	sub := &EventSubscription{C: make(chan models.StreamEvent, eventStreamBuffer)}
+	eventHub.Lock()
+	eventHub.subs[sub] = struct{}{}
+	eventHub.Unlock()
+	return sub
+}
+
+// UnsubscribeEvents снимает подписку клиента.
+func UnsubscribeEvents(sub *EventSubscription) {
+	eventHub.Lock()
+	if _, ok := eventHub.subs[sub]; ok {
+		delete(eventHub.subs, sub)
+		close(sub.C)
+	}
+	eventHub.Unlock()
+}
+
+func publishStreamEvent(ev models.StreamEvent) {
+	eventHub.Lock()
+	defer eventHub.Unlock()
+	for sub := range eventHub.subs {
+		select {
+		case sub.C <- ev:
+		default:
+			logger.Warn.Println("service.publishStreamEvent: slow client dropped")
+			delete(eventHub.subs, sub)
+			close(sub.C)
+		}
+	}
+}
+
+// toStreamEvent превращает событие outbox в событие потока; ok == false для сущностей вне потока
+// и для событий с неразборчивым payload — такие пропускаются.
+func toStreamEvent(e models.OutboxEvent) (models.StreamEvent, bool) {
+	if !streamEntities[strings.SplitN(e.EventType, ".", 2)[0]] {
+		return models.StreamEvent{}, false
+	}
+	var p struct {
+		Data      json.RawMessage `json:"data"`
+		ActorID   *int            `json:"actor_id"`
+		RequestID string          `json:"request_id"`
+	}
+	if err := json.Unmarshal(e.Payload, &p); err != nil {
+		logger.Error.Printf("service.toStreamEvent: bad payload in event ID=%d: %v", e.ID, err)
+		return models.StreamEvent{}, false
+	}
+	var entity struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(p.Data, &entity); err != nil {
+		logger.Error.Printf("service.toStreamEvent: bad data in event ID=%d: %v", e.ID, err)
+		return models.StreamEvent{}, false
+	}
+	return models.StreamEvent{
+		ID:         e.StreamSeq,
+		Type:       e.EventType,
+		OccurredAt: e.CreatedAt,
+		Data:       p.Data,
+		ActorID:    p.ActorID,
+		RequestID:  p.RequestID,
+		EntityID:   entity.ID,
+	}, true
+}
+
+// ParseStreamTypes разбирает ?types=book.*,author.updated; пустое значение — все события потока.
+func ParseStreamTypes(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return defaultStreamTypes, nil
+	}
+	var types []string
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSpace(t)
+		if err := validateWebhookEventType(t); err != nil {
+			return nil, err
+		}
+		if t != "*" && !streamEntities[strings.SplitN(t, ".", 2)[0]] {
+			return nil, fmt.Errorf("%w: %q is not streamed, use book, author or user events", errs.ErrValidationFailed, t)
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+func matchEventType(pattern, t string) bool {
+	if pattern == "*" || pattern == t {
+		return true
+	}
+	return strings.HasSuffix(pattern, ".*") && strings.HasPrefix(t, strings.TrimSuffix(pattern, "*"))
+}
+
+// StreamEventVisible проверяет фильтр клиента и его права: события о пользователях
+// видит администратор, а читатель — только о себе.
+func StreamEventVisible(ev models.StreamEvent, types []string, userID int, role string) bool {
+	matched := false
+	for _, p := range types {
+		if matchEventType(p, ev.Type) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if strings.HasPrefix(ev.Type, models.AuditEntityUser+".") && role != "admin" {
+		return ev.EntityID == userID
+	}
+	return true
+}
+
+// GetStreamEventsAfter возвращает следующую страницу событий после номера afterID для продолжения потока
+// и номер, с которого запрашивать следующую страницу (0 — страниц больше нет).
+func GetStreamEventsAfter(ctx context.Context, afterID int64) ([]models.StreamEvent, int64, error) {
+	ctx, span := tracing.Start(ctx, "service.GetStreamEventsAfter")
+	defer span.End()
+	rows, err := repository.GetOutboxEventsAfter(ctx, afterID, eventStreamReplayPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	events := make([]models.StreamEvent, 0, len(rows))
+	for _, r := range rows {
+		if ev, ok := toStreamEvent(r); ok {
+			events = append(events, ev)
+		}
+	}
+	var next int64
+	if len(rows) == eventStreamReplayPage {
+		next = rows[len(rows)-1].StreamSeq
+	}
+	return events, next, nil
+}
+
+// StartEventStream запускает трансляцию событий outbox подписчикам потока. Если запустить
+// прослушивание не удалось, попытки повторяются в фоне с нарастающей паузой; до этого
+// /events/stream отдаёт только историю.
+func StartEventStream(ctx context.Context) {
+	err := startEventStream(ctx)
+	if err == nil {
+		return
+	}
+	logger.Error.Printf("StartEventStream: cannot start, retrying in background: %v", err)
+	go func() {
+		delay := time.Second
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			err := startEventStream(ctx)
+			if err == nil {
+				return
+			}
+			logger.Warn.Printf("StartEventStream: retry failed: %v", err)
+			if delay *= 2; delay > eventStreamRetryMax {
+				delay = eventStreamRetryMax
+			}
+		}
+	}()
+}
+
+// startEventStream слушает NOTIFY о новых событиях outbox и раздаёт их подписчикам потока.
+// LISTEN работает на каждом экземпляре, поэтому клиент получает изменения, сделанные через любой из них.
+// Уведомление только будит цикл: события нумеруются в порядке фиксации (SequenceOutboxEvents)
+// и читаются по номеру, поэтому событие транзакции, зафиксированной позже соседней, не теряется.
+func startEventStream(ctx context.Context) error {
+	l, err := repository.ListenOutboxEvents(ctx)
+	if err != nil {
+		return err
+	}
+	lastSeq, err := repository.GetLastOutboxStreamSeq(ctx)
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	// catchUp нумерует новые события и раздаёт всё, что идёт после lastSeq. Нумерация на другом
+	// экземпляре тоже подходит: SequenceOutboxEvents ждёт её фиксации на общей блокировке.
+	catchUp := func() {
+		for {
+			if _, err := repository.SequenceOutboxEvents(ctx, eventStreamReplayPage); err != nil {
+				return
+			}
+			rows, err := repository.GetOutboxEventsAfter(ctx, lastSeq, eventStreamReplayPage)
+			if err != nil {
+				return
+			}
+			for _, r := range rows {
+				lastSeq = r.StreamSeq
+				if ev, ok := toStreamEvent(r); ok {
+					publishStreamEvent(ev)
+				}
+			}
+			if len(rows) < eventStreamReplayPage {
+				return
+			}
+		}
+	}
+
+	eventStreamRunning.Store(true)
+	go func() {
+		defer eventStreamRunning.Store(false)
+		defer l.Close()
+		ping := time.NewTicker(eventListenerPing)
+		defer ping.Stop()
+		catchUp()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ping.C:
+				// Заодно подбираем события, если нумерация в прошлый раз не удалась
+				l.Ping()
+				catchUp()
+			case <-l.Notify:
+				// Забираем накопившиеся уведомления разом (nil — переподключение, после него
+				// тоже достаточно догнать поток по номеру)
+				for more := true; more; {
+					select {
+					case <-l.Notify:
+					default:
+						more = false
+					}
+				}
+				catchUp()
+			}
+		}
+	}()
+	logger.Info.Println("StartEventStream: streaming catalog events")
+	return nil
+}
+
+// CloseEventSubscribers отключает всех клиентов потока — при остановке сервера,
+// иначе долгие SSE-соединения не дали бы завершить приём запросов.
+func CloseEventSubscribers() {
+	eventHub.Lock()
+	defer eventHub.Unlock()
+	for sub := range eventHub.subs {
+		delete(eventHub.subs, sub)
+		close(sub.C)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"Library/internal/db"
+	"Library/internal/models"
+	"Library/internal/queue"
+	"Library/internal/repository"
+	"Library/internal/scheduler"
+	"Library/logger"
+)
+
+const healthCheckTimeout = 2 * time.Second
+
+// notReady выставляется при остановке, чтобы балансировщик перестал слать запросы раньше,
+// чем сервер закроет приём соединений.
+var notReady atomic.Bool
+
+// MarkNotReady переводит экземпляр в состояние «не готов»; /readyz начинает отвечать 503.
+func MarkNotReady() {
+	if !notReady.Swap(true) {
+		logger.Info.Println("service.MarkNotReady: instance marked not ready")
+	}
+}
+
+func runHealthCheck(ctx context.Context, fn func(ctx context.Context) (map[string]bool, error)) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	begin := time.Now()
+	details, err := fn(ctx)
+	check := models.HealthCheck{
+		Status:    models.HealthStatusOK,
+		LatencyMS: float64(time.Since(begin).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		check.Status, check.Error = models.HealthStatusFail, err.Error()
+	}
+	return check
+}
+
+// Readiness проверяет, может ли экземпляр обслуживать запросы: база отвечает,
+// схема применена, фоновые обработчики работают.
+func Readiness(ctx context.Context) models.HealthReport {
+	report := models.HealthReport{Status: models.HealthStatusOK, Checks: map[string]models.HealthCheck{}}
+	if notReady.Load() {
+		report.Checks["shutdown"] = models.HealthCheck{Status: models.HealthStatusFail, Error: "instance is shutting down"}
+	}
+
+	// Недоступная реплика готовность не снимает: чтения уходят на основную базу
+	report.Checks["database"] = runHealthCheck(ctx, func(ctx context.Context) (map[string]bool, error) {
+		return db.ReplicaStatus(), repository.PingDB(ctx)
+	})
+	report.Checks["schema"] = runHealthCheck(ctx, func(ctx context.Context) (map[string]bool, error) {
+		missing, err := repository.GetMissingSchemaObjects(ctx)
+		if err == nil && len(missing) > 0 {
+			err = fmt.Errorf("schema.sql is not fully applied, missing: %s", strings.Join(missing, ", "))
+		}
+		return nil, err
+	})
+	// Поток SSE в Details, но готовность не снимает: без него API работает, /events/stream
+	// отдаёт историю, а прослушивание перезапускается в фоне
+	report.Checks["background"] = runHealthCheck(ctx, func(context.Context) (map[string]bool, error) {
+		workers := map[string]bool{
+			"scheduler":     scheduler.Running(),
+			"queue":         queue.Running(),
+			"webhook_relay": webhookRelayRunning.Load(),
+			"event_stream":  eventStreamRunning.Load(),
+		}
+		var stopped []string
+		for name, ok := range workers {
+			if !ok && name != "event_stream" {
+				stopped = append(stopped, name)
+			}
+		}
+		if len(stopped) > 0 {
+			sort.Strings(stopped)
+			return workers, fmt.Errorf("not running: %s", strings.Join(stopped, ", "))
+		}
+		return workers, nil
+	})
+
+	for name, c := range report.Checks {
+		if c.Status != models.HealthStatusOK {
+			report.Status = models.HealthStatusFail
+			if name != "shutdown" {
+				logger.Warn.Printf("service.Readiness: %s check failed: %s", name, c.Error)
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"Library/internal/config"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/queue"
+	"Library/internal/repository"
+	"Library/internal/scheduler"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+// Имена фоновых задач; под ними же задаются расписания в scheduler_params.jobs.
+const (
+	JobDigitalLoanExpiry      = "digital_loan_expiry"
+	JobSoftDeletePurge        = "soft_delete_purge"
+	JobRecommendationsRefresh = "recommendations_refresh"
+	JobRunsCleanup            = "job_runs_cleanup"
+	JobTasksCleanup           = "tasks_cleanup"
+	JobLoanDueReminders       = "loan_due_reminders"
+	JobWebhookHistoryCleanup  = "webhook_history_cleanup"
+)
+
+// Значения по умолчанию для истории запусков.
+const (
+	defaultJobRunsRetentionDays = 30
+	defaultJobRunsLimit         = 20
+	maxJobRunsLimit             = 200
+)
+
+// jobSchedule берёт расписание из конфигурации, иначе — значение по умолчанию.
+func jobSchedule(name, def string) (string, bool) {
+	p, ok := config.AppSettings.SchedulerParams.Jobs[name]
+	if !ok || p.Schedule == "" {
+		return def, !p.Disabled
+	}
+	return p.Schedule, !p.Disabled
+}
+
+func everyMinutes(minutes, def int) string {
+	return fmt.Sprintf("@every %dm", limitOrDefault(minutes, def))
+}
+
+// StartJobs регистрирует фоновые задачи и запускает планировщик.
+// Интервалы по умолчанию берутся из прежних настроек разделов lending/soft_delete/recommend.
+func StartJobs(ctx context.Context) error {
+	defs := []struct {
+		name, description, schedule string
+		run                         scheduler.JobFunc
+	}{
+		{
+			JobDigitalLoanExpiry, "Закрытие просроченных цифровых выдач",
+			everyMinutes(config.AppSettings.LendingParams.ExpiryCheckMinutes, defaultExpiryCheckMinutes),
+			func(ctx context.Context) error { _, err := ExpireDigitalLoans(ctx); return err },
+		},
+		{
+			JobSoftDeletePurge, "Окончательное удаление мягко удалённых записей",
+			everyMinutes(config.AppSettings.SoftDeleteParams.PurgeIntervalMinutes, defaultPurgeIntervalMinutes),
+			func(ctx context.Context) error { _, err := PurgeSoftDeleted(ctx); return err },
+		},
+		{
+			JobRecommendationsRefresh, "Пересчёт похожих книг",
+			everyMinutes(config.AppSettings.RecommendParams.RefreshIntervalMinutes, defaultRecommendIntervalMinutes),
+			func(ctx context.Context) error { _, err := RefreshRecommendations(ctx); return err },
+		},
+		{
+			JobLoanDueReminders, "Напоминания о скором окончании выдач",
+			"*/15 * * * *",
+			func(ctx context.Context) error { _, err := SendDueReminders(ctx); return err },
+		},
+		{
+			JobRunsCleanup, "Очистка истории запусков фоновых задач",
+			"@daily",
+			func(ctx context.Context) error { _, err := PurgeJobRuns(ctx); return err },
+		},
+		{
+			JobTasksCleanup, "Удаление выполненных задач очереди",
+			"@daily",
+			func(ctx context.Context) error { _, err := PurgeFinishedTasks(ctx); return err },
+		},
+		{
+			JobWebhookHistoryCleanup, "Очистка outbox и журнала доставок вебхуков",
+			"@daily",
+			func(ctx context.Context) error { _, err := PurgeWebhookHistory(ctx); return err },
+		},
+	}
+
+	for _, d := range defs {
+		spec, enabled := jobSchedule(d.name, d.schedule)
+		if err := scheduler.Register(d.name, d.description, spec, enabled, d.run); err != nil {
+			logger.Error.Printf("service.StartJobs: %v", err)
+			return err
+		}
+	}
+	scheduler.Start(ctx)
+	return nil
+}
+
+// PurgeJobRuns удаляет старую историю запусков.
+func PurgeJobRuns(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.PurgeJobRuns")
+	defer span.End()
+	n, err := repository.PurgeJobRuns(ctx, time.Now().AddDate(0, 0, -defaultJobRunsRetentionDays))
+	if err != nil {
+		logger.Error.Printf("service.PurgeJobRuns: error: %v", err)
+		return 0, err
+	}
+	logger.Info.Printf("service.PurgeJobRuns: removed %d runs", n)
+	return n, nil
+}
+
+// ListJobs возвращает задачи планировщика вместе с последним запуском каждой (на любом экземпляре).
+func ListJobs(ctx context.Context) ([]models.JobInfo, error) {
+	ctx, span := tracing.Start(ctx, "service.ListJobs")
+	defer span.End()
+	jobs := scheduler.List()
+	latest, err := repository.GetLatestJobRuns(ctx)
+	if err != nil {
+		logger.Error.Printf("service.ListJobs: error: %v", err)
+		return nil, err
+	}
+	byName := make(map[string]models.JobRun, len(latest))
+	for _, r := range latest {
+		byName[r.JobName] = r
+	}
+	for i := range jobs {
+		if r, ok := byName[jobs[i].Name]; ok {
+			jobs[i].LastRun = &r
+		}
+	}
+	return jobs, nil
+}
+
+// GetJobRuns возвращает историю запусков задачи.
+func GetJobRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	ctx, span := tracing.Start(ctx, "service.GetJobRuns")
+	defer span.End()
+	if !scheduler.Exists(name) {
+		return nil, errs.ErrNotFound
+	}
+	if limit > maxJobRunsLimit {
+		limit = maxJobRunsLimit
+	}
+	return repository.GetJobRuns(ctx, name, limitOrDefault(limit, defaultJobRunsLimit))
+}
+
+// TriggerJob запускает задачу вручную; выполнение идёт в фоне.
+func TriggerJob(name string) error {
+	if err := scheduler.Trigger(name); err != nil {
+		logger.Warn.Printf("service.TriggerJob: %v", err)
+		return err
+	}
+	logger.Info.Printf("service.TriggerJob: job %s triggered manually", name)
+	return nil
+}
+
+// WaitBackground после отмены контекста фоновых задач ждёт, пока планировщик и обработчики
+// очереди завершат начатую работу, но не дольше ctx.
+func WaitBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		scheduler.Wait()
+		queue.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
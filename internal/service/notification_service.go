@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"Library/internal/config"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/notify"
+	"Library/internal/queue"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+// Значения по умолчанию для уведомлений.
+const (
+	defaultDueReminderHours      = 24
+	defaultNotificationPageLimit = 20
+	maxNotificationPageLimit     = 100
+)
+
+var notificationChannels = []string{models.NotificationChannelInApp, models.NotificationChannelEmail}
+
+// loadNotificationSettings возвращает настройки читателя, дополненные значениями по умолчанию.
+func loadNotificationSettings(ctx context.Context, userID int) (models.NotificationSettings, error) {
+	locale, explicit, err := repository.GetNotificationSettings(ctx, userID)
+	if err != nil {
+		return models.NotificationSettings{}, err
+	}
+	if locale == "" {
+		locale = models.LocaleRU
+	}
+	set := make(map[string]bool, len(explicit))
+	for _, p := range explicit {
+		set[p.Kind+"/"+p.Channel] = p.Enabled
+	}
+
+	s := models.NotificationSettings{Locale: locale, Preferences: []models.NotificationPreference{}}
+	for _, kind := range notify.Kinds() {
+		for _, ch := range notificationChannels {
+			enabled, ok := set[kind+"/"+ch]
+			s.Preferences = append(s.Preferences, models.NotificationPreference{Kind: kind, Channel: ch, Enabled: !ok || enabled})
+		}
+	}
+	return s, nil
+}
+
+func channelEnabled(s models.NotificationSettings, kind, channel string) bool {
+	for _, p := range s.Preferences {
+		if p.Kind == kind && p.Channel == channel {
+			return p.Enabled
+		}
+	}
+	return false
+}
+
+// Notify отправляет читателю уведомление по включённым каналам на его языке.
+// dedupKey делает повторный вызов для того же события безопасным.
+// Письма уходят через очередь задач, поэтому сбой почтового сервера не теряет уведомление.
+func Notify(ctx context.Context, userID int, kind, link, dedupKey string, data map[string]interface{}) error {
+	ctx, span := tracing.Start(ctx, "service.Notify")
+	defer span.End()
+	settings, err := loadNotificationSettings(ctx, userID)
+	if err != nil {
+		logger.Error.Printf("service.Notify: settings error user_id=%d: %v", userID, err)
+		return err
+	}
+	user, err := repository.GetUserByID(ctx, userID, false)
+	if err != nil {
+		logger.Warn.Printf("service.Notify: user_id=%d: %v", userID, err)
+		return err
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["Username"] = user.Username
+
+	title, body, err := notify.Render(kind, settings.Locale, data)
+	if err != nil {
+		logger.Error.Printf("service.Notify: render error kind=%s: %v", kind, err)
+		return err
+	}
+
+	if channelEnabled(settings, kind, models.NotificationChannelInApp) {
+		n := models.Notification{UserID: userID, Kind: kind, Title: title, Body: body, Link: link}
+		if _, err := repository.InsertNotification(ctx, n, dedupKey); err != nil {
+			return err
+		}
+	}
+	if channelEnabled(settings, kind, models.NotificationChannelEmail) && user.Email != "" {
+		msg := notify.Message{To: user.Email, Subject: title, Body: body}
+		opts := models.TaskOptions{}
+		if dedupKey != "" {
+			opts.IdempotencyKey = "notify:email:" + dedupKey
+		}
+		if _, err := EnqueueTask(ctx, TaskNotificationEmail, msg, opts); err != nil {
+			return err
+		}
+	}
+	logger.Info.Printf("service.Notify: %s sent to user_id=%d", kind, userID)
+	return nil
+}
+
+// deliverEmailTask — обработчик задачи отправки письма.
+func deliverEmailTask(ctx context.Context, payload json.RawMessage) error {
+	var msg notify.Message
+	if err := json.Unmarshal(payload, &msg); err != nil || msg.To == "" {
+		return queue.Permanent(fmt.Errorf("invalid email payload: %v", err))
+	}
+	return notify.GetSender().Send(ctx, msg)
+}
+
+// SendDueReminders напоминает читателям о выдачах, которые скоро закончатся.
+// Неотправленное напоминание возвращается в работу и уйдёт при следующем запуске;
+// повтор безопасен, потому что Notify идемпотентен по ключу выдачи.
+func SendDueReminders(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.SendDueReminders")
+	defer span.End()
+	hours := limitOrDefault(config.AppSettings.NotifyParams.DueReminderHours, defaultDueReminderHours)
+	loans, err := repository.ClaimDueLoanReminders(ctx, time.Duration(hours)*time.Hour)
+	if err != nil {
+		logger.Error.Printf("service.SendDueReminders: error: %v", err)
+		return 0, err
+	}
+
+	sent := 0
+	for _, loan := range loans {
+		data := map[string]interface{}{"BookTitle": loan.BookTitle, "ExpiresAt": loan.ExpiresAt}
+		key := models.NotificationKindLoanDueSoon + ":" + strconv.Itoa(loan.ID)
+		if err := Notify(ctx, loan.UserID, models.NotificationKindLoanDueSoon, "/me/loans", key, data); err != nil {
+			logger.Warn.Printf("service.SendDueReminders: loan ID=%d: %v", loan.ID, err)
+			// Удалённому читателю напоминать уже некому
+			if !errors.Is(err, errs.ErrNotFound) {
+				repository.ReleaseLoanReminder(context.WithoutCancel(ctx), loan.ID)
+			}
+			continue
+		}
+		sent++
+	}
+	if len(loans) > 0 {
+		logger.Info.Printf("service.SendDueReminders: reminded %d of %d loans", sent, len(loans))
+	}
+	return sent, nil
+}
+
+// GetMyNotifications возвращает входящие читателя.
+func GetMyNotifications(ctx context.Context, userID int, unreadOnly bool, limit, offset int) (models.NotificationInbox, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMyNotifications")
+	defer span.End()
+	if limit > maxNotificationPageLimit {
+		limit = maxNotificationPageLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	items, err := repository.GetUserNotifications(ctx, userID, unreadOnly, limitOrDefault(limit, defaultNotificationPageLimit), offset)
+	if err != nil {
+		return models.NotificationInbox{}, err
+	}
+	unread, err := repository.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		return models.NotificationInbox{}, err
+	}
+	return models.NotificationInbox{UnreadCount: unread, Items: items}, nil
+}
+
+// SetNotificationRead отмечает уведомление прочитанным или непрочитанным.
+func SetNotificationRead(ctx context.Context, userID int, id int64, read bool) error {
+	ctx, span := tracing.Start(ctx, "service.SetNotificationRead")
+	defer span.End()
+	return repository.SetNotificationRead(ctx, userID, id, read)
+}
+
+// MarkAllNotificationsRead отмечает прочитанными все уведомления читателя.
+func MarkAllNotificationsRead(ctx context.Context, userID int) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.MarkAllNotificationsRead")
+	defer span.End()
+	return repository.MarkAllNotificationsRead(ctx, userID)
+}
+
+// GetNotificationSettings возвращает язык и каналы уведомлений читателя.
+func GetNotificationSettings(ctx context.Context, userID int) (models.NotificationSettings, error) {
+	ctx, span := tracing.Start(ctx, "service.GetNotificationSettings")
+	defer span.End()
+	return loadNotificationSettings(ctx, userID)
+}
+
+// UpdateNotificationSettings меняет язык и/или перечисленные сочетания вида и канала;
+// остальные настройки не трогает.
+func UpdateNotificationSettings(ctx context.Context, userID int, in models.NotificationSettings) (models.NotificationSettings, error) {
+	ctx, span := tracing.Start(ctx, "service.UpdateNotificationSettings")
+	defer span.End()
+	current, err := loadNotificationSettings(ctx, userID)
+	if err != nil {
+		return models.NotificationSettings{}, err
+	}
+	locale := in.Locale
+	switch locale {
+	case "":
+		locale = current.Locale
+	case models.LocaleRU, models.LocaleEN:
+	default:
+		return models.NotificationSettings{}, fmt.Errorf("%w: locale must be %q or %q", errs.ErrValidationFailed, models.LocaleRU, models.LocaleEN)
+	}
+	for _, p := range in.Preferences {
+		if !knownNotificationPreference(current, p) {
+			return models.NotificationSettings{}, fmt.Errorf("%w: unknown notification %s/%s", errs.ErrValidationFailed, p.Kind, p.Channel)
+		}
+	}
+
+	if err := repository.SaveNotificationSettings(ctx, userID, locale, in.Preferences); err != nil {
+		logger.Error.Printf("service.UpdateNotificationSettings: user_id=%d: %v", userID, err)
+		return models.NotificationSettings{}, err
+	}
+	return loadNotificationSettings(ctx, userID)
+}
+
+func knownNotificationPreference(s models.NotificationSettings, p models.NotificationPreference) bool {
+	for _, known := range s.Preferences {
+		if known.Kind == p.Kind && known.Channel == p.Channel {
+			return true
+		}
+	}
+	return false
+}
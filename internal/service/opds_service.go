@@ -0,0 +1,463 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"Library/internal/config"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+// opdsNewestLimit — сколько книг отдаём в фиде «Новые поступления».
+const opdsNewestLimit = 50
+
+// opdsBaseURL возвращает абсолютный адрес сервера для ссылок в фидах.
+func opdsBaseURL() string {
+	return strings.TrimRight(config.AppSettings.AppParams.ServerURL, "/")
+}
+
+func opdsNow() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// newAtomFeed собирает пустой фид с обязательными ссылками self/start/search.
+func newAtomFeed(id, title, selfPath, selfType string) models.AtomFeed {
+	base := opdsBaseURL()
+	return models.AtomFeed{
+		Xmlns:     "http://www.w3.org/2005/Atom",
+		XmlnsDC:   "http://purl.org/dc/terms/",
+		XmlnsOPDS: "http://opds-spec.org/2010/catalog",
+		ID:        "urn:library:opds:" + id,
+		Title:     title,
+		Updated:   opdsNow(),
+		Author:    &models.AtomAuthor{Name: config.AppSettings.AppParams.ServerName, URI: base},
+		Links: []models.AtomLink{
+			{Rel: "self", Href: base + selfPath, Type: selfType},
+			{Rel: "start", Href: base + "/opds", Type: models.OPDSNavigationType},
+			{Rel: "search", Href: base + "/opds/opensearch.xml", Type: models.OPDSOpenSearchType},
+		},
+	}
+}
+
+// opdsBookFiles одним запросом достаёт цифровые файлы книг фида, сгруппированные по book_id.
+func opdsBookFiles(ctx context.Context, books []models.Book) (map[int][]models.BookFile, error) {
+	if len(books) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, 0, len(books))
+	for _, b := range books {
+		ids = append(ids, int64(b.ID))
+	}
+	files, err := repository.GetBookFilesByBookIDs(ctx, ids)
+	if err != nil {
+		logger.Error.Printf("service.opdsBookFiles: error fetching files: %v", err)
+		return nil, err
+	}
+	byBook := make(map[int][]models.BookFile, len(books))
+	for _, f := range files {
+		byBook[f.BookID] = append(byBook[f.BookID], f)
+	}
+	return byBook, nil
+}
+
+// opdsActiveLoans возвращает действующие выдачи читателя по book_id. Анонимному клиенту (userID 0)
+// выдачи не нужны: ему показывается только ссылка на выдачу.
+func opdsActiveLoans(ctx context.Context, userID int) (map[int]models.DigitalLoan, error) {
+	if userID == 0 {
+		return nil, nil
+	}
+	loans, err := repository.GetActiveDigitalLoansByUser(ctx, userID)
+	if err != nil {
+		logger.Error.Printf("service.opdsActiveLoans: error fetching loans user_id=%d: %v", userID, err)
+		return nil, err
+	}
+	byBook := make(map[int]models.DigitalLoan, len(loans))
+	for _, l := range loans {
+		byBook[l.BookID] = l
+	}
+	return byBook, nil
+}
+
+// opdsAcquisitionLinks возвращает ссылки получения книги с цифровыми файлами. Если у читателя
+// есть действующая выдача, это подписанные ссылки на скачивание каждого файла; иначе — ссылка borrow
+// на /opds/books/{id}/borrow, которая оформляет выдачу и отвечает OPDS-entry с такими ссылками.
+func opdsAcquisitionLinks(bookID int, files []models.BookFile, loan *models.DigitalLoan) []models.AtomLink {
+	if len(files) == 0 {
+		return nil
+	}
+	if loan != nil {
+		links := make([]models.AtomLink, 0, len(files))
+		for _, d := range signDownloadLinks(*loan, files) {
+			links = append(links, models.AtomLink{
+				Rel:   models.OPDSAcquisitionRel,
+				Href:  d.URL,
+				Type:  d.ContentType,
+				Title: d.FileName,
+			})
+		}
+		return links
+	}
+
+	borrow := models.AtomLink{
+		Rel:   models.OPDSBorrowRel,
+		Href:  fmt.Sprintf("%s/opds/books/%d/borrow", opdsBaseURL(), bookID),
+		Type:  models.OPDSEntryType,
+		Title: "Взять на время",
+	}
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if !seen[f.ContentType] {
+			seen[f.ContentType] = true
+			borrow.IndirectAcquisition = append(borrow.IndirectAcquisition, models.OPDSIndirectAcquisition{Type: f.ContentType})
+		}
+	}
+	return []models.AtomLink{borrow}
+}
+
+// opdsBookLinks собирает ссылки получения для каждой книги фида: файлы и выдачи читателя
+// загружаются одним запросом на фид.
+func opdsBookLinks(ctx context.Context, books []models.Book, userID int) (map[int][]models.AtomLink, error) {
+	files, err := opdsBookFiles(ctx, books)
+	if err != nil {
+		return nil, err
+	}
+	loans, err := opdsActiveLoans(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	links := make(map[int][]models.AtomLink, len(files))
+	for bookID, fs := range files {
+		var loan *models.DigitalLoan
+		if l, ok := loans[bookID]; ok {
+			loan = &l
+		}
+		links[bookID] = opdsAcquisitionLinks(bookID, fs, loan)
+	}
+	return links, nil
+}
+
+// bookToAtomEntry превращает книгу в элемент acquisition-фида.
+func bookToAtomEntry(b models.Book, acquisition []models.AtomLink) models.AtomEntry {
+	base := opdsBaseURL()
+	return models.AtomEntry{
+		ID:      fmt.Sprintf("urn:library:book:%d", b.ID),
+		Title:   b.Title,
+		Updated: opdsNow(),
+		Authors: []models.AtomAuthor{{
+			Name: b.AuthorName,
+			URI:  fmt.Sprintf("%s/opds/authors/%d", base, b.AuthorID),
+		}},
+		Content: &models.AtomContent{Type: "text", Body: b.Name},
+		Links: append([]models.AtomLink{
+			{Rel: "alternate", Href: fmt.Sprintf("%s/books/%d", base, b.ID), Type: "application/json"},
+			{Rel: "related", Href: fmt.Sprintf("%s/opds/authors/%d", base, b.AuthorID), Type: models.OPDSAcquisitionType, Title: b.AuthorName},
+		}, acquisition...),
+	}
+}
+
+func booksToAtomFeed(ctx context.Context, userID int, id, title, selfPath string, books []models.Book) (models.AtomFeed, error) {
+	links, err := opdsBookLinks(ctx, books, userID)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	feed := newAtomFeed(id, title, selfPath, models.OPDSAcquisitionType)
+	for _, b := range books {
+		feed.Entries = append(feed.Entries, bookToAtomEntry(b, links[b.ID]))
+	}
+	return feed, nil
+}
+
+// BuildOPDSRootFeed возвращает корневой навигационный фид каталога.
+func BuildOPDSRootFeed() models.AtomFeed {
+	logger.Debug.Println("service.BuildOPDSRootFeed: start")
+	base := opdsBaseURL()
+	feed := newAtomFeed("root", "Каталог библиотеки", "/opds", models.OPDSNavigationType)
+	feed.Entries = []models.AtomEntry{
+		{
+			ID:      "urn:library:opds:new",
+			Title:   "Новые поступления",
+			Updated: feed.Updated,
+			Content: &models.AtomContent{Type: "text", Body: "Последние добавленные книги"},
+			Links: []models.AtomLink{
+				{Rel: "http://opds-spec.org/sort/new", Href: base + "/opds/new", Type: models.OPDSAcquisitionType},
+			},
+		},
+		{
+			ID:      "urn:library:opds:authors",
+			Title:   "По авторам",
+			Updated: feed.Updated,
+			Content: &models.AtomContent{Type: "text", Body: "Книги, сгруппированные по авторам"},
+			Links: []models.AtomLink{
+				{Rel: "subsection", Href: base + "/opds/authors", Type: models.OPDSNavigationType},
+			},
+		},
+	}
+	return feed
+}
+
+// BuildOPDSAuthorsFeed возвращает навигационный фид со списком авторов.
+func BuildOPDSAuthorsFeed(ctx context.Context) (models.AtomFeed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDSAuthorsFeed")
+	defer span.End()
+	logger.Debug.Println("service.BuildOPDSAuthorsFeed: start")
+	authors, err := GetAllAuthors(ctx, false)
+	if err != nil {
+		logger.Error.Printf("service.BuildOPDSAuthorsFeed: error fetching authors: %v", err)
+		return models.AtomFeed{}, err
+	}
+
+	base := opdsBaseURL()
+	feed := newAtomFeed("authors", "По авторам", "/opds/authors", models.OPDSNavigationType)
+	for _, a := range authors {
+		feed.Entries = append(feed.Entries, models.AtomEntry{
+			ID:      fmt.Sprintf("urn:library:author:%d", a.ID),
+			Title:   a.Name,
+			Updated: feed.Updated,
+			Links: []models.AtomLink{
+				{Rel: "subsection", Href: fmt.Sprintf("%s/opds/authors/%d", base, a.ID), Type: models.OPDSAcquisitionType},
+			},
+		})
+	}
+	logger.Info.Printf("service.BuildOPDSAuthorsFeed: built feed with %d authors", len(authors))
+	return feed, nil
+}
+
+// BuildOPDSAuthorBooksFeed возвращает acquisition-фид с книгами автора.
+func BuildOPDSAuthorBooksFeed(ctx context.Context, authorID, userID int) (models.AtomFeed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDSAuthorBooksFeed")
+	defer span.End()
+	logger.Debug.Printf("service.BuildOPDSAuthorBooksFeed: start author_id=%d", authorID)
+	author, err := GetAuthorByID(ctx, authorID, false)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	books, err := GetBooksByAuthorID(ctx, authorID)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	feed, err := booksToAtomFeed(ctx, userID,
+		fmt.Sprintf("author:%d", authorID),
+		author.Name,
+		fmt.Sprintf("/opds/authors/%d", authorID),
+		books,
+	)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	feed.Links = append(feed.Links, models.AtomLink{Rel: "up", Href: opdsBaseURL() + "/opds/authors", Type: models.OPDSNavigationType})
+	logger.Info.Printf("service.BuildOPDSAuthorBooksFeed: built feed with %d books", len(books))
+	return feed, nil
+}
+
+// BuildOPDSNewestFeed возвращает acquisition-фид новых поступлений.
+func BuildOPDSNewestFeed(ctx context.Context, userID int) (models.AtomFeed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDSNewestFeed")
+	defer span.End()
+	logger.Debug.Println("service.BuildOPDSNewestFeed: start")
+	books, err := GetNewestBooks(ctx, opdsNewestLimit)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	feed, err := booksToAtomFeed(ctx, userID, "new", "Новые поступления", "/opds/new", books)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	logger.Info.Printf("service.BuildOPDSNewestFeed: built feed with %d books", len(books))
+	return feed, nil
+}
+
+// BuildOPDSSearchFeed возвращает результаты поиска книг в виде acquisition-фида.
+func BuildOPDSSearchFeed(ctx context.Context, query string, userID int) (models.AtomFeed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDSSearchFeed")
+	defer span.End()
+	logger.Debug.Printf("service.BuildOPDSSearchFeed: start query=%q", query)
+	books, err := SearchBooksByName(ctx, query)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	feed, err := booksToAtomFeed(ctx, userID, "search", "Поиск: "+query, "/opds/search?q="+url.QueryEscape(query), books)
+	if err != nil {
+		return models.AtomFeed{}, err
+	}
+	logger.Info.Printf("service.BuildOPDSSearchFeed: built feed with %d books", len(books))
+	return feed, nil
+}
+
+// BorrowOPDSBook обслуживает ссылку borrow из фида: оформляет читателю выдачу и возвращает entry
+// книги с подписанными ссылками на файлы. Повторный переход по той же ссылке не занимает вторую
+// лицензию, а отдаёт действующую выдачу.
+func BorrowOPDSBook(ctx context.Context, bookID, userID int) (models.AtomEntry, error) {
+	ctx, span := tracing.Start(ctx, "service.BorrowOPDSBook")
+	defer span.End()
+	logger.Debug.Printf("service.BorrowOPDSBook: start book_id=%d user_id=%d", bookID, userID)
+	book, err := GetBookByID(ctx, bookID, false)
+	if err != nil {
+		return models.AtomEntry{}, err
+	}
+
+	var loan models.DigitalLoan
+	created, err := CheckoutDigitalBook(ctx, bookID, userID)
+	switch {
+	case err == nil:
+		loan = created.DigitalLoan
+	case errors.Is(err, errs.ErrAlreadyBorrowed):
+		loans, lerr := opdsActiveLoans(ctx, userID)
+		if lerr != nil {
+			return models.AtomEntry{}, lerr
+		}
+		active, ok := loans[bookID]
+		if !ok {
+			return models.AtomEntry{}, err
+		}
+		loan = active
+	default:
+		return models.AtomEntry{}, err
+	}
+
+	files, err := repository.GetBookFilesByBookID(ctx, bookID)
+	if err != nil {
+		return models.AtomEntry{}, err
+	}
+	entry := bookToAtomEntry(book, opdsAcquisitionLinks(bookID, files, &loan))
+	entry.Xmlns = "http://www.w3.org/2005/Atom"
+	entry.XmlnsOPDS = "http://opds-spec.org/2010/catalog"
+	logger.Info.Printf("service.BorrowOPDSBook: loan ID=%d book_id=%d user_id=%d", loan.ID, bookID, userID)
+	return entry, nil
+}
+
+// BuildOpenSearchDescription возвращает OpenSearch-описание поиска книг.
+func BuildOpenSearchDescription() models.OpenSearchDescription {
+	return models.OpenSearchDescription{
+		Xmlns:          "http://a9.com/-/spec/opensearch/1.1/",
+		ShortName:      config.AppSettings.AppParams.ServerName,
+		Description:    "Поиск книг в каталоге библиотеки",
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+		URL: models.OpenSearchURL{
+			Type:     models.OPDSAcquisitionType,
+			Template: opdsBaseURL() + "/opds/search?q={searchTerms}",
+		},
+	}
+}
+
+// newOPDS2Feed собирает пустой OPDS 2.0 фид со стандартными ссылками.
+func newOPDS2Feed(title, selfPath string) models.OPDS2Feed {
+	base := opdsBaseURL()
+	return models.OPDS2Feed{
+		Metadata: models.OPDS2Metadata{Title: title, Modified: opdsNow()},
+		Links: []models.OPDS2Link{
+			{Rel: "self", Href: base + selfPath, Type: models.OPDS2Type},
+			{Rel: "start", Href: base + "/opds/v2", Type: models.OPDS2Type},
+			{Rel: "search", Href: base + "/opds/v2/search{?query}", Type: models.OPDS2Type, Templated: true},
+		},
+	}
+}
+
+func bookToOPDS2Publication(b models.Book, acquisition []models.AtomLink) models.OPDS2Publication {
+	base := opdsBaseURL()
+	links := []models.OPDS2Link{
+		{Rel: "self", Href: fmt.Sprintf("%s/books/%d", base, b.ID), Type: "application/json"},
+	}
+	for _, l := range acquisition {
+		links = append(links, models.OPDS2Link{Rel: l.Rel, Href: l.Href, Type: l.Type, Title: l.Title})
+	}
+	return models.OPDS2Publication{
+		Metadata: models.OPDS2Metadata{
+			Title:      b.Title,
+			Identifier: fmt.Sprintf("urn:library:book:%d", b.ID),
+			Author:     []string{b.AuthorName},
+		},
+		Links: links,
+	}
+}
+
+func booksToOPDS2Feed(ctx context.Context, userID int, title, selfPath string, books []models.Book) (models.OPDS2Feed, error) {
+	links, err := opdsBookLinks(ctx, books, userID)
+	if err != nil {
+		return models.OPDS2Feed{}, err
+	}
+	feed := newOPDS2Feed(title, selfPath)
+	feed.Metadata.NumberOfItems = len(books)
+	feed.Publications = make([]models.OPDS2Publication, 0, len(books))
+	for _, b := range books {
+		feed.Publications = append(feed.Publications, bookToOPDS2Publication(b, links[b.ID]))
+	}
+	return feed, nil
+}
+
+// BuildOPDS2RootFeed возвращает корневой навигационный фид OPDS 2.0.
+func BuildOPDS2RootFeed() models.OPDS2Feed {
+	base := opdsBaseURL()
+	feed := newOPDS2Feed("Каталог библиотеки", "/opds/v2")
+	feed.Navigation = []models.OPDS2Link{
+		{Rel: "http://opds-spec.org/sort/new", Href: base + "/opds/v2/new", Type: models.OPDS2Type, Title: "Новые поступления"},
+		{Rel: "subsection", Href: base + "/opds/v2/authors", Type: models.OPDS2Type, Title: "По авторам"},
+	}
+	return feed
+}
+
+// BuildOPDS2AuthorsFeed возвращает навигационный фид авторов OPDS 2.0.
+func BuildOPDS2AuthorsFeed(ctx context.Context) (models.OPDS2Feed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDS2AuthorsFeed")
+	defer span.End()
+	authors, err := GetAllAuthors(ctx, false)
+	if err != nil {
+		return models.OPDS2Feed{}, err
+	}
+	base := opdsBaseURL()
+	feed := newOPDS2Feed("По авторам", "/opds/v2/authors")
+	for _, a := range authors {
+		feed.Navigation = append(feed.Navigation, models.OPDS2Link{
+			Rel:   "subsection",
+			Href:  fmt.Sprintf("%s/opds/v2/authors/%d", base, a.ID),
+			Type:  models.OPDS2Type,
+			Title: a.Name,
+		})
+	}
+	return feed, nil
+}
+
+// BuildOPDS2AuthorBooksFeed возвращает публикации автора OPDS 2.0.
+func BuildOPDS2AuthorBooksFeed(ctx context.Context, authorID, userID int) (models.OPDS2Feed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDS2AuthorBooksFeed")
+	defer span.End()
+	author, err := GetAuthorByID(ctx, authorID, false)
+	if err != nil {
+		return models.OPDS2Feed{}, err
+	}
+	books, err := GetBooksByAuthorID(ctx, authorID)
+	if err != nil {
+		return models.OPDS2Feed{}, err
+	}
+	return booksToOPDS2Feed(ctx, userID, author.Name, fmt.Sprintf("/opds/v2/authors/%d", authorID), books)
+}
+
+// BuildOPDS2NewestFeed возвращает новые поступления OPDS 2.0.
+func BuildOPDS2NewestFeed(ctx context.Context, userID int) (models.OPDS2Feed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDS2NewestFeed")
+	defer span.End()
+	books, err := GetNewestBooks(ctx, opdsNewestLimit)
+	if err != nil {
+		return models.OPDS2Feed{}, err
+	}
+	return booksToOPDS2Feed(ctx, userID, "Новые поступления", "/opds/v2/new", books)
+}
+
+// BuildOPDS2SearchFeed возвращает результаты поиска OPDS 2.0.
+func BuildOPDS2SearchFeed(ctx context.Context, query string, userID int) (models.OPDS2Feed, error) {
+	ctx, span := tracing.Start(ctx, "service.BuildOPDS2SearchFeed")
+	defer span.End()
+	books, err := SearchBooksByName(ctx, query)
+	if err != nil {
+		return models.OPDS2Feed{}, err
+	}
+	return booksToOPDS2Feed(ctx, userID, "Поиск: "+query, "/opds/v2/search?query="+url.QueryEscape(query), books)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"Library/internal/models"
+	"Library/utils"
+)
+
+var opdsTestFiles = []models.BookFile{
+	{ID: 10, BookID: 1, FileName: "book.epub", ContentType: "application/epub+zip"},
+	{ID: 11, BookID: 1, FileName: "book.pdf", ContentType: "application/pdf"},
+	{ID: 12, BookID: 1, FileName: "book-2.epub", ContentType: "application/epub+zip"},
+}
+
+func TestOPDSAcquisitionLinksWithoutFiles(t *testing.T) {
+	loan := models.DigitalLoan{ID: 5, BookID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	for _, l := range []*models.DigitalLoan{nil, &loan} {
+		if links := opdsAcquisitionLinks(1, nil, l); len(links) != 0 {
+			t.Errorf("book without files got links %+v", links)
+		}
+	}
+}
+
+func TestOPDSAcquisitionLinksBorrow(t *testing.T) {
+	links := opdsAcquisitionLinks(1, opdsTestFiles, nil)
+	if len(links) != 1 {
+		t.Fatalf("got %d links, want a single borrow link: %+v", len(links), links)
+	}
+
+	borrow := links[0]
+	if borrow.Rel != models.OPDSBorrowRel || borrow.Type != models.OPDSEntryType {
+		t.Errorf("borrow link = %+v", borrow)
+	}
+	if !strings.HasSuffix(borrow.Href, "/opds/books/1/borrow") {
+		t.Errorf("borrow href = %q, want the OPDS borrow endpoint", borrow.Href)
+	}
+	wantTypes := []string{"application/epub+zip", "application/pdf"}
+	if len(borrow.IndirectAcquisition) != len(wantTypes) {
+		t.Fatalf("indirect acquisition = %+v, want types %v", borrow.IndirectAcquisition, wantTypes)
+	}
+	for i, typ := range wantTypes {
+		if borrow.IndirectAcquisition[i].Type != typ {
+			t.Errorf("indirect acquisition[%d] = %q, want %q", i, borrow.IndirectAcquisition[i].Type, typ)
+		}
+	}
+}
+
+func TestOPDSAcquisitionLinksActiveLoan(t *testing.T) {
+	loan := models.DigitalLoan{ID: 5, BookID: 1, UserID: 3, ExpiresAt: time.Now().Add(10 * time.Minute)}
+	links := opdsAcquisitionLinks(1, opdsTestFiles, &loan)
+	if len(links) != len(opdsTestFiles) {
+		t.Fatalf("got %d links, want %d: %+v", len(links), len(opdsTestFiles), links)
+	}
+
+	for i, f := range opdsTestFiles {
+		l := links[i]
+		if l.Rel != models.OPDSAcquisitionRel || l.Type != f.ContentType || l.Title != f.FileName {
+			t.Errorf("acquisition link %d = %+v", i, l)
+		}
+		u, err := url.Parse(l.Href)
+		if err != nil {
+			t.Fatalf("acquisition link %d href %q: %v", i, l.Href, err)
+		}
+		if u.Path != "/digital/download" {
+			t.Errorf("acquisition link %d path = %q, want /digital/download", i, u.Path)
+		}
+		q := u.Query()
+		if q.Get("loan") != "5" || q.Get("file") != fmt.Sprint(f.ID) {
+			t.Errorf("acquisition link %d query = %v", i, q)
+		}
+		expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+		if err != nil || expires > loan.ExpiresAt.Unix() {
+			t.Errorf("acquisition link %d expires = %q, must not outlive the loan", i, q.Get("expires"))
+		}
+		if !utils.VerifyDownload(loan.ID, f.ID, expires, q.Get("sig")) {
+			t.Errorf("acquisition link %d signature does not verify", i)
+		}
+	}
+}
+
+func TestBookToAtomEntryXML(t *testing.T) {
+	b := models.Book{ID: 1, Title: "Book", AuthorID: 2, AuthorName: "Author"}
+
+	out, err := xml.Marshal(bookToAtomEntry(b, opdsAcquisitionLinks(1, opdsTestFiles[:1], nil)))
+	if err != nil {
+		t.Fatalf("xml.Marshal: %v", err)
+	}
+	for _, want := range []string{
+		`<entry>`,
+		`rel="http://opds-spec.org/acquisition/borrow" href="/opds/books/1/borrow"`,
+		`<opds:indirectAcquisition type="application/epub+zip"></opds:indirectAcquisition>`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("entry XML %s\nmissing %s", out, want)
+		}
+	}
+	if strings.Contains(string(out), "/books/1/files/") {
+		t.Errorf("entry XML exposes admin-only file URLs: %s", out)
+	}
+
+	out, err = xml.Marshal(bookToAtomEntry(b, nil))
+	if err != nil {
+		t.Fatalf("xml.Marshal: %v", err)
+	}
+	if strings.Contains(string(out), "opds-spec.org/acquisition") {
+		t.Errorf("entry without files has acquisition links: %s", out)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"Library/internal/errs"
+)
+
+// Поля JSON Merge Patch (RFC 7396) приходят как map[string]json.RawMessage:
+// отсутствующий ключ — «не менять», null — «удалить». Обработка null зависит от поля:
+// помощники ниже разбирают обязательные колонки (NOT NULL) и отклоняют null как ошибку
+// валидации, а необязательные выходные данные книги (издательство, год, издание и т. п.)
+// разбирает patchBookMetadata, для которого null означает «очистить».
+
+func patchError(field, msg string) error {
+	return fmt.Errorf("%w: field %q %s", errs.ErrValidationFailed, field, msg)
+}
+
+// patchString разбирает непустую строку.
+func patchString(field string, raw json.RawMessage) (string, error) {
+	if string(raw) == "null" {
+		return "", patchError(field, "cannot be null")
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", patchError(field, "must be a string")
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", patchError(field, "cannot be empty")
+	}
+	return s, nil
+}
+
+// patchSecret разбирает строку как есть, без обрезки пробелов: пароль должен совпадать
+// с тем, что читатель будет вводить при входе.
+func patchSecret(field string, raw json.RawMessage) (string, error) {
+	if string(raw) == "null" {
+		return "", patchError(field, "cannot be null")
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", patchError(field, "must be a string")
+	}
+	if s == "" {
+		return "", patchError(field, "cannot be empty")
+	}
+	return s, nil
+}
+
+// patchPositiveInt разбирает положительное целое.
+func patchPositiveInt(field string, raw json.RawMessage) (int, error) {
+	if string(raw) == "null" {
+		return 0, patchError(field, "cannot be null")
+	}
+	var n int
+	if err := json.Unmarshal(raw, &n); err != nil || n <= 0 {
+		return 0, patchError(field, "must be a positive integer")
+	}
+	return n, nil
+}
+
+// patchEmail разбирает адрес электронной почты.
+func patchEmail(field string, raw json.RawMessage) (string, error) {
+	s, err := patchString(field, raw)
+	if err != nil {
+		return "", err
+	}
+	if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+		return "", patchError(field, "must be a valid email")
+	}
+	return s, nil
+}
+
+func unknownPatchField(field string) error {
+	return patchError(field, "is unknown or read-only")
+}
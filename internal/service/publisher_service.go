@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+func validatePublisher(p *models.Publisher) error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Country = strings.TrimSpace(p.Country)
+	p.Website = strings.TrimSpace(p.Website)
+	if p.Name == "" {
+		return fmt.Errorf("%w: publisher name is required", errs.ErrValidationFailed)
+	}
+	if p.Website != "" {
+		if u, err := url.Parse(p.Website); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: website must be an http(s) URL", errs.ErrValidationFailed)
+		}
+	}
+	return nil
+}
+
+// GetAllPublishers возвращает всех издателей с логированием.
+func GetAllPublishers(ctx context.Context) ([]models.Publisher, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllPublishers")
+	defer span.End()
+	logger.Debug.Println("service.GetAllPublishers: start")
+	publishers, err := repository.GetAllPublishers(ctx)
+	if err != nil {
+		logger.Error.Printf("service.GetAllPublishers: error fetching publishers: %v", err)
+		return nil, err
+	}
+	return publishers, nil
+}
+
+// GetPublisherByID возвращает издателя по ID с логированием.
+func GetPublisherByID(ctx context.Context, publisherID int) (models.Publisher, error) {
+	ctx, span := tracing.Start(ctx, "service.GetPublisherByID")
+	defer span.End()
+	logger.Debug.Printf("service.GetPublisherByID: start id=%d", publisherID)
+	p, err := repository.GetPublisherByID(ctx, publisherID)
+	if err != nil {
+		logger.Error.Printf("service.GetPublisherByID: error fetching publisher id=%d: %v", publisherID, err)
+		return models.Publisher{}, err
+	}
+	return p, nil
+}
+
+// CreatePublisher создаёт издателя с логированием.
+func CreatePublisher(ctx context.Context, p *models.Publisher, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.CreatePublisher")
+	defer span.End()
+	logger.Debug.Printf("service.CreatePublisher: start name=%q", p.Name)
+	if err := validatePublisher(p); err != nil {
+		return err
+	}
+	if err := repository.CreatePublisher(ctx, p, actor); err != nil {
+		logger.Error.Printf("service.CreatePublisher: error creating publisher name=%q: %v", p.Name, err)
+		return err
+	}
+	logger.Info.Printf("service.CreatePublisher: created publisher ID=%d", p.ID)
+	return nil
+}
+
+// UpdatePublisher обновляет издателя с логированием.
+func UpdatePublisher(ctx context.Context, p *models.Publisher, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.UpdatePublisher")
+	defer span.End()
+	logger.Debug.Printf("service.UpdatePublisher: start ID=%d name=%q", p.ID, p.Name)
+	if err := validatePublisher(p); err != nil {
+		return err
+	}
+	if err := repository.UpdatePublisher(ctx, p, actor); err != nil {
+		logger.Error.Printf("service.UpdatePublisher: error updating publisher ID=%d: %v", p.ID, err)
+		return err
+	}
+	logger.Info.Printf("service.UpdatePublisher: updated publisher ID=%d", p.ID)
+	return nil
+}
+
+// DeletePublisherByID удаляет издателя с логированием.
+func DeletePublisherByID(ctx context.Context, publisherID int, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.DeletePublisherByID")
+	defer span.End()
+	logger.Debug.Printf("service.DeletePublisherByID: start id=%d", publisherID)
+	if err := repository.DeletePublisherByID(ctx, publisherID, actor); err != nil {
+		logger.Error.Printf("service.DeletePublisherByID: error deleting publisher id=%d: %v", publisherID, err)
+		return err
+	}
+	logger.Info.Printf("service.DeletePublisherByID: deleted publisher ID=%d", publisherID)
+	return nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"Library/internal/config"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+// Значения по умолчанию для очистки мягко удалённых записей.
+const (
+	defaultRetentionDays        = 30
+	defaultPurgeIntervalMinutes = 60
+)
+
+// PurgeSoftDeleted окончательно удаляет записи, удалённые раньше срока хранения,
+// и освобождает файлы книг, на которые больше никто не ссылается.
+func PurgeSoftDeleted(ctx context.Context) (models.PurgeResult, error) {
+	ctx, span := tracing.Start(ctx, "service.PurgeSoftDeleted")
+	defer span.End()
+	days := limitOrDefault(config.AppSettings.SoftDeleteParams.RetentionDays, defaultRetentionDays)
+	olderThan := time.Now().AddDate(0, 0, -days)
+	logger.Debug.Printf("service.PurgeSoftDeleted: start retention=%dd", days)
+
+	hashes, err := repository.GetBlobHashesOfPurgeableBooks(ctx, olderThan)
+	if err != nil {
+		return models.PurgeResult{}, err
+	}
+	res, err := repository.PurgeSoftDeleted(ctx, olderThan)
+	if err != nil {
+		logger.Error.Printf("service.PurgeSoftDeleted: error: %v", err)
+		return models.PurgeResult{}, err
+	}
+	for _, h := range hashes {
+		releaseBlob(ctx, h)
+	}
+	logger.Info.Printf("service.PurgeSoftDeleted: purged books=%d authors=%d users=%d", res.Books, res.Authors, res.Users)
+	return res, nil
+}
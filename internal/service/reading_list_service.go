@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Library/internal/db"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+const (
+	maxReadingListName = 100
+	maxReadingListNote = 1000
+)
+
+// readingShelves — стандартные полки, которые есть у каждого читателя.
+var readingShelves = []models.ReadingList{
+	{Kind: models.ReadingListWantToRead, Name: "Хочу прочитать"},
+	{Kind: models.ReadingListReading, Name: "Читаю"},
+	{Kind: models.ReadingListFinished, Name: "Прочитано"},
+	{Kind: models.ReadingListFavourites, Name: "Избранное"},
+}
+
+// newShareToken генерирует непредсказуемый токен для ссылки на список.
+func newShareToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// withShareURL заполняет ссылку для публичных списков.
+func withShareURL(l *models.ReadingList) {
+	if l.Visibility == models.ReadingListPublic {
+		l.ShareURL = "/lists/shared/" + l.ShareToken
+	}
+}
+
+func validateReadingList(l *models.ReadingList) error {
+	l.Name = strings.TrimSpace(l.Name)
+	if l.Name == "" || utf8.RuneCountInString(l.Name) > maxReadingListName {
+		return fmt.Errorf("%w: list name must be 1..%d characters", errs.ErrValidationFailed, maxReadingListName)
+	}
+	if l.Visibility == "" {
+		l.Visibility = models.ReadingListPrivate
+	}
+	if l.Visibility != models.ReadingListPrivate && l.Visibility != models.ReadingListPublic {
+		return fmt.Errorf("%w: visibility must be private or public", errs.ErrValidationFailed)
+	}
+	return nil
+}
+
+// GetMyReadingLists возвращает списки читателя, при первом обращении создавая стандартные полки.
+func GetMyReadingLists(ctx context.Context, userID int) ([]models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMyReadingLists")
+	defer span.End()
+	logger.Debug.Printf("service.GetMyReadingLists: start user_id=%d", userID)
+	shelves := make([]models.ReadingList, len(readingShelves))
+	for i, s := range readingShelves {
+		token, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		s.ShareToken = token
+		shelves[i] = s
+	}
+	if err := repository.EnsureReadingShelves(ctx, userID, shelves); err != nil {
+		logger.Error.Printf("service.GetMyReadingLists: error creating shelves user_id=%d: %v", userID, err)
+		return nil, err
+	}
+	// Полки могли только что создаться, а реплика — ещё не получить их: читаем с основной базы
+	lists, err := repository.GetUserReadingLists(db.UsePrimary(ctx), userID)
+	if err != nil {
+		logger.Error.Printf("service.GetMyReadingLists: error fetching lists user_id=%d: %v", userID, err)
+		return nil, err
+	}
+	for i := range lists {
+		withShareURL(&lists[i])
+	}
+	return lists, nil
+}
+
+// GetMyReadingList возвращает список читателя вместе с книгами.
+func GetMyReadingList(ctx context.Context, listID, userID int) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "service.GetMyReadingList")
+	defer span.End()
+	logger.Debug.Printf("service.GetMyReadingList: start id=%d user_id=%d", listID, userID)
+	l, err := repository.GetReadingList(ctx, listID, userID)
+	if err != nil {
+		logger.Error.Printf("service.GetMyReadingList: error fetching list id=%d: %v", listID, err)
+		return models.ReadingList{}, err
+	}
+	if l.Items, err = repository.GetReadingListItems(ctx, l.ID); err != nil {
+		return models.ReadingList{}, err
+	}
+	withShareURL(&l)
+	return l, nil
+}
+
+// GetSharedReadingList возвращает публичный список по токену ссылки.
+func GetSharedReadingList(ctx context.Context, token string) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSharedReadingList")
+	defer span.End()
+	logger.Debug.Println("service.GetSharedReadingList: start")
+	l, err := repository.GetPublicReadingList(ctx, token)
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+	if l.Items, err = repository.GetReadingListItems(ctx, l.ID); err != nil {
+		logger.Error.Printf("service.GetSharedReadingList: error fetching items list_id=%d: %v", l.ID, err)
+		return models.ReadingList{}, err
+	}
+	withShareURL(&l)
+	return l, nil
+}
+
+// CreateReadingList создаёт именованный список читателя с логированием.
+func CreateReadingList(ctx context.Context, l *models.ReadingList) error {
+	ctx, span := tracing.Start(ctx, "service.CreateReadingList")
+	defer span.End()
+	logger.Debug.Printf("service.CreateReadingList: start user_id=%d name=%q", l.UserID, l.Name)
+	if err := validateReadingList(l); err != nil {
+		return err
+	}
+	token, err := newShareToken()
+	if err != nil {
+		return err
+	}
+	l.Kind = models.ReadingListCustom
+	l.ShareToken = token
+	if err := repository.CreateReadingList(ctx, l); err != nil {
+		logger.Error.Printf("service.CreateReadingList: error creating list user_id=%d: %v", l.UserID, err)
+		return err
+	}
+	withShareURL(l)
+	logger.Info.Printf("service.CreateReadingList: created list ID=%d", l.ID)
+	return nil
+}
+
+// UpdateReadingList меняет название и видимость списка с логированием.
+func UpdateReadingList(ctx context.Context, l *models.ReadingList) error {
+	ctx, span := tracing.Start(ctx, "service.UpdateReadingList")
+	defer span.End()
+	logger.Debug.Printf("service.UpdateReadingList: start id=%d", l.ID)
+	if err := validateReadingList(l); err != nil {
+		return err
+	}
+	if err := repository.UpdateReadingList(ctx, l); err != nil {
+		logger.Error.Printf("service.UpdateReadingList: error updating list id=%d: %v", l.ID, err)
+		return err
+	}
+	withShareURL(l)
+	logger.Info.Printf("service.UpdateReadingList: updated list ID=%d", l.ID)
+	return nil
+}
+
+// DeleteReadingList удаляет именованный список с логированием.
+func DeleteReadingList(ctx context.Context, listID, userID int) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteReadingList")
+	defer span.End()
+	logger.Debug.Printf("service.DeleteReadingList: start id=%d user_id=%d", listID, userID)
+	if err := repository.DeleteReadingList(ctx, listID, userID); err != nil {
+		logger.Error.Printf("service.DeleteReadingList: error deleting list id=%d: %v", listID, err)
+		return err
+	}
+	logger.Info.Printf("service.DeleteReadingList: deleted list ID=%d", listID)
+	return nil
+}
+
+// RotateReadingListShareLink выдаёт списку новую ссылку; старая перестаёт открываться.
+func RotateReadingListShareLink(ctx context.Context, listID, userID int) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "service.RotateReadingListShareLink")
+	defer span.End()
+	logger.Debug.Printf("service.RotateReadingListShareLink: start id=%d", listID)
+	token, err := newShareToken()
+	if err != nil {
+		return models.ReadingList{}, err
+	}
+	if err := repository.SetReadingListShareToken(ctx, listID, userID, token); err != nil {
+		logger.Error.Printf("service.RotateReadingListShareLink: error id=%d: %v", listID, err)
+		return models.ReadingList{}, err
+	}
+	return GetMyReadingList(ctx, listID, userID)
+}
+
+// AddReadingListItem добавляет книгу в список и возвращает обновлённый список.
+func AddReadingListItem(ctx context.Context, listID, userID, bookID int, note string) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "service.AddReadingListItem")
+	defer span.End()
+	logger.Debug.Printf("service.AddReadingListItem: start list_id=%d book_id=%d", listID, bookID)
+	if bookID <= 0 {
+		return models.ReadingList{}, fmt.Errorf("%w: book_id must be positive", errs.ErrValidationFailed)
+	}
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxReadingListNote {
+		return models.ReadingList{}, fmt.Errorf("%w: note must be at most %d characters", errs.ErrValidationFailed, maxReadingListNote)
+	}
+	if err := repository.AddReadingListItem(ctx, listID, userID, bookID, note); err != nil {
+		logger.Error.Printf("service.AddReadingListItem: error list_id=%d: %v", listID, err)
+		return models.ReadingList{}, err
+	}
+	return GetMyReadingList(ctx, listID, userID)
+}
+
+// RemoveReadingListItem убирает книгу из списка с логированием.
+func RemoveReadingListItem(ctx context.Context, listID, userID, bookID int) error {
+	ctx, span := tracing.Start(ctx, "service.RemoveReadingListItem")
+	defer span.End()
+	logger.Debug.Printf("service.RemoveReadingListItem: start list_id=%d book_id=%d", listID, bookID)
+	if err := repository.RemoveReadingListItem(ctx, listID, userID, bookID); err != nil {
+		logger.Error.Printf("service.RemoveReadingListItem: error list_id=%d: %v", listID, err)
+		return err
+	}
+	return nil
+}
+
+// ReorderReadingList расставляет книги списка в заданном порядке.
+func ReorderReadingList(ctx context.Context, listID, userID int, bookIDs []int) (models.ReadingList, error) {
+	ctx, span := tracing.Start(ctx, "service.ReorderReadingList")
+	defer span.End()
+	logger.Debug.Printf("service.ReorderReadingList: start list_id=%d", listID)
+	seen := make(map[int]bool, len(bookIDs))
+	ids := make([]int64, 0, len(bookIDs))
+	for _, id := range bookIDs {
+		if seen[id] {
+			return models.ReadingList{}, fmt.Errorf("%w: book %d is listed twice", errs.ErrValidationFailed, id)
+		}
+		seen[id] = true
+		ids = append(ids, int64(id))
+	}
+	if err := repository.ReorderReadingList(ctx, listID, userID, ids); err != nil {
+		logger.Error.Printf("service.ReorderReadingList: error list_id=%d: %v", listID, err)
+		return models.ReadingList{}, err
+	}
+	return GetMyReadingList(ctx, listID, userID)
+}
+
+// ReadingListCSV выгружает книги списка в CSV (UTF-8, первая строка — заголовки).
+func ReadingListCSV(l models.ReadingList) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"position", "book_id", "title", "author", "publisher", "published_year", "language", "rating_avg", "note", "added_at"})
+	for _, it := range l.Items {
+		_ = w.Write([]string{
+			strconv.Itoa(it.Position),
+			strconv.Itoa(it.ID),
+			csvText(it.Title),
+			csvText(it.AuthorName),
+			csvText(derefString(it.PublisherName)),
+			derefInt(it.PublishedYear),
+			csvText(derefString(it.Language)),
+			derefFloat(it.RatingAvg),
+			csvText(it.Note),
+			it.AddedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logger.Error.Printf("service.ReadingListCSV: write error list_id=%d: %v", l.ID, err)
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvText защищает текстовую ячейку от CSV-инъекции: значение, которое табличный редактор
+// принял бы за формулу (начинается с =, +, -, @, табуляции или CR), получает префикс '.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+func derefFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', 2, 64)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"Library/internal/models"
+)
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"War and Peace", "War and Peace"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1+1", "'+1+1"},
+		{"-2+3", "'-2+3"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\tindented", "'\tindented"},
+		{"\rcarriage", "'\rcarriage"},
+		{"a=b", "a=b"},
+		{" =trimmed later", " =trimmed later"},
+		{"'quoted", "'quoted"},
+		{"Ёлка", "Ёлка"},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReadingListCSV(t *testing.T) {
+	publisher := "=cmd|' /C calc'!A0"
+	lang := "ru"
+	year := 1869
+	rating := 4.5
+	added := time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("MSK", 3*60*60))
+
+	l := models.ReadingList{
+		ID: 7,
+		Items: []models.ReadingListItem{
+			{
+				Position: 1,
+				Note:     "re-read, \"slowly\"\nsecond line",
+				AddedAt:  added,
+				Book: models.Book{
+					ID:            42,
+					Title:         "War and Peace, vol. 1",
+					AuthorName:    "Leo Tolstoy",
+					PublisherName: &publisher,
+					PublishedYear: &year,
+					Language:      &lang,
+					RatingAvg:     &rating,
+				},
+			},
+			{
+				Position: 2,
+				Note:     "-1 star",
+				AddedAt:  added,
+				Book: models.Book{
+					ID:         43,
+					Title:      "@home",
+					AuthorName: "+Anon",
+				},
+			},
+		},
+	}
+
+	out, err := ReadingListCSV(l)
+	if err != nil {
+		t.Fatalf("ReadingListCSV: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	want := [][]string{
+		{"position", "book_id", "title", "author", "publisher", "published_year", "language", "rating_avg", "note", "added_at"},
+		{"1", "42", "War and Peace, vol. 1", "Leo Tolstoy", "'=cmd|' /C calc'!A0", "1869", "ru", "4.50", "re-read, \"slowly\"\nsecond line", "2024-05-01T09:30:00Z"},
+		{"2", "43", "'@home", "'+Anon", "", "", "", "", "'-1 star", "2024-05-01T09:30:00Z"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("ReadingListCSV records =\n%q\nwant\n%q", records, want)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"Library/internal/config"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+	"context"
+)
+
+// Значения по умолчанию для рекомендаций.
+const (
+	defaultRecommendIntervalMinutes = 30
+	defaultRecommendTopN            = 20
+	recommendBatchSize              = 200
+	defaultRecommendLimit           = 10
+	maxRecommendLimit               = 50
+)
+
+func recommendLimit(limit int) int {
+	if limit <= 0 {
+		return defaultRecommendLimit
+	}
+	if limit > maxRecommendLimit {
+		return maxRecommendLimit
+	}
+	return limit
+}
+
+// GetSimilarBooks возвращает похожие книги: сначала те, что брали те же читатели,
+// затем книги того же автора и из тех же рубрик.
+func GetSimilarBooks(ctx context.Context, bookID, limit int) ([]models.Recommendation, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSimilarBooks")
+	defer span.End()
+	logger.Debug.Printf("service.GetSimilarBooks: start book_id=%d limit=%d", bookID, limit)
+	if _, err := repository.GetBookByID(ctx, bookID, false); err != nil {
+		return nil, err
+	}
+	recs, err := repository.GetSimilarBooks(ctx, bookID, recommendLimit(limit))
+	if err != nil {
+		logger.Error.Printf("service.GetSimilarBooks: error book_id=%d: %v", bookID, err)
+		return nil, err
+	}
+	return recs, nil
+}
+
+// GetUserRecommendations возвращает персональные рекомендации читателю.
+func GetUserRecommendations(ctx context.Context, userID, limit int) ([]models.Recommendation, error) {
+	ctx, span := tracing.Start(ctx, "service.GetUserRecommendations")
+	defer span.End()
+	logger.Debug.Printf("service.GetUserRecommendations: start user_id=%d limit=%d", userID, limit)
+	recs, err := repository.GetUserRecommendations(ctx, userID, recommendLimit(limit))
+	if err != nil {
+		logger.Error.Printf("service.GetUserRecommendations: error user_id=%d: %v", userID, err)
+		return nil, err
+	}
+	return recs, nil
+}
+
+// RefreshRecommendations пересчитывает таблицу похожих книг по новой активности читателей.
+func RefreshRecommendations(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.RefreshRecommendations")
+	defer span.End()
+	topN := limitOrDefault(config.AppSettings.RecommendParams.TopN, defaultRecommendTopN)
+	logger.Debug.Printf("service.RefreshRecommendations: start top_n=%d", topN)
+	n, err := repository.RefreshBookSimilarities(ctx, topN, recommendBatchSize)
+	if err != nil {
+		logger.Error.Printf("service.RefreshRecommendations: error: %v", err)
+		return 0, err
+	}
+	logger.Info.Printf("service.RefreshRecommendations: recomputed %d books", n)
+	return n, nil
+}
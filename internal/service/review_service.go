@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+const maxReviewLength = 5000
+
+func validateReview(r *models.Review) error {
+	if r.Rating < 1 || r.Rating > 5 {
+		return fmt.Errorf("%w: rating must be between 1 and 5", errs.ErrValidationFailed)
+	}
+	r.Text = strings.TrimSpace(r.Text)
+	if utf8.RuneCountInString(r.Text) > maxReviewLength {
+		return fmt.Errorf("%w: review text must be at most %d characters", errs.ErrValidationFailed, maxReviewLength)
+	}
+	return nil
+}
+
+// GetBookReviews возвращает отзывы о книге с логированием.
+func GetBookReviews(ctx context.Context, bookID int, includeHidden bool) ([]models.Review, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBookReviews")
+	defer span.End()
+	logger.Debug.Printf("service.GetBookReviews: start book_id=%d include_hidden=%t", bookID, includeHidden)
+	if _, err := repository.GetBookByID(ctx, bookID, false); err != nil {
+		return nil, err
+	}
+	reviews, err := repository.GetBookReviews(ctx, bookID, includeHidden)
+	if err != nil {
+		logger.Error.Printf("service.GetBookReviews: error fetching reviews book_id=%d: %v", bookID, err)
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// CreateReview публикует отзыв читателя с логированием.
+func CreateReview(ctx context.Context, r *models.Review, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.CreateReview")
+	defer span.End()
+	logger.Debug.Printf("service.CreateReview: start book_id=%d user_id=%d", r.BookID, r.UserID)
+	if err := validateReview(r); err != nil {
+		logger.Warn.Printf("service.CreateReview: invalid review: %v", err)
+		return err
+	}
+	if err := repository.CreateReview(ctx, r, actor); err != nil {
+		logger.Error.Printf("service.CreateReview: error creating review book_id=%d: %v", r.BookID, err)
+		return err
+	}
+	logger.Info.Printf("service.CreateReview: created review ID=%d", r.ID)
+	return nil
+}
+
+// UpdateReview правит собственный отзыв читателя с логированием.
+func UpdateReview(ctx context.Context, r *models.Review, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.UpdateReview")
+	defer span.End()
+	logger.Debug.Printf("service.UpdateReview: start id=%d user_id=%d", r.ID, r.UserID)
+	if err := validateReview(r); err != nil {
+		logger.Warn.Printf("service.UpdateReview: invalid review id=%d: %v", r.ID, err)
+		return err
+	}
+	if err := repository.UpdateReview(ctx, r, actor); err != nil {
+		logger.Error.Printf("service.UpdateReview: error updating review id=%d: %v", r.ID, err)
+		return err
+	}
+	logger.Info.Printf("service.UpdateReview: updated review ID=%d", r.ID)
+	return nil
+}
+
+// DeleteReview удаляет отзыв с логированием.
+func DeleteReview(ctx context.Context, reviewID, userID int, asAdmin bool, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteReview")
+	defer span.End()
+	logger.Debug.Printf("service.DeleteReview: start id=%d user_id=%d admin=%t", reviewID, userID, asAdmin)
+	if err := repository.DeleteReview(ctx, reviewID, userID, asAdmin, actor); err != nil {
+		logger.Error.Printf("service.DeleteReview: error deleting review id=%d: %v", reviewID, err)
+		return err
+	}
+	logger.Info.Printf("service.DeleteReview: deleted review ID=%d", reviewID)
+	return nil
+}
+
+// ModerateReview меняет статус отзыва (скрыть/одобрить) с логированием.
+func ModerateReview(ctx context.Context, reviewID int, status string, actor models.AuditActor) (models.Review, error) {
+	ctx, span := tracing.Start(ctx, "service.ModerateReview")
+	defer span.End()
+	logger.Debug.Printf("service.ModerateReview: start id=%d status=%q", reviewID, status)
+	if status != models.ReviewStatusApproved && status != models.ReviewStatusHidden {
+		return models.Review{}, fmt.Errorf("%w: unknown review status %q", errs.ErrValidationFailed, status)
+	}
+	r, err := repository.SetReviewStatus(ctx, reviewID, status, actor)
+	if err != nil {
+		logger.Error.Printf("service.ModerateReview: error moderating review id=%d: %v", reviewID, err)
+		return models.Review{}, err
+	}
+	logger.Info.Printf("service.ModerateReview: review ID=%d is now %s", reviewID, status)
+	return r, nil
+}
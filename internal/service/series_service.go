@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+// maxSeriesPosition — верхняя граница позиции, которую вмещает NUMERIC(8,2).
+const maxSeriesPosition = 999999.99
+
+func validateSeries(s *models.Series) error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Description = strings.TrimSpace(s.Description)
+	if s.Name == "" {
+		return fmt.Errorf("%w: series name is required", errs.ErrValidationFailed)
+	}
+	return nil
+}
+
+// validateSeriesPosition допускает положительные позиции не более чем с двумя знаками после запятой.
+func validateSeriesPosition(position float64) error {
+	if math.IsNaN(position) || position <= 0 || position > maxSeriesPosition {
+		return fmt.Errorf("%w: position must be between 0 and %g", errs.ErrValidationFailed, maxSeriesPosition)
+	}
+	if rounded, _ := strconv.ParseFloat(strconv.FormatFloat(position, 'f', 2, 64), 64); rounded != position {
+		return fmt.Errorf("%w: position must have at most two decimal places", errs.ErrValidationFailed)
+	}
+	return nil
+}
+
+// GetAllSeries возвращает список серий с логированием.
+func GetAllSeries(ctx context.Context) ([]models.Series, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllSeries")
+	defer span.End()
+	logger.Debug.Println("service.GetAllSeries: start")
+	series, err := repository.GetAllSeries(ctx)
+	if err != nil {
+		logger.Error.Printf("service.GetAllSeries: error fetching series: %v", err)
+		return nil, err
+	}
+	return series, nil
+}
+
+// GetSeriesByID возвращает серию вместе с книгами в порядке чтения.
+func GetSeriesByID(ctx context.Context, seriesID int) (models.Series, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSeriesByID")
+	defer span.End()
+	logger.Debug.Printf("service.GetSeriesByID: start id=%d", seriesID)
+	s, err := repository.GetSeriesByID(ctx, seriesID)
+	if err != nil {
+		logger.Error.Printf("service.GetSeriesByID: error fetching series id=%d: %v", seriesID, err)
+		return models.Series{}, err
+	}
+	if s.Books, err = repository.GetSeriesBooks(ctx, seriesID); err != nil {
+		logger.Error.Printf("service.GetSeriesByID: error fetching books series_id=%d: %v", seriesID, err)
+		return models.Series{}, err
+	}
+	logger.Info.Printf("service.GetSeriesByID: returned series ID=%d with %d books", s.ID, len(s.Books))
+	return s, nil
+}
+
+// CreateSeries создаёт серию с логированием.
+func CreateSeries(ctx context.Context, s *models.Series, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.CreateSeries")
+	defer span.End()
+	logger.Debug.Printf("service.CreateSeries: start name=%q", s.Name)
+	if err := validateSeries(s); err != nil {
+		return err
+	}
+	if err := repository.CreateSeries(ctx, s, actor); err != nil {
+		logger.Error.Printf("service.CreateSeries: error creating series name=%q: %v", s.Name, err)
+		return err
+	}
+	logger.Info.Printf("service.CreateSeries: created series ID=%d", s.ID)
+	return nil
+}
+
+// UpdateSeries обновляет название и описание серии с логированием.
+func UpdateSeries(ctx context.Context, s *models.Series, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.UpdateSeries")
+	defer span.End()
+	logger.Debug.Printf("service.UpdateSeries: start ID=%d name=%q", s.ID, s.Name)
+	if err := validateSeries(s); err != nil {
+		return err
+	}
+	if err := repository.UpdateSeries(ctx, s, actor); err != nil {
+		logger.Error.Printf("service.UpdateSeries: error updating series ID=%d: %v", s.ID, err)
+		return err
+	}
+	logger.Info.Printf("service.UpdateSeries: updated series ID=%d", s.ID)
+	return nil
+}
+
+// DeleteSeriesByID удаляет серию с логированием.
+func DeleteSeriesByID(ctx context.Context, seriesID int, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteSeriesByID")
+	defer span.End()
+	logger.Debug.Printf("service.DeleteSeriesByID: start id=%d", seriesID)
+	if err := repository.DeleteSeriesByID(ctx, seriesID, actor); err != nil {
+		logger.Error.Printf("service.DeleteSeriesByID: error deleting series id=%d: %v", seriesID, err)
+		return err
+	}
+	logger.Info.Printf("service.DeleteSeriesByID: deleted series ID=%d", seriesID)
+	return nil
+}
+
+// SetSeriesBookPosition ставит книгу в серию на указанную позицию и возвращает обновлённую серию.
+func SetSeriesBookPosition(ctx context.Context, seriesID, bookID int, position float64, actor models.AuditActor) (models.Series, error) {
+	ctx, span := tracing.Start(ctx, "service.SetSeriesBookPosition")
+	defer span.End()
+	logger.Debug.Printf("service.SetSeriesBookPosition: start series_id=%d book_id=%d position=%g", seriesID, bookID, position)
+	if err := validateSeriesPosition(position); err != nil {
+		return models.Series{}, err
+	}
+	if err := repository.SetSeriesBookPosition(ctx, seriesID, bookID, position, actor); err != nil {
+		logger.Error.Printf("service.SetSeriesBookPosition: error series_id=%d book_id=%d: %v", seriesID, bookID, err)
+		return models.Series{}, err
+	}
+	return GetSeriesByID(ctx, seriesID)
+}
+
+// RemoveBookFromSeries исключает книгу из серии с логированием.
+func RemoveBookFromSeries(ctx context.Context, seriesID, bookID int, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.RemoveBookFromSeries")
+	defer span.End()
+	logger.Debug.Printf("service.RemoveBookFromSeries: start series_id=%d book_id=%d", seriesID, bookID)
+	if err := repository.RemoveBookFromSeries(ctx, seriesID, bookID, actor); err != nil {
+		logger.Error.Printf("service.RemoveBookFromSeries: error series_id=%d book_id=%d: %v", seriesID, bookID, err)
+		return err
+	}
+	logger.Info.Printf("service.RemoveBookFromSeries: removed book_id=%d from series_id=%d", bookID, seriesID)
+	return nil
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"Library/internal/models"
+	"Library/internal/repository"
+)
+
+// GetLibraryStats возвращает бизнес-показатели для /metrics.
+func GetLibraryStats(ctx context.Context) (models.LibraryStats, error) {
+	return repository.GetLibraryStats(ctx)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+func validateSubject(ctx context.Context, s *models.Subject) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return fmt.Errorf("%w: subject name is required", errs.ErrValidationFailed)
+	}
+	if s.ParentID != nil {
+		if _, err := repository.GetSubjectByID(ctx, *s.ParentID); err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return fmt.Errorf("%w: unknown parent subject", errs.ErrValidationFailed)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllSubjects возвращает рубрикатор целиком с логированием.
+func GetAllSubjects(ctx context.Context) ([]models.Subject, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllSubjects")
+	defer span.End()
+	logger.Debug.Println("service.GetAllSubjects: start")
+	subjects, err := repository.GetAllSubjects(ctx)
+	if err != nil {
+		logger.Error.Printf("service.GetAllSubjects: error fetching subjects: %v", err)
+		return nil, err
+	}
+	return subjects, nil
+}
+
+// GetSubjectByID возвращает рубрику по ID с логированием.
+func GetSubjectByID(ctx context.Context, subjectID int) (models.Subject, error) {
+	ctx, span := tracing.Start(ctx, "service.GetSubjectByID")
+	defer span.End()
+	logger.Debug.Printf("service.GetSubjectByID: start id=%d", subjectID)
+	s, err := repository.GetSubjectByID(ctx, subjectID)
+	if err != nil {
+		logger.Error.Printf("service.GetSubjectByID: error fetching subject id=%d: %v", subjectID, err)
+		return models.Subject{}, err
+	}
+	return s, nil
+}
+
+// CreateSubject создаёт рубрику с логированием.
+func CreateSubject(ctx context.Context, s *models.Subject, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.CreateSubject")
+	defer span.End()
+	logger.Debug.Printf("service.CreateSubject: start name=%q", s.Name)
+	if err := validateSubject(ctx, s); err != nil {
+		return err
+	}
+	if err := repository.CreateSubject(ctx, s, actor); err != nil {
+		logger.Error.Printf("service.CreateSubject: error creating subject name=%q: %v", s.Name, err)
+		return err
+	}
+	logger.Info.Printf("service.CreateSubject: created subject ID=%d", s.ID)
+	return nil
+}
+
+// UpdateSubject переименовывает или переносит рубрику с логированием.
+func UpdateSubject(ctx context.Context, s *models.Subject, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.UpdateSubject")
+	defer span.End()
+	logger.Debug.Printf("service.UpdateSubject: start ID=%d name=%q", s.ID, s.Name)
+	if err := validateSubject(ctx, s); err != nil {
+		return err
+	}
+	if err := repository.UpdateSubject(ctx, s, actor); err != nil {
+		logger.Error.Printf("service.UpdateSubject: error updating subject ID=%d: %v", s.ID, err)
+		return err
+	}
+	logger.Info.Printf("service.UpdateSubject: updated subject ID=%d", s.ID)
+	return nil
+}
+
+// DeleteSubjectByID удаляет рубрику с логированием.
+func DeleteSubjectByID(ctx context.Context, subjectID int, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteSubjectByID")
+	defer span.End()
+	logger.Debug.Printf("service.DeleteSubjectByID: start id=%d", subjectID)
+	if err := repository.DeleteSubjectByID(ctx, subjectID, actor); err != nil {
+		logger.Error.Printf("service.DeleteSubjectByID: error deleting subject id=%d: %v", subjectID, err)
+		return err
+	}
+	logger.Info.Printf("service.DeleteSubjectByID: deleted subject ID=%d", subjectID)
+	return nil
+}
+
+// GetBooksBySubject возвращает книги рубрики вместе с книгами всех вложенных рубрик.
+func GetBooksBySubject(ctx context.Context, subjectID int) ([]models.Book, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBooksBySubject")
+	defer span.End()
+	logger.Debug.Printf("service.GetBooksBySubject: start subject_id=%d", subjectID)
+	if _, err := repository.GetSubjectByID(ctx, subjectID); err != nil {
+		return nil, err
+	}
+	books, err := repository.GetBooksBySubject(ctx, subjectID)
+	if err != nil {
+		logger.Error.Printf("service.GetBooksBySubject: error fetching books subject_id=%d: %v", subjectID, err)
+		return nil, err
+	}
+	return books, nil
+}
+
+// GetBookSubjects возвращает рубрики книги с логированием.
+func GetBookSubjects(ctx context.Context, bookID int) ([]models.Subject, error) {
+	ctx, span := tracing.Start(ctx, "service.GetBookSubjects")
+	defer span.End()
+	logger.Debug.Printf("service.GetBookSubjects: start book_id=%d", bookID)
+	if _, err := repository.GetBookByID(ctx, bookID, false); err != nil {
+		return nil, err
+	}
+	return repository.GetSubjectsByBookID(ctx, bookID)
+}
+
+// SetBookSubjects заменяет набор рубрик книги. Повторы в списке игнорируются.
+func SetBookSubjects(ctx context.Context, bookID int, subjectIDs []int, actor models.AuditActor) ([]models.Subject, error) {
+	ctx, span := tracing.Start(ctx, "service.SetBookSubjects")
+	defer span.End()
+	logger.Debug.Printf("service.SetBookSubjects: start book_id=%d subjects=%v", bookID, subjectIDs)
+	seen := make(map[int]bool, len(subjectIDs))
+	ids := make([]int64, 0, len(subjectIDs))
+	for _, id := range subjectIDs {
+		if id <= 0 {
+			return nil, fmt.Errorf("%w: subject ids must be positive", errs.ErrValidationFailed)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, int64(id))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if err := repository.SetBookSubjects(ctx, bookID, ids, actor); err != nil {
+		logger.Error.Printf("service.SetBookSubjects: error book_id=%d: %v", bookID, err)
+		return nil, err
+	}
+	return repository.GetSubjectsByBookID(ctx, bookID)
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"Library/internal/config"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/queue"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+// Виды задач очереди.
+const (
+	TaskRecommendationsRefresh = "recommendations.refresh"
+	TaskNotificationEmail      = "notification.email"
+	TaskWebhookDeliver         = "webhook.deliver"
+)
+
+// Значения по умолчанию для очереди.
+const (
+	defaultQueueWorkers          = 4
+	defaultQueuePollSeconds      = 5
+	defaultTaskMaxAttempts       = 5
+	defaultTaskBackoffSeconds    = 10
+	defaultTaskMaxBackoffSeconds = 3600
+	defaultTaskLockSeconds       = 300
+	defaultTaskRetentionDays     = 7
+	defaultTaskListLimit         = 50
+	maxTaskListLimit             = 500
+	maxTaskIdempotencyKeyLen     = 200
+)
+
+// StartQueue регистрирует обработчики задач и запускает пул обработчиков.
+func StartQueue(ctx context.Context) {
+	queue.Register(TaskRecommendationsRefresh, func(context.Context, json.RawMessage) error {
+		_, err := RefreshRecommendations(ctx)
+		return err
+	})
+	queue.Register(TaskNotificationEmail, deliverEmailTask)
+	queue.Register(TaskWebhookDeliver, deliverWebhookTask)
+
+	p := config.AppSettings.QueueParams
+	seconds := func(v, def int) time.Duration { return time.Duration(limitOrDefault(v, def)) * time.Second }
+	queue.Start(ctx, queue.Options{
+		Workers:      limitOrDefault(p.Workers, defaultQueueWorkers),
+		PollInterval: seconds(p.PollIntervalSeconds, defaultQueuePollSeconds),
+		LockTimeout:  seconds(p.LockTimeoutSeconds, defaultTaskLockSeconds),
+		BaseBackoff:  seconds(p.BaseBackoffSeconds, defaultTaskBackoffSeconds),
+		MaxBackoff:   seconds(p.MaxBackoffSeconds, defaultTaskMaxBackoffSeconds),
+	})
+}
+
+// EnqueueTask ставит задачу в очередь. payload сериализуется в JSON.
+// При совпадении opts.IdempotencyKey возвращается ранее поставленная задача.
+func EnqueueTask(ctx context.Context, kind string, payload interface{}, opts models.TaskOptions) (models.Task, error) {
+	ctx, span := tracing.Start(ctx, "service.EnqueueTask")
+	defer span.End()
+	if !queue.Registered(kind) {
+		return models.Task{}, fmt.Errorf("%w: unknown task kind %q", errs.ErrValidationFailed, kind)
+	}
+	if len(opts.IdempotencyKey) > maxTaskIdempotencyKeyLen {
+		return models.Task{}, fmt.Errorf("%w: idempotency_key is longer than %d characters", errs.ErrValidationFailed, maxTaskIdempotencyKeyLen)
+	}
+	if opts.MaxAttempts < 0 {
+		return models.Task{}, fmt.Errorf("%w: max_attempts must be positive", errs.ErrValidationFailed)
+	}
+
+	var raw json.RawMessage
+	switch p := payload.(type) {
+	case nil:
+		raw = json.RawMessage(`{}`)
+	case json.RawMessage:
+		raw = p
+		if len(raw) == 0 {
+			raw = json.RawMessage(`{}`)
+		}
+	default:
+		var err error
+		if raw, err = json.Marshal(p); err != nil {
+			return models.Task{}, fmt.Errorf("%w: payload: %v", errs.ErrValidationFailed, err)
+		}
+	}
+	if !json.Valid(raw) {
+		return models.Task{}, fmt.Errorf("%w: payload is not valid JSON", errs.ErrValidationFailed)
+	}
+
+	var key *string
+	if opts.IdempotencyKey != "" {
+		key = &opts.IdempotencyKey
+	}
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	maxAttempts := limitOrDefault(opts.MaxAttempts, limitOrDefault(config.AppSettings.QueueParams.MaxAttempts, defaultTaskMaxAttempts))
+
+	task, created, err := repository.EnqueueTask(ctx, kind, raw, maxAttempts, runAt, key)
+	if err != nil {
+		logger.Error.Printf("service.EnqueueTask: error kind=%s: %v", kind, err)
+		return models.Task{}, err
+	}
+	if created {
+		logger.Info.Printf("service.EnqueueTask: task ID=%d kind=%s queued", task.ID, kind)
+		queue.Notify()
+	}
+	return task, nil
+}
+
+// EnqueueTaskRequest — ручная постановка задачи администратором.
+func EnqueueTaskRequest(ctx context.Context, req models.EnqueueTaskRequest) (models.Task, error) {
+	ctx, span := tracing.Start(ctx, "service.EnqueueTaskRequest")
+	defer span.End()
+	opts := models.TaskOptions{IdempotencyKey: req.IdempotencyKey, MaxAttempts: req.MaxAttempts}
+	if req.RunAt != nil {
+		opts.RunAt = *req.RunAt
+	}
+	return EnqueueTask(ctx, req.Kind, req.Payload, opts)
+}
+
+// GetTasks возвращает задачи очереди по фильтру.
+func GetTasks(ctx context.Context, f models.TaskFilter) ([]models.Task, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTasks")
+	defer span.End()
+	switch f.Status {
+	case "", models.TaskStatusQueued, models.TaskStatusRunning, models.TaskStatusSucceeded, models.TaskStatusDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", errs.ErrValidationFailed, f.Status)
+	}
+	if f.Limit > maxTaskListLimit {
+		f.Limit = maxTaskListLimit
+	}
+	f.Limit = limitOrDefault(f.Limit, defaultTaskListLimit)
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	return repository.GetTasks(ctx, f)
+}
+
+// GetTaskByID возвращает задачу очереди.
+func GetTaskByID(ctx context.Context, id int64) (models.Task, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTaskByID")
+	defer span.End()
+	return repository.GetTaskByID(ctx, id)
+}
+
+// GetTaskStats возвращает число задач по видам и статусам.
+func GetTaskStats(ctx context.Context) ([]models.TaskStats, error) {
+	ctx, span := tracing.Start(ctx, "service.GetTaskStats")
+	defer span.End()
+	return repository.GetTaskStats(ctx)
+}
+
+// RetryTask возвращает задачу из dead в очередь.
+func RetryTask(ctx context.Context, id int64) (models.Task, error) {
+	ctx, span := tracing.Start(ctx, "service.RetryTask")
+	defer span.End()
+	task, err := repository.RequeueDeadTask(ctx, id)
+	if err != nil {
+		logger.Warn.Printf("service.RetryTask: ID=%d: %v", id, err)
+		return models.Task{}, err
+	}
+	queue.Notify()
+	return task, nil
+}
+
+// PurgeFinishedTasks удаляет выполненные задачи старше срока хранения.
+func PurgeFinishedTasks(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.PurgeFinishedTasks")
+	defer span.End()
+	days := limitOrDefault(config.AppSettings.QueueParams.RetentionDays, defaultTaskRetentionDays)
+	n, err := repository.PurgeFinishedTasks(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.Error.Printf("service.PurgeFinishedTasks: error: %v", err)
+		return 0, err
+	}
+	logger.Info.Printf("service.PurgeFinishedTasks: removed %d tasks", n)
+	return n, nil
+}
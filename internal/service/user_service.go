@@ -1,20 +1,23 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 
 	"Library/internal/models"
 	"Library/internal/repository"
+	"Library/internal/tracing"
 	"Library/logger"
 	"Library/utils"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // GetAllUsers возвращает всех пользователей с логированием.
-func GetAllUsers() ([]models.User, error) {
-	logger.Debug.Println("service.GetAllUsers: start")
-	users, err := repository.GetallUsers()
+func GetAllUsers(ctx context.Context, includeDeleted bool) ([]models.User, error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllUsers")
+	defer span.End()
+	logger.Debug.Printf("service.GetAllUsers: start include_deleted=%t", includeDeleted)
+	users, err := repository.GetallUsers(ctx, includeDeleted)
 	if err != nil {
 		logger.Error.Printf("service.GetAllUsers: error fetching users: %v", err)
 		return nil, err
@@ -23,10 +26,18 @@ func GetAllUsers() ([]models.User, error) {
 	return users, nil
 }
 
+// IsActiveUser проверяет, что владелец токена не удалён: токен удалённого пользователя
+// перестаёт действовать сразу, а не по истечении срока.
+func IsActiveUser(ctx context.Context, userID int) (bool, error) {
+	return repository.IsActiveUser(ctx, userID)
+}
+
 // GetUserByID возвращает пользователя по ID с логированием.
-func GetUserByID(userID int) (models.User, error) {
+func GetUserByID(ctx context.Context, userID int, includeDeleted bool) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "service.GetUserByID")
+	defer span.End()
 	logger.Debug.Printf("service.GetUserByID: start id=%d", userID)
-	user, err := repository.GetUserByID(userID)
+	user, err := repository.GetUserByID(ctx, userID, includeDeleted)
 	if err != nil {
 		logger.Error.Printf("service.GetUserByID: error fetching user id=%d: %v", userID, err)
 		return models.User{}, err
@@ -36,16 +47,18 @@ func GetUserByID(userID int) (models.User, error) {
 }
 
 // CreateUser создаёт нового пользователя с логированием и хешированием пароля.
-func CreateUser(user *models.User) error {
+func CreateUser(ctx context.Context, user *models.User, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.CreateUser")
+	defer span.End()
 	logger.Debug.Printf("service.CreateUser: start username=%q email=%q", user.Username, user.Email)
-	hash, err := utils.HashPassword(user.Password)
+	hash, err := utils.HashPassword(ctx, user.Password)
 	if err != nil {
 		logger.Error.Printf("service.CreateUser: hash error: %v", err)
 		return err
 	}
 	user.Password = hash
 
-	if err := repository.CreateUser(user); err != nil {
+	if err := repository.CreateUser(ctx, user, actor); err != nil {
 		logger.Error.Printf("service.CreateUser: repository error creating user username=%q: %v", user.Username, err)
 		return err
 	}
@@ -54,9 +67,11 @@ func CreateUser(user *models.User) error {
 }
 
 // UpdateUser обновляет данные пользователя с логированием.
-func UpdateUser(user *models.User) error {
+func UpdateUser(ctx context.Context, user *models.User, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.UpdateUser")
+	defer span.End()
 	logger.Debug.Printf("service.UpdateUser: start ID=%d username=%q email=%q role=%q", user.ID, user.Username, user.Email, user.Role)
-	if err := repository.UpdateUser(user); err != nil {
+	if err := repository.UpdateUser(ctx, user, actor); err != nil {
 		logger.Error.Printf("service.UpdateUser: error updating user ID=%d: %v", user.ID, err)
 		return err
 	}
@@ -65,9 +80,11 @@ func UpdateUser(user *models.User) error {
 }
 
 // DeleteUserByID удаляет пользователя по ID с логированием.
-func DeleteUserByID(userID int) error {
+func DeleteUserByID(ctx context.Context, userID int, actor models.AuditActor) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteUserByID")
+	defer span.End()
 	logger.Debug.Printf("service.DeleteUserByID: start id=%d", userID)
-	if err := repository.DeleteUserByID(userID); err != nil {
+	if err := repository.DeleteUserByID(ctx, userID, actor); err != nil {
 		logger.Error.Printf("service.DeleteUserByID: error deleting user id=%d: %v", userID, err)
 		return err
 	}
@@ -76,18 +93,17 @@ func DeleteUserByID(userID int) error {
 }
 
 // AuthenticateUser проверяет учётные данные пользователя с логированием.
-func AuthenticateUser(username, plainPassword string) (*models.User, error) {
+func AuthenticateUser(ctx context.Context, username, plainPassword string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "service.AuthenticateUser")
+	defer span.End()
 	logger.Debug.Printf("service.AuthenticateUser: start username=%q", username)
-	user, err := repository.GetUserByUsername(username)
+	user, err := repository.GetUserByUsername(ctx, username)
 	if err != nil {
 		logger.Warn.Printf("service.AuthenticateUser: user not found username=%q", username)
 		return nil, errors.New("invalid credentials")
 	}
 
-	if err := bcrypt.CompareHashAndPassword(
-		[]byte(user.Password),
-		[]byte(plainPassword),
-	); err != nil {
+	if err := utils.CheckPassword(ctx, user.Password, plainPassword); err != nil {
 		logger.Warn.Printf("service.AuthenticateUser: password mismatch for username=%q", username)
 		return nil, errors.New("invalid credentials")
 	}
@@ -95,3 +111,64 @@ func AuthenticateUser(username, plainPassword string) (*models.User, error) {
 	logger.Info.Printf("service.AuthenticateUser: authenticated userID=%d username=%q role=%q", user.ID, user.Username, user.Role)
 	return user, nil
 }
+
+// RestoreUserByID восстанавливает удалённого пользователя с логированием.
+func RestoreUserByID(ctx context.Context, userID int, actor models.AuditActor) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "service.RestoreUserByID")
+	defer span.End()
+	logger.Debug.Printf("service.RestoreUserByID: start id=%d", userID)
+	user, err := repository.RestoreUserByID(ctx, userID, actor)
+	if err != nil {
+		logger.Error.Printf("service.RestoreUserByID: error restoring user id=%d: %v", userID, err)
+		return models.User{}, err
+	}
+	logger.Info.Printf("service.RestoreUserByID: restored user ID=%d", userID)
+	return user, nil
+}
+
+// PatchUser применяет JSON Merge Patch к пользователю. Новый пароль хешируется.
+func PatchUser(ctx context.Context, userID, version int, patch map[string]json.RawMessage, actor models.AuditActor) (models.User, error) {
+	ctx, span := tracing.Start(ctx, "service.PatchUser")
+	defer span.End()
+	logger.Debug.Printf("service.PatchUser: start id=%d fields=%d", userID, len(patch))
+	fields := make(map[string]interface{}, len(patch))
+	for field, raw := range patch {
+		var (
+			v   string
+			err error
+		)
+		switch field {
+		case "username":
+			v, err = patchString(field, raw)
+		case "email":
+			v, err = patchEmail(field, raw)
+		case "role":
+			if v, err = patchString(field, raw); err == nil && v != "user" && v != "admin" {
+				err = patchError(field, `must be "user" or "admin"`)
+			}
+		case "password":
+			if v, err = patchSecret(field, raw); err == nil {
+				if len(v) < 6 {
+					err = patchError(field, "must be at least 6 characters")
+				} else {
+					v, err = utils.HashPassword(ctx, v)
+				}
+			}
+		default:
+			err = unknownPatchField(field)
+		}
+		if err != nil {
+			logger.Warn.Printf("service.PatchUser: invalid patch id=%d: %v", userID, err)
+			return models.User{}, err
+		}
+		fields[field] = v
+	}
+
+	user, err := repository.PatchUser(ctx, userID, version, fields, actor)
+	if err != nil {
+		logger.Error.Printf("service.PatchUser: error patching user id=%d: %v", userID, err)
+		return models.User{}, err
+	}
+	logger.Info.Printf("service.PatchUser: patched user ID=%d", userID)
+	return user, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// errWebhookForbiddenAddress — адрес подписчика ведёт во внутреннюю сеть.
+var errWebhookForbiddenAddress = errors.New("webhook target resolves to a forbidden address")
+
+// sharedAddressSpace — 100.64.0.0/10 (CGNAT), в сеть провайдера вебхуки тоже не ходят.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// forbiddenWebhookAddr сообщает, что вебхук не должен уходить на адрес: loopback, частные сети,
+// link-local (в том числе метаданные облака 169.254.169.254), multicast и неопределённый адрес.
+func forbiddenWebhookAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || sharedAddressSpace.Contains(ip)
+}
+
+// webhookDialControl проверяет адрес уже после разрешения DNS, непосредственно перед connect,
+// поэтому имя, которое ведёт во внутреннюю сеть (или меняет адрес между проверкой и запросом),
+// не проходит.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errWebhookForbiddenAddress, address)
+	}
+	if forbiddenWebhookAddr(ap.Addr()) {
+		return fmt.Errorf("%w: %s", errWebhookForbiddenAddress, ap.Addr())
+	}
+	return nil
+}
+
+// checkWebhookHost заранее отклоняет явно внутренние адреса в URL подписки,
+// чтобы ошибка была видна при создании, а не только в журнале доставок.
+func checkWebhookHost(host string) error {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return errWebhookForbiddenAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && forbiddenWebhookAddr(ip) {
+		return errWebhookForbiddenAddress
+	}
+	return nil
+}
+
+// webhookClient — отдельный клиент для доставок: без прокси из окружения, без перехода
+// по редиректам (3xx считается неуспешным ответом) и без соединений во внутреннюю сеть.
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   defaultWebhookTimeoutSeconds * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   webhookDialControl,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   defaultWebhookTimeoutSeconds * time.Second,
+		ExpectContinueTimeout: time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"Library/internal/config"
+	"Library/internal/errs"
+	"Library/internal/models"
+	"Library/internal/queue"
+	"Library/internal/repository"
+	"Library/internal/tracing"
+	"Library/logger"
+)
+
+// Значения по умолчанию для вебхуков.
+const (
+	defaultWebhookRelaySeconds   = 2
+	defaultWebhookTimeoutSeconds = 10
+	defaultWebhookMaxAttempts    = 8
+	defaultWebhookRetentionDays  = 30
+	webhookRelayBatch            = 100
+	webhookResponseDrainLimit    = 4096
+	minWebhookSecretLen          = 16
+	defaultWebhookDeliveryLimit  = 50
+	maxWebhookDeliveryLimit      = 500
+)
+
+// Сущности и действия, из которых складываются типы событий (<сущность>.<действие>).
+var (
+	webhookEventEntities = map[string]bool{
+		models.AuditEntityBook: true, models.AuditEntityAuthor: true, models.AuditEntityUser: true,
+		models.AuditEntityPublisher: true, models.AuditEntitySubject: true, models.AuditEntitySeries: true,
+		models.AuditEntityReview: true, "loan": true,
+	}
+	webhookEventVerbs = map[string]bool{
+		"created": true, "updated": true, "deleted": true, "restored": true, "purged": true, "merged": true,
+		"checked_out": true, "returned": true, "expired": true,
+	}
+)
+
+// validateWebhookEventType допускает точный тип события, маску "<сущность>.*" и "*".
+func validateWebhookEventType(t string) error {
+	if t == "*" {
+		return nil
+	}
+	parts := strings.SplitN(t, ".", 2)
+	if len(parts) == 2 && webhookEventEntities[parts[0]] && (parts[1] == "*" || webhookEventVerbs[parts[1]]) {
+		return nil
+	}
+	return fmt.Errorf("%w: unknown event type %q", errs.ErrValidationFailed, t)
+}
+
+func validateWebhookInput(in models.WebhookSubscriptionInput) error {
+	u, err := url.Parse(in.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", errs.ErrValidationFailed)
+	}
+	if err := checkWebhookHost(u.Hostname()); err != nil {
+		return fmt.Errorf("%w: url must not point to a loopback, private or link-local address", errs.ErrValidationFailed)
+	}
+	if len(in.EventTypes) == 0 {
+		return fmt.Errorf("%w: event_types must not be empty", errs.ErrValidationFailed)
+	}
+	for _, t := range in.EventTypes {
+		if err := validateWebhookEventType(t); err != nil {
+			return err
+		}
+	}
+	if in.Secret != "" && len(in.Secret) < minWebhookSecretLen {
+		return fmt.Errorf("%w: secret must be at least %d characters", errs.ErrValidationFailed, minWebhookSecretLen)
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GetWebhookSubscriptions возвращает подписки без секретов.
+func GetWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "service.GetWebhookSubscriptions")
+	defer span.End()
+	subs, err := repository.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	return subs, nil
+}
+
+// GetWebhookSubscriptionByID возвращает подписку без секрета.
+func GetWebhookSubscriptionByID(ctx context.Context, id int) (models.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "service.GetWebhookSubscriptionByID")
+	defer span.End()
+	s, err := repository.GetWebhookSubscriptionByID(ctx, id)
+	s.Secret = ""
+	return s, err
+}
+
+// CreateWebhookSubscription создаёт подписку. Секрет возвращается только в этом ответе.
+func CreateWebhookSubscription(ctx context.Context, in models.WebhookSubscriptionInput) (models.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "service.CreateWebhookSubscription")
+	defer span.End()
+	if err := validateWebhookInput(in); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	s := models.WebhookSubscription{
+		URL:         in.URL,
+		Secret:      in.Secret,
+		EventTypes:  in.EventTypes,
+		Description: in.Description,
+		Active:      in.Active == nil || *in.Active,
+	}
+	if s.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return models.WebhookSubscription{}, err
+		}
+		s.Secret = secret
+	}
+	if err := repository.CreateWebhookSubscription(ctx, &s); err != nil {
+		logger.Error.Printf("service.CreateWebhookSubscription: error: %v", err)
+		return models.WebhookSubscription{}, err
+	}
+	return s, nil
+}
+
+// UpdateWebhookSubscription заменяет настройки подписки. Секрет меняется, только если передан
+// новый или запрошен rotate_secret; тогда он возвращается в ответе.
+func UpdateWebhookSubscription(ctx context.Context, id int, in models.WebhookSubscriptionInput) (models.WebhookSubscription, error) {
+	ctx, span := tracing.Start(ctx, "service.UpdateWebhookSubscription")
+	defer span.End()
+	if err := validateWebhookInput(in); err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	s, err := repository.GetWebhookSubscriptionByID(ctx, id)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	s.URL, s.EventTypes, s.Description = in.URL, in.EventTypes, in.Description
+	if in.Active != nil {
+		s.Active = *in.Active
+	}
+	secretChanged := true
+	switch {
+	case in.Secret != "":
+		s.Secret = in.Secret
+	case in.RotateSecret:
+		if s.Secret, err = newWebhookSecret(); err != nil {
+			return models.WebhookSubscription{}, err
+		}
+	default:
+		secretChanged = false
+	}
+
+	if err := repository.UpdateWebhookSubscription(ctx, &s); err != nil {
+		logger.Error.Printf("service.UpdateWebhookSubscription: ID=%d: %v", id, err)
+		return models.WebhookSubscription{}, err
+	}
+	if !secretChanged {
+		s.Secret = ""
+	}
+	return s, nil
+}
+
+// DeleteWebhookSubscription удаляет подписку.
+func DeleteWebhookSubscription(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "service.DeleteWebhookSubscription")
+	defer span.End()
+	return repository.DeleteWebhookSubscription(ctx, id)
+}
+
+// GetWebhookDeliveries возвращает журнал доставок подписки.
+func GetWebhookDeliveries(ctx context.Context, subscriptionID, limit, offset int) ([]models.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "service.GetWebhookDeliveries")
+	defer span.End()
+	if _, err := repository.GetWebhookSubscriptionByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	if limit > maxWebhookDeliveryLimit {
+		limit = maxWebhookDeliveryLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return repository.GetWebhookDeliveries(ctx, subscriptionID, limitOrDefault(limit, defaultWebhookDeliveryLimit), offset)
+}
+
+// PingWebhook синхронно отправляет подписчику проверочное событие ping и возвращает результат.
+// Ping отправляется и неактивной подписке, чтобы её можно было проверить до включения.
+func PingWebhook(ctx context.Context, subscriptionID int) (models.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "service.PingWebhook")
+	defer span.End()
+	sub, err := repository.GetWebhookSubscriptionByID(ctx, subscriptionID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":        models.WebhookEventPing,
+		"occurred_at": time.Now().UTC(),
+		"data":        map[string]interface{}{"subscription_id": sub.ID},
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	d := models.WebhookDelivery{SubscriptionID: sub.ID, EventType: models.WebhookEventPing, Payload: payload}
+	if err := repository.CreateWebhookDelivery(ctx, &d); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	// Результат пинга записывается, даже если клиент не дождался ответа
+	ctx = context.WithoutCancel(ctx)
+	return repository.RecordWebhookAttempt(ctx, d.ID, sendWebhook(ctx, sub, d))
+}
+
+// RedeliverWebhook ставит повторную доставку события в очередь.
+func RedeliverWebhook(ctx context.Context, subscriptionID int, deliveryID int64) (models.WebhookDelivery, error) {
+	ctx, span := tracing.Start(ctx, "service.RedeliverWebhook")
+	defer span.End()
+	d, err := repository.GetWebhookDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if d.SubscriptionID != subscriptionID {
+		return models.WebhookDelivery{}, errs.ErrNotFound
+	}
+	if _, err := EnqueueTask(ctx, TaskWebhookDeliver, webhookTaskPayload{DeliveryID: d.ID}, models.TaskOptions{MaxAttempts: webhookMaxAttempts()}); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	logger.Info.Printf("service.RedeliverWebhook: delivery ID=%d requeued", d.ID)
+	return d, nil
+}
+
+var webhookRelayRunning atomic.Bool
+
+type webhookTaskPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+func webhookMaxAttempts() int {
+	return limitOrDefault(config.AppSettings.WebhookParams.MaxAttempts, defaultWebhookMaxAttempts)
+}
+
+// deliverWebhookTask — обработчик задачи доставки; ошибка возвращает задачу в очередь с задержкой.
+func deliverWebhookTask(ctx context.Context, payload json.RawMessage) error {
+	var p webhookTaskPayload
+	if err := json.Unmarshal(payload, &p); err != nil || p.DeliveryID == 0 {
+		return queue.Permanent(fmt.Errorf("invalid webhook task payload: %v", err))
+	}
+	d, err := repository.GetWebhookDeliveryByID(ctx, p.DeliveryID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return queue.Permanent(err)
+		}
+		return err
+	}
+	sub, err := repository.GetWebhookSubscriptionByID(ctx, d.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return queue.Permanent(err)
+		}
+		return err
+	}
+	if !sub.Active {
+		repository.RecordWebhookAttempt(ctx, d.ID, models.WebhookAttempt{Status: models.WebhookDeliveryFailed, Error: "subscription is disabled"})
+		return queue.Permanent(errors.New("subscription is disabled"))
+	}
+
+	attempt := sendWebhook(ctx, sub, d)
+	if _, err := repository.RecordWebhookAttempt(ctx, d.ID, attempt); err != nil {
+		return err
+	}
+	if attempt.Status == models.WebhookDeliverySucceeded {
+		return nil
+	}
+	// 410 Gone — получатель просит больше не присылать
+	if attempt.ResponseStatus != nil && *attempt.ResponseStatus == http.StatusGone {
+		return queue.Permanent(errors.New(attempt.Error))
+	}
+	return errors.New(attempt.Error)
+}
+
+// signWebhook возвращает значение заголовка X-Webhook-Signature: sha256=<hex> от HMAC-SHA256
+// секрета подписки над строкой "<X-Webhook-Timestamp>.<тело запроса>".
+func signWebhook(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhook выполняет одну HTTP-попытку, подписывая тело через signWebhook.
+func sendWebhook(ctx context.Context, sub models.WebhookSubscription, d models.WebhookDelivery) models.WebhookAttempt {
+	timeout := time.Duration(limitOrDefault(config.AppSettings.WebhookParams.TimeoutSeconds, defaultWebhookTimeoutSeconds)) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body := []byte(d.Payload)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+	failed := func(msg string) models.WebhookAttempt {
+		return models.WebhookAttempt{Status: models.WebhookDeliveryFailed, Error: msg}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return failed(err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OnlineLibrary-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", signWebhook(sub.Secret, ts, body))
+	tracing.Inject(ctx, req.Header)
+
+	begin := time.Now()
+	resp, err := webhookClient.Do(req)
+	elapsed := int(time.Since(begin) / time.Millisecond)
+	if err != nil {
+		a := failed(err.Error())
+		a.DurationMS = elapsed
+		return a
+	}
+	defer resp.Body.Close()
+	// Тело ответа не сохраняется: в журнал доставок попадает только код ответа
+	io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseDrainLimit))
+
+	a := models.WebhookAttempt{
+		Status:         models.WebhookDeliverySucceeded,
+		ResponseStatus: &resp.StatusCode,
+		DurationMS:     elapsed,
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.Status = models.WebhookDeliveryFailed
+		a.Error = "unexpected response status " + resp.Status
+	}
+	logger.Debug.Printf("service.sendWebhook: delivery ID=%d -> %s status=%s in %dms", d.ID, sub.URL, a.Status, elapsed)
+	return a
+}
+
+// RelayOutbox разносит накопившиеся события outbox по подпискам.
+func RelayOutbox(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "service.RelayOutbox")
+	defer span.End()
+	total := 0
+	for {
+		events, deliveries, err := repository.RelayOutboxEvents(ctx, webhookRelayBatch, TaskWebhookDeliver, webhookMaxAttempts())
+		if err != nil {
+			return total, err
+		}
+		if deliveries > 0 {
+			queue.Notify()
+		}
+		total += events
+		if events < webhookRelayBatch {
+			return total, nil
+		}
+	}
+}
+
+// StartWebhookRelay периодически разносит события outbox, пока не отменён ctx.
+func StartWebhookRelay(ctx context.Context) {
+	interval := time.Duration(limitOrDefault(config.AppSettings.WebhookParams.RelayIntervalSeconds, defaultWebhookRelaySeconds)) * time.Second
+	logger.Info.Printf("StartWebhookRelay: relaying outbox every %s", interval)
+	webhookRelayRunning.Store(true)
+	go func() {
+		defer webhookRelayRunning.Store(false)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				RelayOutbox(ctx)
+			}
+		}
+	}()
+}
+
+// PurgeWebhookHistory удаляет старые события outbox и журнал доставок.
+func PurgeWebhookHistory(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "service.PurgeWebhookHistory")
+	defer span.End()
+	days := limitOrDefault(config.AppSettings.WebhookParams.RetentionDays, defaultWebhookRetentionDays)
+	n, err := repository.PurgeWebhookHistory(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logger.Error.Printf("service.PurgeWebhookHistory: error: %v", err)
+		return 0, err
+	}
+	logger.Info.Printf("service.PurgeWebhookHistory: removed %d rows", n)
+	return n, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"Library/internal/errs"
+	"Library/internal/models"
+)
+
+func TestSignWebhook(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		ts     string
+		body   string
+		want   string
+	}{
+		{
+			name:   "payload",
+			secret: "whsec_test_secret",
+			ts:     "1700000000",
+			body:   `{"event":"book.created","data":{"id":42}}`,
+			want:   "sha256=a62d10413ad06178b1ad0d496eb92443c60f93411744744af119b603a2ddb410",
+		},
+		{
+			name:   "empty body",
+			secret: "whsec_test_secret",
+			ts:     "1700000000",
+			body:   "",
+			want:   "sha256=316b9ab98c15bfa039d243f3196acee619cf29749a91a634e6a8154e2f7b6727",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signWebhook(tt.secret, tt.ts, []byte(tt.body)); got != tt.want {
+				t.Errorf("signWebhook() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	base := signWebhook("whsec_test_secret", "1700000000", []byte("{}"))
+	if signWebhook("whsec_other_secret", "1700000000", []byte("{}")) == base {
+		t.Error("signature does not depend on the secret")
+	}
+	if signWebhook("whsec_test_secret", "1700000001", []byte("{}")) == base {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestForbiddenWebhookAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.10", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"fe80::1", true},
+		{"fd00::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"93.184.216.34", false},
+		{"100.128.0.1", false},
+		{"2606:4700::1111", false},
+	}
+	for _, tt := range tests {
+		if got := forbiddenWebhookAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("forbiddenWebhookAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWebhookInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      models.WebhookSubscriptionInput
+		wantErr bool
+	}{
+		{"valid", models.WebhookSubscriptionInput{URL: "https://example.com/hook", EventTypes: []string{"book.created"}}, false},
+		{"wildcard", models.WebhookSubscriptionInput{URL: "http://example.com/hook", EventTypes: []string{"*"}}, false},
+		{"entity wildcard", models.WebhookSubscriptionInput{URL: "https://example.com/hook", EventTypes: []string{"book.*"}}, false},
+		{"relative url", models.WebhookSubscriptionInput{URL: "/hook", EventTypes: []string{"*"}}, true},
+		{"ftp scheme", models.WebhookSubscriptionInput{URL: "ftp://example.com/hook", EventTypes: []string{"*"}}, true},
+		{"localhost", models.WebhookSubscriptionInput{URL: "http://localhost:8080/hook", EventTypes: []string{"*"}}, true},
+		{"loopback ip", models.WebhookSubscriptionInput{URL: "http://127.0.0.1/hook", EventTypes: []string{"*"}}, true},
+		{"metadata ip", models.WebhookSubscriptionInput{URL: "http://169.254.169.254/latest", EventTypes: []string{"*"}}, true},
+		{"ipv6 loopback", models.WebhookSubscriptionInput{URL: "http://[::1]/hook", EventTypes: []string{"*"}}, true},
+		{"no event types", models.WebhookSubscriptionInput{URL: "https://example.com/hook"}, true},
+		{"unknown event", models.WebhookSubscriptionInput{URL: "https://example.com/hook", EventTypes: []string{"book.exploded"}}, true},
+		{"short secret", models.WebhookSubscriptionInput{URL: "https://example.com/hook", EventTypes: []string{"*"}, Secret: "short"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookInput(tt.in)
+			if tt.wantErr && !errors.Is(err, errs.ErrValidationFailed) {
+				t.Errorf("validateWebhookInput() = %v, want ErrValidationFailed", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateWebhookInput() = %v, want nil", err)
+			}
+		})
+	}
+}
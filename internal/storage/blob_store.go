@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"Library/internal/models"
+	"Library/logger"
+)
+
+// ErrBlobNotFound возвращается, если объекта с таким ключом нет в хранилище.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore — хранилище бинарных объектов (обложки, файлы книг).
+// Ключом объекта служит SHA-256 его содержимого, поэтому одинаковые файлы
+// хранятся один раз.
+type BlobStore interface {
+	Put(key string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Exists(key string) (bool, error)
+	Delete(key string) error
+}
+
+var store BlobStore
+
+// InitBlobStore создаёт хранилище согласно конфигу.
+func InitBlobStore(cfg models.StorageParams) error {
+	switch cfg.Driver {
+	case "", "local":
+		s, err := NewLocalBlobStore(cfg.Directory)
+		if err != nil {
+			logger.Error.Printf("InitBlobStore: cannot init local store at %q: %v", cfg.Directory, err)
+			return err
+		}
+		store = s
+	default:
+		return fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+	logger.Info.Printf("InitBlobStore: using %q blob store", cfg.Driver)
+	return nil
+}
+
+// GetBlobStore возвращает текущее хранилище.
+func GetBlobStore() BlobStore {
+	if store == nil {
+		logger.Warn.Println("GetBlobStore: warning: returning nil blob store")
+	}
+	return store
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"Library/logger"
+)
+
+// LocalBlobStore хранит объекты в локальной файловой системе.
+// Объекты раскладываются по подкаталогам из первых двух символов ключа,
+// чтобы не держать тысячи файлов в одной папке.
+type LocalBlobStore struct {
+	root string
+}
+
+// NewLocalBlobStore создаёт хранилище в каталоге root (создаёт его при необходимости).
+func NewLocalBlobStore(root string) (*LocalBlobStore, error) {
+	if root == "" {
+		return nil, errors.New("storage directory is not set")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalBlobStore{root: root}, nil
+}
+
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if len(key) < 3 || filepath.Base(key) != key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.root, key[:2], key), nil
+}
+
+// Put записывает объект атомарно: сначала во временный файл, затем rename.
+func (s *LocalBlobStore) Put(key string, r io.Reader) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), key+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	logger.Debug.Printf("LocalBlobStore.Put: stored %s", key)
+	return nil
+}
+
+// Get открывает объект на чтение.
+func (s *LocalBlobStore) Get(key string) (io.ReadCloser, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return f, err
+}
+
+// Exists проверяет наличие объекта.
+func (s *LocalBlobStore) Exists(key string) (bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Delete удаляет объект; отсутствие объекта ошибкой не считается.
+func (s *LocalBlobStore) Delete(key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	logger.Debug.Printf("LocalBlobStore.Delete: removed %s", key)
+	return nil
+}
@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxStatementLength ограничивает текст запроса в атрибуте спана.
+const maxStatementLength = 2048
+
+var (
+	sqlStringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumericLiteral = regexp.MustCompile(`([^\w$.])\d+(?:\.\d+)?\b`) // $1 и имена вроде col2 не трогаем
+	sqlSpaces         = regexp.MustCompile(`\s+`)
+)
+
+// SanitizeSQL убирает из текста запроса литералы и лишние пробелы. Значения в репозитории
+// передаются параметрами ($1, $2), но литералы в тексте тоже не должны попадать в трассы.
+func SanitizeSQL(query string) string {
+	q := sqlStringLiteral.ReplaceAllString(query, "?")
+	q = sqlNumericLiteral.ReplaceAllString(q, "${1}?")
+	q = strings.TrimSpace(sqlSpaces.ReplaceAllString(q, " "))
+	if len(q) > maxStatementLength {
+		q = q[:maxStatementLength] + "…"
+	}
+	return q
+}
+
+// sqlOperation — первое слово запроса (SELECT, INSERT, WITH…) для имени спана.
+func sqlOperation(q string) string {
+	if i := strings.IndexByte(q, ' '); i > 0 {
+		q = q[:i]
+	}
+	return strings.ToUpper(q)
+}
+
+// WrapConnector оборачивает драйвер БД так, что каждый запрос с контекстом
+// (ExecContext/QueryContext, в том числе через подготовленный запрос) пишется отдельным клиентским спаном.
+// Спан запроса заканчивается, когда сервер ответил, без учёта чтения строк.
+func WrapConnector(c driver.Connector) driver.Connector {
+	return &tracedConnector{Connector: c}
+}
+
+type tracedConnector struct {
+	driver.Connector
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn}, nil
+}
+
+// tracedConn пробрасывает все интерфейсы драйвера pq, чтобы database/sql
+// не переключился на менее эффективные пути (например, prepare на каждый Exec).
+type tracedConn struct {
+	driver.Conn
+}
+
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	q := SanitizeSQL(query)
+	return otel.Tracer(tracerName).Start(ctx, "db "+sqlOperation(q),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement", q),
+		))
+}
+
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil && err != driver.ErrSkip {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	res, err := execer.ExecContext(ctx, query, args)
+	endQuerySpan(span, err)
+	return res, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, query: query}, nil
+}
+
+// tracedStmt пишет спан на каждое выполнение подготовленного запроса,
+// так же как tracedConn для запросов без подготовки.
+type tracedStmt struct {
+	driver.Stmt
+	query string
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	ctx, span := startQuerySpan(ctx, s.query)
+	var (
+		res driver.Result
+		err error
+	)
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			res, err = s.Stmt.Exec(values)
+		}
+	}
+	endQuerySpan(span, err)
+	return res, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	ctx, span := startQuerySpan(ctx, s.query)
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+// namedValuesToValues — то же преобразование, что делает database/sql для драйверов
+// без *Context-методов: именованные параметры такие драйверы не поддерживают.
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errors.New("tracing: driver does not support named parameters")
+		}
+		values[i] = nv.Value
+	}
+	return values, nil
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"Library/internal/models"
+	"Library/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName — имя инструментирующей библиотеки в спанах.
+const tracerName = "Library"
+
+// Init настраивает провайдер трассировки и W3C-пропагацию (traceparent/tracestate, baggage).
+// Возвращает функцию, которая при остановке досылает накопленные спаны.
+// Пропагация включена всегда: даже без экспорта входящий traceparent передаётся дальше.
+func Init(cfg models.TracingParams, app models.AppParams) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Exporter {
+	case "":
+		logger.Info.Println("tracing.Init: tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case "otlp":
+		exporter, err = newOTLPExporter(cfg)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot create %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName(app)),
+		attribute.String("service.version", app.AppVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("cannot build tracing resource: %w", err)
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn.Printf("tracing: %v", err)
+	}))
+	logger.Info.Printf("tracing.Init: exporting spans via %s, sample ratio %.2f", cfg.Exporter, ratio)
+	return tp.Shutdown, nil
+}
+
+func newOTLPExporter(cfg models.TracingParams) (sdktrace.SpanExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("tracing_params.endpoint is required for otlp")
+	}
+	var opts []otlptracehttp.Option
+	if strings.Contains(cfg.Endpoint, "://") {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	return otlptracehttp.New(context.Background(), opts...)
+}
+
+func serviceName(app models.AppParams) string {
+	if app.ServerName != "" {
+		return app.ServerName
+	}
+	return "online-library"
+}
+
+// Start открывает внутренний спан, например для функции сервиса:
+//
+//	ctx, span := tracing.Start(ctx, "service.GetBooks")
+//	defer span.End()
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer открывает серверный спан входящего запроса.
+func StartServer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// Extract восстанавливает контекст трассировки из заголовков входящего запроса.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// Inject добавляет traceparent в заголовки исходящего запроса.
+func Inject(ctx context.Context, h http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// TraceID возвращает идентификатор текущей трассировки или пустую строку.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -10,12 +10,34 @@ import (
 	"Library/internal/config"
 	"Library/internal/controller"
 	"Library/internal/db"
+	"Library/internal/metrics"
+	"Library/internal/middleware"
+	"Library/internal/notify"
+	"Library/internal/service"
+	"Library/internal/storage"
+	"Library/internal/tracing"
 	"Library/logger"
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	// Код выхода выставляется, если сервер упал сам; os.Exit вызывается последним отложенным
+	// вызовом, чтобы сначала отработали CloseDB и остальные defer
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
 	// 1) Читаем .env и JSON-конфиг (логгер, JWT, app-параметры)
 	if err := config.ReadSettings(); err != nil {
 		log.Fatalf("cannot load config: %v", err)
@@ -26,6 +48,12 @@ func main() {
 		log.Fatalf("cannot init logger: %v", err)
 	}
 
+	// 2.1) Трассировка OpenTelemetry (tracing_params): спаны запросов, сервисов и SQL
+	shutdownTracing, err := tracing.Init(config.AppSettings.TracingParams, config.AppSettings.AppParams)
+	if err != nil {
+		logger.Error.Fatalf("Tracing init failed: %v", err)
+	}
+
 	// 3) Подключаемся к базе (PostgresParams берутся из config.AppSettings)
 	if err := db.ConnectDB(config.AppSettings.PostgresParams); err != nil {
 		logger.Error.Fatalf("DB connection failed: %v", err)
@@ -36,23 +64,106 @@ func main() {
 		}
 	}()
 
+	// 3.1) Метрики Prometheus (metrics_params): HTTP, пул соединений, запросы репозитория, бизнес-показатели
+	metrics.Init(config.AppSettings.MetricsParams, db.GetDBConn().DB, service.GetLibraryStats)
+
+	// 3.2) Хранилище обложек и файлов книг, отправка уведомлений
+	if err := storage.InitBlobStore(config.AppSettings.StorageParams); err != nil {
+		logger.Error.Fatalf("Blob store init failed: %v", err)
+	}
+	if err := notify.InitSender(config.AppSettings.NotifyParams); err != nil {
+		logger.Error.Fatalf("Notification sender init failed: %v", err)
+	}
+
+	// 3.3) Планировщик фоновых задач (расписания — scheduler_params в configs.json).
+	// Все фоновые задачи останавливаются отменой bgCtx при завершении сервера
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if err := service.StartJobs(bgCtx); err != nil {
+		logger.Error.Fatalf("Scheduler start failed: %v", err)
+	}
+	// 3.4) Обработчики очереди задач в PostgreSQL (queue_params)
+	service.StartQueue(bgCtx)
+	// 3.5) Рассылка событий из outbox подписчикам вебхуков (webhook_params)
+	service.StartWebhookRelay(bgCtx)
+	// 3.6) Поток изменений для SSE: LISTEN на события outbox (при сбое повторяется в фоне)
+	service.StartEventStream(bgCtx)
+
 	// 4) Выбираем режим Gin (release/debug)
 	gin.SetMode(config.AppSettings.AppParams.GinMode)
 
 	// 5) Инициализируем роутер
 	r := gin.Default()
+	r.Use(middleware.RequestID, middleware.Tracing, middleware.Metrics, middleware.ReplicaReads)
 
 	setupSwagger(r)
 	// 6) Регистрируем публичные и защищённые маршруты
-	controller.RegisterAuthRoutes(r)   // /auth/sign-up, /auth/sign-in
-	controller.RegisterUserRoutes(r)   // /users (GET открытые, POST/PUT/DELETE через JWT+AdminOnly)
-	controller.RegisterAuthorRoutes(r) // /authors
-	controller.RegisterBookRoutes(r)   // /books
+	controller.RegisterHealthRoutes(r)         // /healthz, /readyz (пробы оркестратора, без JWT)
+	controller.RegisterMetricsRoutes(r)        // /metrics (если включено; Basic-авторизация по metrics_params)
+	controller.RegisterAuthRoutes(r)           // /auth/sign-up, /auth/sign-in
+	controller.RegisterUserRoutes(r)           // /users (GET открытые, POST/PUT/DELETE через JWT+AdminOnly)
+	controller.RegisterAuthorRoutes(r)         // /authors
+	controller.RegisterBookRoutes(r)           // /books
+	controller.RegisterPublisherRoutes(r)      // /publishers
+	controller.RegisterSubjectRoutes(r)        // /subjects, /books/:id/subjects
+	controller.RegisterSeriesRoutes(r)         // /series
+	controller.RegisterReviewRoutes(r)         // /books/:id/reviews, /reviews (модерация — AdminOnly)
+	controller.RegisterOPDSRoutes(r)           // /opds (OPDS 1.2 + 2.0 для читалок)
+	controller.RegisterDigitalLoanRoutes(r)    // /books/:id/checkout, /me/loans, /digital/download
+	controller.RegisterReadingListRoutes(r)    // /me/lists, /lists/shared/:token
+	controller.RegisterRecommendationRoutes(r) // /books/:id/similar, /me/recommendations
+	controller.RegisterNotificationRoutes(r)   // /me/notifications, /me/notification-settings
+	controller.RegisterEventStreamRoutes(r)    // /events/stream (SSE, JWT)
+	controller.RegisterAuditRoutes(r)          // /audit (JWT+AdminOnly)
+	controller.RegisterJobRoutes(r)            // /jobs (JWT+AdminOnly)
+	controller.RegisterTaskRoutes(r)           // /tasks (JWT+AdminOnly)
+	controller.RegisterWebhookRoutes(r)        // /webhooks (JWT+AdminOnly)
 
 	// 7) Старт сервера на порту из конфига
-	addr := config.AppSettings.AppParams.PortRun
-	logger.Info.Printf("Starting server on %s", addr)
-	if err := r.Run(addr); err != nil {
-		logger.Error.Fatalf("Server stopped with error: %v", err)
+	srv := newHTTPServer(config.AppSettings.AppParams, r)
+	srv.RegisterOnShutdown(service.CloseEventSubscribers) // SSE-клиенты иначе держали бы Shutdown до таймаута
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info.Printf("Starting server on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	// 8) Плавная остановка по SIGINT/SIGTERM или при ошибке сервера: дожидаемся текущих запросов,
+	// затем фоновых задач; пул соединений с БД закрывается отложенным CloseDB после выхода из main
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case <-sigCtx.Done():
+	case err := <-serveErr:
+		logger.Error.Printf("Server stopped with error: %v", err)
+		exitCode = 1
+	}
+	stopSignals()
+
+	service.MarkNotReady()
+	// Задержка нужна, чтобы балансировщик успел убрать под; если сервер уже упал, ждать нечего
+	if delay := config.AppSettings.AppParams.ShutdownDelaySeconds; delay > 0 && exitCode == 0 {
+		logger.Info.Printf("Shutdown requested, reporting not ready for %ds before draining", delay)
+		time.Sleep(time.Duration(delay) * time.Second)
+	}
+
+	timeout := shutdownTimeout(config.AppSettings.AppParams)
+	logger.Info.Printf("Shutting down, waiting up to %s for in-flight work", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error.Printf("HTTP server shutdown error: %v", err)
+	}
+	stopBackground()
+	if err := service.WaitBackground(shutdownCtx); err != nil {
+		logger.Warn.Printf("Background workers did not stop in time: %v", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn.Printf("Tracing shutdown error: %v", err)
 	}
+	logger.Info.Println("Server stopped")
 }
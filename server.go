@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"Library/internal/models"
+)
+
+// Значения по умолчанию для HTTP-сервера.
+const (
+	defaultReadTimeout       = 2 * time.Minute // загрузка файлов книг
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultWriteTimeout      = 2 * time.Minute
+	defaultIdleTimeout       = 2 * time.Minute
+	defaultMaxHeaderBytes    = 64 << 10
+	defaultShutdownTimeout   = 30 * time.Second
+)
+
+func secondsOr(v int, def time.Duration) time.Duration {
+	if v <= 0 {
+		return def
+	}
+	return time.Duration(v) * time.Second
+}
+
+// newHTTPServer собирает http.Server с таймаутами и лимитом заголовков из AppParams.
+func newHTTPServer(cfg models.AppParams, h http.Handler) *http.Server {
+	maxHeader := cfg.MaxHeaderBytes
+	if maxHeader <= 0 {
+		maxHeader = defaultMaxHeaderBytes
+	}
+	return &http.Server{
+		Addr:              cfg.PortRun,
+		Handler:           h,
+		ReadTimeout:       secondsOr(cfg.ReadTimeoutSeconds, defaultReadTimeout),
+		ReadHeaderTimeout: secondsOr(cfg.ReadHeaderTimeoutSeconds, defaultReadHeaderTimeout),
+		WriteTimeout:      secondsOr(cfg.WriteTimeoutSeconds, defaultWriteTimeout),
+		IdleTimeout:       secondsOr(cfg.IdleTimeoutSeconds, defaultIdleTimeout),
+		MaxHeaderBytes:    maxHeader,
+	}
+}
+
+// shutdownTimeout — сколько ждать завершения запросов и фоновых задач при остановке.
+func shutdownTimeout(cfg models.AppParams) time.Duration {
+	return secondsOr(cfg.ShutdownTimeoutSeconds, defaultShutdownTimeout)
+}
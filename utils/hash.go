@@ -1,12 +1,17 @@
 package utils
 
 import (
+	"context"
+
+	"Library/internal/tracing"
 	"Library/logger"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // HashPassword генерирует bcrypt-хеш из plainPassword
-func HashPassword(plainPassword string) (string, error) {
+func HashPassword(ctx context.Context, plainPassword string) (string, error) {
+	_, span := tracing.Start(ctx, "utils.HashPassword")
+	defer span.End()
 	logger.Debug.Println("HashPassword: start hashing password")
 	hashBytes, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -16,3 +21,10 @@ func HashPassword(plainPassword string) (string, error) {
 	logger.Info.Println("HashPassword: password hashed successfully")
 	return string(hashBytes), nil
 }
+
+// CheckPassword сравнивает пароль с bcrypt-хешем; nil — пароль верный.
+func CheckPassword(ctx context.Context, hash, plainPassword string) error {
+	_, span := tracing.Start(ctx, "utils.CheckPassword")
+	defer span.End()
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(plainPassword))
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"Library/internal/config"
+	"Library/logger"
+)
+
+func downloadMAC(loanID, fileID int, expires int64) []byte {
+	key := config.AppSettings.AuthParams.DownloadSigningKey
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%d:%d:%d", loanID, fileID, expires)
+	return mac.Sum(nil)
+}
+
+// SignDownload подписывает ссылку на скачивание файла по выдаче HMAC-SHA256.
+func SignDownload(loanID, fileID int, expires time.Time) string {
+	return hex.EncodeToString(downloadMAC(loanID, fileID, expires.Unix()))
+}
+
+// VerifyDownload проверяет подпись и срок действия ссылки.
+func VerifyDownload(loanID, fileID int, expires int64, signature string) bool {
+	if time.Now().Unix() > expires {
+		logger.Warn.Printf("VerifyDownload: link expired loanID=%d fileID=%d", loanID, fileID)
+		return false
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		logger.Warn.Printf("VerifyDownload: malformed signature loanID=%d", loanID)
+		return false
+	}
+	if !hmac.Equal(sig, downloadMAC(loanID, fileID, expires)) {
+		logger.Warn.Printf("VerifyDownload: signature mismatch loanID=%d fileID=%d", loanID, fileID)
+		return false
+	}
+	return true
+}